@@ -0,0 +1,428 @@
+// cmd 包实现了 fieldline 命令行程序. 程序由若干子命令组成, 每个子命令读入一个场数据文件,
+// 按指定的网格密度和插值参数构建场, 然后将计算结果(等值线, 流线, 超流线或拓扑结构)写入输出文件.
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"stj/fieldline/fe"
+	"stj/fieldline/field"
+	"stj/fieldline/grid"
+	"stj/fieldline/vtk"
+)
+
+// command 表示 fieldline 程序的一个子命令.
+type command struct {
+	name  string
+	short string
+	run   func(args []string) error
+}
+
+// commands 是所有可用的子命令.
+var commands = []*command{
+	contourCmd,
+	streamlineCmd,
+	hyperstreamlineCmd,
+	topologyCmd,
+}
+
+// Execute 解析命令行参数并执行相应的子命令. 若执行出错, 则打印错误信息并以非零状态退出.
+func Execute() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage(os.Stdout)
+		return
+	}
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(os.Args[2:]); err != nil {
+				if err == flag.ErrHelp {
+					return
+				}
+				fmt.Fprintf(os.Stderr, "fieldline %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "fieldline: unknown command %q\n\n", name)
+	usage(os.Stderr)
+	os.Exit(2)
+}
+
+// usage 打印程序的使用说明.
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: fieldline <command> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", c.name, c.short)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'fieldline <command> -h' for the options of a command.")
+}
+
+// options 是所有子命令共有的命令行选项.
+type options struct {
+	input    string
+	output   string
+	cols     string
+	array    string
+	format   string
+	coords   string
+	compPos  bool
+	method   string
+	maxEdge  float64
+	density  float64
+	maxQty   int
+	idwPower float64
+	radius   float64
+	sector   int
+	aniso    float64
+	anisoDeg float64
+	kernel   string
+	smooth   float64
+	epsilon  float64
+	rbfN     int
+	model    string
+	krigN    int
+	mode     string
+	domain   string
+	alpha    float64
+	workers  int
+	// fieldOpts 为由以上选项得到的创建场所用的参数, 由 apply 方法设置
+	fieldOpts *field.Options
+}
+
+// register 将共有选项注册到 fs 中.
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.input, "i", "", "input field data file")
+	fs.StringVar(&o.output, "o", "-", "output file, '-' for standard output")
+	fs.StringVar(&o.array, "array", "", "point data array used as the field when the input is a VTK file")
+	fs.StringVar(&o.format, "format", "", "finite element result format of the input stress listing, 'abaqus' or 'ansys'")
+	fs.StringVar(&o.coords, "coords", "", "nodal coordinate listing joined with the input stress listing when -format is given")
+	fs.BoolVar(&o.compPos, "comppos", false, "the input stress listing takes compression as positive")
+	fs.StringVar(&o.cols, "cols", "", "column mapping of the input file, e.g. 'x=X,y=Y,xx=S11,yy=S22,xy=S12'")
+	fs.StringVar(&o.method, "method", field.IDWMethod.String(), "interpolation method of scattered data, 'idw', 'linear', 'cloughtocher', 'sibson', 'rbf' or 'kriging'")
+	fs.Float64Var(&o.maxEdge, "maxedge", 0.0, "maximum boundary edge length of the triangulation used by 'linear', 'cloughtocher' and 'sibson', 0 for the convex hull")
+	def := field.DefaultOptions()
+	fs.Float64Var(&o.density, "density", def.Density, "average number of quantities per grid cell")
+	fs.IntVar(&o.maxQty, "maxqty", def.IDW.MaxQty, "maximum number of quantities used by one interpolation")
+	fs.Float64Var(&o.idwPower, "power", def.IDW.Power, "power parameter of the IDW interpolation")
+	fs.Float64Var(&o.radius, "radius", def.IDW.Radius, "search radius of the IDW interpolation, 0 for the one derived from -maxqty and -density")
+	fs.IntVar(&o.sector, "sector", def.IDW.Sector, "maximum number of quantities per quadrant used by the IDW interpolation, 0 to disable the sector search")
+	fs.Float64Var(&o.aniso, "aniso", 1.0, "anisotropy ratio of the IDW interpolation, i.e. how many times farther a quantity reaches along -anisoangle")
+	fs.Float64Var(&o.anisoDeg, "anisoangle", 0.0, "direction of the IDW anisotropy in degrees from the x axis")
+	fs.StringVar(&o.kernel, "kernel", def.RBF.Kernel.String(), "kernel of the RBF interpolation, 'thinplate', 'multiquadric', 'inversemultiquadric' or 'gaussian'")
+	fs.Float64Var(&o.smooth, "smooth", def.RBF.Smooth, "smoothing parameter of the RBF interpolation, 0 for exact interpolation")
+	fs.Float64Var(&o.epsilon, "epsilon", def.RBF.Epsilon, "shape parameter of the RBF kernel, 0 for the mean spacing of the points")
+	fs.IntVar(&o.rbfN, "rbfn", def.RBF.Neighbors, "number of nearest neighbors used by the local RBF interpolation, 0 for a global fit")
+	fs.StringVar(&o.mode, "tensormode", field.ComponentMode.String(), "interpolation mode of tensors, 'component', 'logeuclidean' or 'eigen'")
+	fs.StringVar(&o.model, "variogram", def.Kriging.Model.String(), "variogram model fitted for the kriging interpolation, 'spherical', 'exponential' or 'gaussian'")
+	fs.StringVar(&o.domain, "domain", "", "polygon file of the field domain (outer boundary followed by holes), or 'alpha' for the alpha shape of the data points")
+	fs.Float64Var(&o.alpha, "alpha", 0.0, "circumradius limit of the alpha shape used by '-domain alpha', 0 for twice the median point spacing")
+	fs.IntVar(&o.workers, "workers", def.Workers, "number of goroutines used to interpolate the grid nodes and trace the lines, 0 for the number of CPUs")
+	fs.IntVar(&o.krigN, "krign", def.Kriging.Neighbors, "number of nearest neighbors used by the local kriging interpolation, 0 for all the points")
+}
+
+// apply 检查共有选项, 并由其生成创建场所用的参数.
+func (o *options) apply() error {
+	if o.input == "" {
+		return errors.New("no input file given, use -i to specify one")
+	}
+	fo := field.DefaultOptions()
+	fo.Density = o.density
+	fo.IDW = field.IDWConfig{Power: o.idwPower, MaxQty: o.maxQty, Radius: o.radius, Sector: o.sector,
+		Ratio: o.aniso, Angle: o.anisoDeg * math.Pi / 180.0}
+	fo.Workers = o.workers
+	kernel, err := field.ParseKernel(o.kernel)
+	if err != nil {
+		return err
+	}
+	fo.RBF = field.RBF{Kernel: kernel, Epsilon: o.epsilon, Smooth: o.smooth, Neighbors: o.rbfN}
+	if fo.Kriging.Model, err = field.ParseVariogramModel(o.model); err != nil {
+		return err
+	}
+	fo.Kriging.Neighbors = o.krigN
+	if err := fo.Validate(); err != nil {
+		return err
+	}
+	if _, err := field.ParseMethod(o.method); err != nil {
+		return err
+	}
+	if o.maxEdge < 0.0 {
+		return errors.New("the maximum edge length should not be negative")
+	}
+	if _, err := field.ParseTensorIntrplMode(o.mode); err != nil {
+		return err
+	}
+	if o.alpha < 0.0 {
+		return errors.New("the alpha value should not be negative")
+	}
+	if o.format != "" && o.coords == "" {
+		return errors.New("no nodal coordinate listing given, use -coords to specify one")
+	}
+	o.fieldOpts = fo
+	return nil
+}
+
+// create 创建输出文件. 若输出文件为 "-", 则输出到标准输出.
+func (o *options) create() (io.WriteCloser, error) {
+	if o.output == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(o.output)
+}
+
+// loadTensorField 读入输入文件并解析为一个张量场, 并设置其插值方法和插值方式.
+func (o *options) loadTensorField() (*field.TensorField, error) {
+	tf, err := o.readTensorField()
+	if err != nil {
+		return nil, err
+	}
+	mode, err := field.ParseTensorIntrplMode(o.mode)
+	if err != nil {
+		return nil, err
+	}
+	tf.SetIntrplMode(mode)
+	if err := o.setDomain(tf); err != nil {
+		return nil, err
+	}
+	return tf, o.setMethod(tf)
+}
+
+// readTensorField 读入输入文件并解析为一个张量场.
+func (o *options) readTensorField() (*field.TensorField, error) {
+	if o.format != "" {
+		return o.loadFE()
+	}
+	f, err := os.Open(o.input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if isVTK(o.input) {
+		d, err := vtk.Read(f)
+		if err != nil {
+			return nil, err
+		}
+		return d.TensorField(o.array, o.fieldOpts)
+	}
+	if o.cols == "" {
+		return field.ReadTensorData(f, o.fieldOpts)
+	}
+	t, err := o.readTable(f)
+	if err != nil {
+		return nil, err
+	}
+	return t.TensorField(o.fieldOpts)
+}
+
+// loadScalarField 读入输入文件并解析为一个标量场, 并设置其插值方法.
+func (o *options) loadScalarField() (*field.ScalarField, error) {
+	sf, err := o.readScalarField()
+	if err != nil {
+		return nil, err
+	}
+	if err := o.setDomain(sf); err != nil {
+		return nil, err
+	}
+	return sf, o.setMethod(sf)
+}
+
+// readScalarField 读入输入文件并解析为一个标量场.
+func (o *options) readScalarField() (*field.ScalarField, error) {
+	if o.format != "" {
+		return nil, errors.New("finite element stress listings can only be read as tensor fields")
+	}
+	f, err := os.Open(o.input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if isVTK(o.input) {
+		d, err := vtk.Read(f)
+		if err != nil {
+			return nil, err
+		}
+		return d.ScalarField(o.array, o.fieldOpts)
+	}
+	if o.cols == "" {
+		return field.ReadScalarData(f, o.fieldOpts)
+	}
+	t, err := o.readTable(f)
+	if err != nil {
+		return nil, err
+	}
+	return t.ScalarField(o.fieldOpts)
+}
+
+// loadVectorField 读入输入文件并解析为一个向量场, 并设置其插值方法.
+func (o *options) loadVectorField() (*field.VectorField, error) {
+	vf, err := o.readVectorField()
+	if err != nil {
+		return nil, err
+	}
+	if err := o.setDomain(vf); err != nil {
+		return nil, err
+	}
+	return vf, o.setMethod(vf)
+}
+
+// readVectorField 读入输入文件并解析为一个向量场.
+func (o *options) readVectorField() (*field.VectorField, error) {
+	if o.format != "" {
+		return nil, errors.New("finite element stress listings can only be read as tensor fields")
+	}
+	f, err := os.Open(o.input)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if isVTK(o.input) {
+		d, err := vtk.Read(f)
+		if err != nil {
+			return nil, err
+		}
+		return d.VectorField(o.array, o.fieldOpts)
+	}
+	if o.cols == "" {
+		return field.ReadVectorData(f, o.fieldOpts)
+	}
+	t, err := o.readTable(f)
+	if err != nil {
+		return nil, err
+	}
+	return t.VectorField(o.fieldOpts)
+}
+
+// loadFE 读入 -coords 指定的节点坐标列表和输入文件中的节点应力列表, 并按节点编号将二者连接为一个张量场.
+func (o *options) loadFE() (*field.TensorField, error) {
+	format, err := fe.ParseFormat(o.format)
+	if err != nil {
+		return nil, err
+	}
+	cf, err := os.Open(o.coords)
+	if err != nil {
+		return nil, err
+	}
+	defer cf.Close()
+	coords, err := fe.ReadCoords(cf, format)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", o.coords, err)
+	}
+	sf, err := os.Open(o.input)
+	if err != nil {
+		return nil, err
+	}
+	defer sf.Close()
+	stress, err := fe.ReadStress(sf, format, fe.Convention{CompressionPositive: o.compPos})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", o.input, err)
+	}
+	return fe.Join(coords, stress, o.fieldOpts)
+}
+
+// intrplField 是可以设置插值方法的场.
+type intrplField interface {
+	SetMethod(m field.Method)
+	Triangulate(maxEdge float64) error
+}
+
+// setMethod 按 -method 和 -maxedge 选项设置场的插值方法.
+func (o *options) setMethod(f intrplField) error {
+	m, err := field.ParseMethod(o.method)
+	if err != nil {
+		return err
+	}
+	f.SetMethod(m)
+	if m == field.IDWMethod || m == field.RBFMethod || m == field.KrigingMethod || o.maxEdge == 0.0 {
+		return nil
+	}
+	return f.Triangulate(o.maxEdge)
+}
+
+// domainField 是可以设置定义域的场.
+type domainField interface {
+	SetDomain(d *grid.Domain)
+	AlphaShape(alpha float64) (*grid.Domain, error)
+}
+
+// setDomain 按 -domain 和 -alpha 选项设置场的定义域.
+func (o *options) setDomain(f domainField) error {
+	switch o.domain {
+	case "":
+		return nil
+	case "alpha":
+		d, err := f.AlphaShape(o.alpha)
+		if err != nil {
+			return err
+		}
+		f.SetDomain(d)
+		return nil
+	}
+	df, err := os.Open(o.domain)
+	if err != nil {
+		return err
+	}
+	defer df.Close()
+	d, err := grid.ReadDomain(df)
+	if err != nil {
+		return fmt.Errorf("%s: %v", o.domain, err)
+	}
+	f.SetDomain(d)
+	return nil
+}
+
+// isVTK 根据文件扩展名判断文件是否为 VTK 文件.
+func isVTK(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".vtk", ".vtu", ".vtp":
+		return true
+	}
+	return false
+}
+
+// readTable 按 -cols 选项指定的列映射读入数据表, 并在标准错误输出中报告被舍弃的行.
+func (o *options) readTable(r io.Reader) (*field.Table, error) {
+	cols, err := field.ParseColumns(o.cols)
+	if err != nil {
+		return nil, err
+	}
+	t, err := field.ReadTableFrom(r, cols)
+	if err != nil {
+		return nil, err
+	}
+	for _, le := range t.Rejected {
+		fmt.Fprintf(os.Stderr, "fieldline: %s: %v\n", o.input, le)
+	}
+	return t, nil
+}
+
+// parse 解析子命令的参数. 子命令不接受选项以外的参数.
+func parse(fs *flag.FlagSet, o *options, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	return o.apply()
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"stj/fieldline/geom"
+)
+
+func TestSeedPoints(t *testing.T) {
+	r, _ := geom.NewRect(0.0, 0.0, 4.0, 2.0)
+	ps := seedPoints(r, 2)
+	want := []geom.Point{{X: 1.0, Y: 0.5}, {X: 3.0, Y: 0.5}, {X: 1.0, Y: 1.5}, {X: 3.0, Y: 1.5}}
+	if len(ps) != len(want) {
+		t.Fatalf("got %d seed points, want %d", len(ps), len(want))
+	}
+	for i := range ps {
+		if ps[i] != want[i] {
+			t.Errorf("seed point %d is %v, want %v", i, ps[i], want[i])
+		}
+	}
+}
+
+func TestContourValues(t *testing.T) {
+	vs, err := contourValues(nil, "1, 2.5,-3", 0)
+	if err != nil || len(vs) != 3 || vs[0] != 1.0 || vs[1] != 2.5 || vs[2] != -3.0 {
+		t.Errorf("func contourValues wrong: %v, %v", vs, err)
+	}
+	if _, err := contourValues(nil, "1,a", 0); err == nil {
+		t.Error("func contourValues should reject invalid values")
+	}
+}
+
+// TestStreamline 检查 streamline 子命令能够读入向量场数据并输出流线.
+func TestStreamline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fieldline")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+	in, out := filepath.Join(dir, "vector.dat"), filepath.Join(dir, "lines.txt")
+	var b strings.Builder
+	for x := 0; x <= 10; x++ {
+		for y := 0; y <= 10; y++ {
+			// 绕点 (5, 5) 旋转的向量场
+			fmt.Fprintf(&b, "%d, %d, %d, %d\n", x, y, 5-y, x-5)
+		}
+	}
+	if err := ioutil.WriteFile(in, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := runStreamline([]string{"-i", in, "-o", out, "-seeds", "2", "-steps", "50"}); err != nil {
+		t.Fatal(err.Error())
+	}
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if n := strings.Count(string(data), "# seed:"); n == 0 {
+		t.Errorf("no streamline written:\n%s", data)
+	}
+}
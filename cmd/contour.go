@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"stj/fieldline/field"
+)
+
+var contourCmd = &command{
+	name:  "contour",
+	short: "draw contour lines of a scalar field or a tensor component",
+	run:   runContour,
+}
+
+// tensorComps 将命令行中的张量分量名称映射为 field 包中的分量类型.
+var tensorComps = map[string]int{
+	"xx":  field.TXX,
+	"yy":  field.TYY,
+	"xy":  field.TXY,
+	"ev1": field.TEV1,
+	"ev2": field.TEV2,
+}
+
+func runContour(args []string) error {
+	var o options
+	fs := flag.NewFlagSet("contour", flag.ContinueOnError)
+	o.register(fs)
+	kind := fs.String("field", "tensor", "type of the input field: scalar or tensor")
+	comp := fs.String("comp", "xx", "tensor component to be contoured: xx, yy, xy, ev1 or ev2")
+	levels := fs.Int("levels", 10, "number of contour levels evenly distributed between the minimum and maximum value")
+	values := fs.String("values", "", "comma separated contour values, overrides -levels")
+	variance := fs.Bool("variance", false, "contour the kriging variance of the field instead of the field itself")
+	if err := parse(fs, &o, args); err != nil {
+		return err
+	}
+	var sf *field.ScalarField
+	var err error
+	switch *kind {
+	case "scalar":
+		*comp = "v"
+		if sf, err = o.loadScalarField(); err != nil {
+			return err
+		}
+		if err = sf.GenNodes(); err != nil {
+			return err
+		}
+	case "tensor":
+		if sf, err = o.loadTensorComp(*comp); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown field type %q", *kind)
+	}
+	if *variance {
+		*comp += " variance"
+		if sf, err = sf.GenFieldOfKrigingVariance(); err != nil {
+			return err
+		}
+	}
+	vs, err := contourValues(sf, *values, *levels)
+	if err != nil {
+		return err
+	}
+	return o.write(func(w *bufio.Writer) error {
+		for _, v := range vs {
+			lines, err := sf.Contour(v)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "# %s = %g\n", *comp, v)
+			writeSegments(w, lines)
+		}
+		return nil
+	})
+}
+
+// loadTensorComp 读入张量场, 并由其分量 comp 生成一个标量场.
+func (o *options) loadTensorComp(comp string) (*field.ScalarField, error) {
+	ct, ok := tensorComps[comp]
+	if !ok {
+		return nil, fmt.Errorf("unknown tensor component %q", comp)
+	}
+	tf, err := o.loadTensorField()
+	if err != nil {
+		return nil, err
+	}
+	if ct == field.TEV1 || ct == field.TEV2 {
+		tf.Align()
+	}
+	return tf.GenFieldOfComp(ct)
+}
+
+// contourValues 确定等值线的取值. 若 values 不为空, 则从中解析出各个值;
+// 否则在标量场的最小值和最大值之间均匀地取 levels 个值(不含两端).
+func contourValues(sf *field.ScalarField, values string, levels int) ([]float64, error) {
+	if values != "" {
+		var vs []float64
+		for _, s := range strings.Split(values, ",") {
+			v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid contour value %q", s)
+			}
+			vs = append(vs, v)
+		}
+		return vs, nil
+	}
+	if levels <= 0 {
+		return nil, errors.New("the number of contour levels should be greater than zero")
+	}
+	min, max, err := sf.MinMax()
+	if err != nil {
+		return nil, err
+	}
+	vs := make([]float64, levels)
+	for i := 0; i < levels; i++ {
+		vs[i] = min + (max-min)*float64(i+1)/float64(levels+1)
+	}
+	return vs, nil
+}
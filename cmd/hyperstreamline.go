@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/ode"
+)
+
+var hyperstreamlineCmd = &command{
+	name:  "hyperstreamline",
+	short: "trace hyperstreamlines (principal stress trajectories) of a tensor field",
+	run:   runHyperstreamline,
+}
+
+func runHyperstreamline(args []string) error {
+	var o options
+	fs := flag.NewFlagSet("hyperstreamline", flag.ContinueOnError)
+	o.register(fs)
+	family := fs.Int("family", 1, "eigenvector family to be traced: 1 for the major, 2 for the minor")
+	seeds := fs.Int("seeds", 10, "number of seed points along each axis of the field range")
+	steps := fs.Int("steps", 500, "maximum number of integration steps in each direction")
+	if err := parse(fs, &o, args); err != nil {
+		return err
+	}
+	if *family != 1 && *family != 2 {
+		return errors.New("the eigenvector family should be 1 or 2")
+	}
+	if *seeds <= 0 || *steps <= 0 {
+		return errors.New("the number of seeds and steps should be greater than zero")
+	}
+	tf, err := o.loadTensorField()
+	if err != nil {
+		return err
+	}
+	tf.Align()
+	if err := tf.GenNodes(); err != nil {
+		return err
+	}
+	ed := tf.ED1
+	if *family == 2 {
+		ed = tf.ED2
+	}
+	// 超流线的斜率即为特征向量方向角的正切.
+	slope := ode.ODE(func(x, y float64) (float64, error) {
+		d, err := ed(x, y)
+		if err != nil {
+			return 0.0, err
+		}
+		return math.Tan(d), nil
+	})
+	ss := seedPoints(tf.Range(), *seeds)
+	lines := traceAll(ss, o.fieldOpts.Workers, func(it *ode.Integrator, s geom.Point) []geom.Point {
+		points, _ := it.Solve(slope, s.X, s.Y, *steps)
+		return points
+	})
+	return o.write(func(w *bufio.Writer) error {
+		for i, s := range ss {
+			if len(lines[i]) < 2 {
+				continue
+			}
+			fmt.Fprintf(w, "# seed: %g %g\n", s.X, s.Y)
+			writePolyline(w, lines[i])
+		}
+		return nil
+	})
+}
+
+// traceAll 在 workers 个 goroutine 中同时由各个种子点 ss 追踪流线, workers <= 0 时取 CPU 的个数,
+// 每个 goroutine 使用一个自己的积分器. 返回的各条流线与种子点一一对应, 因而结果与追踪的先后顺序无关.
+func traceAll(ss []geom.Point, workers int, trace func(it *ode.Integrator, s geom.Point) []geom.Point) [][]geom.Point {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	lines := make([][]geom.Point, len(ss))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			it := ode.NewIntegrator(nil)
+			for i := range next {
+				lines[i] = trace(it, ss[i])
+			}
+		}()
+	}
+	for i := range ss {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return lines
+}
+
+// seedPoints 在矩形 r 内均匀布置 n*n 个种子点, 各点位于等分后小矩形的中心.
+func seedPoints(r *geom.Rect, n int) []geom.Point {
+	ps := make([]geom.Point, 0, n*n)
+	dx := (r.Xmax - r.Xmin) / float64(n)
+	dy := (r.Ymax - r.Ymin) / float64(n)
+	for yi := 0; yi < n; yi++ {
+		for xi := 0; xi < n; xi++ {
+			ps = append(ps, *geom.NewPoint(r.Xmin+(float64(xi)+0.5)*dx, r.Ymin+(float64(yi)+0.5)*dy))
+		}
+	}
+	return ps
+}
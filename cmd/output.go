@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+
+	"stj/fieldline/geom"
+)
+
+// 输出文件为纯文本格式: 每行一个点的 "x y" 坐标, 各条曲线之间以空行分隔, 以 # 开头的行为注释.
+// 该格式可直接被 gnuplot 等绘图程序读取.
+
+// writePolyline 将一条由 points 组成的多段线写入 w.
+func writePolyline(w *bufio.Writer, points []geom.Point) {
+	for _, p := range points {
+		fmt.Fprintf(w, "%g %g\n", p.X, p.Y)
+	}
+	fmt.Fprintln(w)
+}
+
+// writeSegments 将一系列线段写入 w, 每条线段作为一条独立的曲线.
+func writeSegments(w *bufio.Writer, lines []*geom.Line) {
+	for _, l := range lines {
+		fmt.Fprintf(w, "%g %g\n%g %g\n\n", l.X1, l.Y1, l.X2, l.Y2)
+	}
+}
+
+// write 创建输出文件, 调用 fn 写入内容, 然后关闭文件.
+func (o *options) write(fn func(w *bufio.Writer) error) (err error) {
+	f, err := o.create()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	w := bufio.NewWriter(f)
+	if err = fn(w); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/ode"
+)
+
+var streamlineCmd = &command{
+	name:  "streamline",
+	short: "trace streamlines of a vector field",
+	run:   runStreamline,
+}
+
+func runStreamline(args []string) error {
+	var o options
+	fs := flag.NewFlagSet("streamline", flag.ContinueOnError)
+	o.register(fs)
+	seeds := fs.Int("seeds", 10, "number of seed points along each axis of the field range")
+	steps := fs.Int("steps", 500, "maximum number of integration steps in each direction")
+	if err := parse(fs, &o, args); err != nil {
+		return err
+	}
+	if *seeds <= 0 || *steps <= 0 {
+		return errors.New("the number of seeds and steps should be greater than zero")
+	}
+	vf, err := o.loadVectorField()
+	if err != nil {
+		return err
+	}
+	if err := vf.GenNodes(); err != nil {
+		return err
+	}
+	// 流线的斜率即为向量的斜率, 积分在向量为零的临界点处终止.
+	slope := ode.ODE(vf.Slope)
+	ss := seedPoints(vf.Range(), *seeds)
+	lines := traceAll(ss, o.fieldOpts.Workers, func(it *ode.Integrator, s geom.Point) []geom.Point {
+		points, _ := it.Solve(slope, s.X, s.Y, *steps)
+		return points
+	})
+	return o.write(func(w *bufio.Writer) error {
+		for i, s := range ss {
+			if len(lines[i]) < 2 {
+				continue
+			}
+			fmt.Fprintf(w, "# seed: %g %g\n", s.X, s.Y)
+			writePolyline(w, lines[i])
+		}
+		return nil
+	})
+}
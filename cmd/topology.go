@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+
+	"stj/fieldline/grid"
+)
+
+var topologyCmd = &command{
+	name:  "topology",
+	short: "classify the degenerate points and regions of a tensor field or extract the skeleton of a vector field",
+	run:   runTopology,
+}
+
+// regionNames 是各类退化形状在输出文件中的名称.
+var regionNames = map[int]string{
+	grid.PointRegionType:     "point",
+	grid.CurveRegionType:     "curve",
+	grid.RegionRegionType:    "region",
+	grid.CompositeRegionType: "composite",
+}
+
+func runTopology(args []string) error {
+	var o options
+	fs := flag.NewFlagSet("topology", flag.ContinueOnError)
+	o.register(fs)
+	kind := fs.String("field", "tensor", "type of the input field: vector or tensor")
+	steps := fs.Int("steps", 2000, "maximum number of integration steps of each separatrix")
+	if err := parse(fs, &o, args); err != nil {
+		return err
+	}
+	if *steps <= 0 {
+		return errors.New("the number of steps should be greater than zero")
+	}
+	switch *kind {
+	case "vector":
+		return vectorTopology(&o, *steps)
+	case "tensor":
+		return tensorTopology(&o, *steps)
+	default:
+		return fmt.Errorf("unknown field type %q", *kind)
+	}
+}
+
+// tensorTopology 输出张量场的拓扑骨架: 先输出各退化点, 再输出由退化点出发的两族特征向量的各条分界线,
+// 最后输出由零节点构成的退化曲线和区域. 分界线注释中的 from 和 to 为退化点的序号(从 0 开始),
+// to 为 -1 时表示分界线不终止于退化点.
+func tensorTopology(o *options, steps int) error {
+	tf, err := o.loadTensorField()
+	if err != nil {
+		return err
+	}
+	if err := tf.GenNodes(); err != nil {
+		return err
+	}
+	sk, err := tf.Skeleton(nil, steps)
+	if err != nil {
+		return err
+	}
+	df := tf.GenFieldOfEVDiff()
+	zni, err := df.ZeroNodeIdxes()
+	if err != nil {
+		return err
+	}
+	g := df.Grid()
+	return o.write(func(w *bufio.Writer) error {
+		for i, d := range sk.Points {
+			fmt.Fprintf(w, "# degenerate %d: %s, index %g\n%g %g\n\n", i, d.Kind, d.Index(), d.X, d.Y)
+		}
+		for _, s := range sk.Separatrices {
+			fmt.Fprintf(w, "# separatrix family %d: from %d to %d, end at %s\n", s.Family, s.From, s.To, s.End)
+			writePolyline(w, s.Points)
+		}
+		// 孤立的退化点已由 DegeneratePoints 精确求得, 这里只输出由零节点构成的退化曲线和区域
+		for _, nodes := range zni {
+			r, err := g.ParseZeroNode(nodes)
+			if err != nil {
+				return err
+			}
+			if r.Type() == grid.PointRegionType {
+				continue
+			}
+			fmt.Fprintf(w, "# degenerate %s\n", regionNames[r.Type()])
+			for _, ni := range nodes {
+				fmt.Fprintf(w, "%g %g\n", g.Nodes[ni].X, g.Nodes[ni].Y)
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	})
+}
+
+// vectorTopology 输出向量场的拓扑骨架: 先输出各临界点, 再输出由鞍点出发的各条分界线.
+// 分界线注释中的 from 和 to 为临界点的序号(从 0 开始), to 为 -1 时表示分界线不终止于临界点.
+func vectorTopology(o *options, steps int) error {
+	vf, err := o.loadVectorField()
+	if err != nil {
+		return err
+	}
+	if err := vf.GenNodes(); err != nil {
+		return err
+	}
+	sk, err := vf.Skeleton(nil, steps)
+	if err != nil {
+		return err
+	}
+	return o.write(func(w *bufio.Writer) error {
+		for i, c := range sk.Points {
+			fmt.Fprintf(w, "# critical %d: %s, index %g\n%g %g\n\n", i, c.Kind, c.Index(), c.X, c.Y)
+		}
+		for _, s := range sk.Separatrices {
+			dir := "incoming"
+			if s.Outgoing {
+				dir = "outgoing"
+			}
+			fmt.Fprintf(w, "# separatrix %s: from %d to %d, end at %s\n", dir, s.From, s.To, s.End)
+			writePolyline(w, s.Points)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,88 @@
+package delaunay
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"stj/fieldline/geom"
+)
+
+// AlphaShape 方法返回点集的 alpha 形状(一种凹包)的边界: 外接圆半径大于 alpha 的三角形被舍弃,
+// 其余三角形的并集的边界即为 alpha 形状的边界. 与 Concave 方法不同, 它不仅能使外边界凹进, 还能得到数据点中的孔洞.
+// 若 alpha <= 0, 则取三角剖分各边长度的中位数的 2 倍. 返回的每个环为一个闭合的边界, 外边界按逆时针排列,
+// 孔洞的边界按顺时针排列. 若没有三角形被保留, 则返回一个错误.
+func (t *Triangulation) AlphaShape(alpha float64) ([]geom.Polygon, error) {
+	if alpha <= 0.0 {
+		alpha = 2.0 * t.medianEdge()
+	}
+	type edge struct{ a, b int }
+	edges := map[edge]bool{}
+	for _, tr := range t.Triangles {
+		if circumradius(t.Points[tr[0]], t.Points[tr[1]], t.Points[tr[2]]) > alpha {
+			continue
+		}
+		for k := 0; k < 3; k++ {
+			edges[edge{tr[k], tr[(k+1)%3]}] = true
+		}
+	}
+	if len(edges) == 0 {
+		return nil, errors.New("no triangle is kept in the alpha shape, the alpha value may be too small")
+	}
+	// 只属于一个三角形的边为边界边, out[a] 为从点 a 出发的边界边的终点. 两个区域在一点相接时, 该点有多条边界边.
+	out := map[int][]int{}
+	var starts []int
+	for e := range edges {
+		if !edges[edge{e.b, e.a}] {
+			out[e.a] = append(out[e.a], e.b)
+		}
+	}
+	for a, bs := range out {
+		sort.Ints(bs) // 保证结果与 map 的遍历顺序无关
+		starts = append(starts, a)
+	}
+	sort.Ints(starts)
+	var rings []geom.Polygon
+	for _, s := range starts {
+		for len(out[s]) > 0 {
+			var ring geom.Polygon
+			for a := s; ; {
+				ring = append(ring, t.Points[a])
+				b := out[a][0]
+				out[a] = out[a][1:]
+				if a = b; a == s {
+					break
+				}
+			}
+			rings = append(rings, ring)
+		}
+	}
+	return rings, nil
+}
+
+// circumradius 返回三角形 abc 的外接圆半径.
+func circumradius(a, b, c geom.Point) float64 {
+	s := math.Abs(cross(a, b, c)) // 面积的 2 倍
+	if s == 0.0 {
+		return math.Inf(1)
+	}
+	return a.DistTo(&b) * b.DistTo(&c) * c.DistTo(&a) / (2.0 * s)
+}
+
+// medianEdge 方法返回三角剖分各边长度的中位数.
+func (t *Triangulation) medianEdge() float64 {
+	var ls []float64
+	for _, tr := range t.Triangles {
+		for k := 0; k < 3; k++ {
+			a, b := tr[k], tr[(k+1)%3]
+			if a < b { // 内部的边只计一次
+				ls = append(ls, t.Points[a].DistTo(&t.Points[b]))
+			}
+		}
+	}
+	if len(ls) == 0 {
+		return 0.0
+	}
+	sort.Float64s(ls)
+	return ls[len(ls)/2]
+}
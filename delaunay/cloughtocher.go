@@ -0,0 +1,153 @@
+package delaunay
+
+import (
+	"fmt"
+
+	"stj/fieldline/geom"
+)
+
+// CloughTocher 实现了基于三角剖分的 Clough-Tocher C1 插值. 其构造方法参见:
+//
+// Alfeld, P. (1984). A trivariate Clough-Tocher scheme for tetrahedral data.
+// Computer Aided Geometric Design, 1(2), 169-181.
+//
+// 以及 scipy.interpolate.CloughTocher2DInterpolator 的实现.
+type CloughTocher struct {
+	t     *Triangulation
+	vs    []float64
+	grads [][2]float64 // 各点处的梯度
+}
+
+// NewCloughTocher 根据三角剖分 t 和各点处的值 vs 创建一个 Clough-Tocher 插值器.
+// 各点处的梯度取其周围各三角形中线性插值函数梯度以面积为权的加权平均值.
+func NewCloughTocher(t *Triangulation, vs []float64) (*CloughTocher, error) {
+	if len(vs) != len(t.Points) {
+		return nil, fmt.Errorf("%d values given for %d points", len(vs), len(t.Points))
+	}
+	grads := make([][2]float64, len(t.Points))
+	weights := make([]float64, len(t.Points))
+	for _, tr := range t.Triangles {
+		a, b, c := t.Points[tr[0]], t.Points[tr[1]], t.Points[tr[2]]
+		det := cross(a, b, c)
+		if det == 0.0 {
+			continue
+		}
+		va, vb, vc := vs[tr[0]], vs[tr[1]], vs[tr[2]]
+		// 平面 v = gx*x + gy*y + c 的梯度
+		gx := ((vb-va)*(c.Y-a.Y) - (vc-va)*(b.Y-a.Y)) / det
+		gy := ((vc-va)*(b.X-a.X) - (vb-va)*(c.X-a.X)) / det
+		area := 0.5 * det
+		for _, v := range tr {
+			grads[v][0] += area * gx
+			grads[v][1] += area * gy
+			weights[v] += area
+		}
+	}
+	for i, w := range weights {
+		if w > 0.0 {
+			grads[i][0] /= w
+			grads[i][1] /= w
+		}
+	}
+	return &CloughTocher{t: t, vs: vs, grads: grads}, nil
+}
+
+// Value 方法返回点 (x, y) 处的插值. 若该点在三角剖分的范围之外, 则返回一个错误.
+func (ct *CloughTocher) Value(x, y float64) (float64, error) {
+	ti, b, err := ct.t.Locate(x, y)
+	if err != nil {
+		return 0.0, err
+	}
+	t := ct.t
+	tr := t.Triangles[ti]
+	p1, p2, p3 := t.Points[tr[0]], t.Points[tr[1]], t.Points[tr[2]]
+	f1, f2, f3 := ct.vs[tr[0]], ct.vs[tr[1]], ct.vs[tr[2]]
+	g1, g2, g3 := ct.grads[tr[0]], ct.grads[tr[1]], ct.grads[tr[2]]
+
+	e12x, e12y := p2.X-p1.X, p2.Y-p1.Y
+	e23x, e23y := p3.X-p2.X, p3.Y-p2.Y
+	e31x, e31y := p1.X-p3.X, p1.Y-p3.Y
+
+	// 各顶点沿相邻两边的方向导数(乘以边长)
+	df12 := g1[0]*e12x + g1[1]*e12y
+	df21 := -(g2[0]*e12x + g2[1]*e12y)
+	df23 := g2[0]*e23x + g2[1]*e23y
+	df32 := -(g3[0]*e23x + g3[1]*e23y)
+	df31 := g3[0]*e31x + g3[1]*e31y
+	df13 := -(g1[0]*e31x + g1[1]*e31y)
+
+	// Bezier 控制点, 下标依次为三个顶点和形心的幂次
+	c3000 := f1
+	c2100 := (df12 + 3*c3000) / 3
+	c2010 := (df13 + 3*c3000) / 3
+	c0300 := f2
+	c1200 := (df21 + 3*c0300) / 3
+	c0210 := (df23 + 3*c0300) / 3
+	c0030 := f3
+	c1020 := (df31 + 3*c0030) / 3
+	c0120 := (df32 + 3*c0030) / 3
+
+	c2001 := (c2100 + c2010 + c3000) / 3
+	c0201 := (c1200 + c0300 + c0210) / 3
+	c0021 := (c1020 + c0120 + c0030) / 3
+
+	// 使样条沿各边的跨边方向导数为线性函数, 从而与相邻三角形 C1 连续. 跨边方向取为由边的中点指向
+	// 相邻三角形形心的方向; 在边界上, 取为指向本三角形形心的方向.
+	var g [3]float64
+	for k := 0; k < 3; k++ {
+		ni := t.Neighbors[ti][k]
+		if ni < 0 {
+			g[k] = -0.5
+			continue
+		}
+		nt := t.Triangles[ni]
+		cx := (t.Points[nt[0]].X + t.Points[nt[1]].X + t.Points[nt[2]].X) / 3
+		cy := (t.Points[nt[0]].Y + t.Points[nt[1]].Y + t.Points[nt[2]].Y) / 3
+		c := barycentric(p1, p2, p3, cx, cy)
+		switch k {
+		case 0:
+			g[k] = (2*c[2] + c[1] - 1) / (2 - 3*c[2] - 3*c[1])
+		case 1:
+			g[k] = (2*c[0] + c[2] - 1) / (2 - 3*c[0] - 3*c[2])
+		case 2:
+			g[k] = (2*c[1] + c[0] - 1) / (2 - 3*c[1] - 3*c[0])
+		}
+	}
+
+	c0111 := (g[0]*(-c0300+3*c0210-3*c0120+c0030) + (-c0300 + 2*c0210 - c0120 + c0021 + c0201)) / 2
+	c1011 := (g[1]*(-c0030+3*c1020-3*c2010+c3000) + (-c0030 + 2*c1020 - c2010 + c2001 + c0021)) / 2
+	c1101 := (g[2]*(-c3000+3*c2100-3*c1200+c0300) + (-c3000 + 2*c2100 - c1200 + c2001 + c0201)) / 2
+
+	c1002 := (c1101 + c1011 + c2001) / 3
+	c0102 := (c1101 + c0111 + c0201) / 3
+	c0012 := (c1011 + c0111 + c0021) / 3
+
+	c0003 := (c1002 + c0102 + c0012) / 3
+
+	// 点所在子三角形中的面积坐标, 其中 b4 对应形心, 另外三个中必有一个为 0
+	m := b[0]
+	if b[1] < m {
+		m = b[1]
+	}
+	if b[2] < m {
+		m = b[2]
+	}
+	b1, b2, b3, b4 := b[0]-m, b[1]-m, b[2]-m, 3*m
+
+	return b1*b1*b1*c3000 + 3*b1*b1*b2*c2100 + 3*b1*b1*b3*c2010 +
+		3*b1*b1*b4*c2001 + 3*b1*b2*b2*c1200 +
+		6*b1*b2*b4*c1101 + 3*b1*b3*b3*c1020 + 6*b1*b3*b4*c1011 +
+		3*b1*b4*b4*c1002 + b2*b2*b2*c0300 + 3*b2*b2*b3*c0210 +
+		3*b2*b2*b4*c0201 + 3*b2*b3*b3*c0120 + 6*b2*b3*b4*c0111 +
+		3*b2*b4*b4*c0102 + b3*b3*b3*c0030 + 3*b3*b3*b4*c0021 +
+		3*b3*b4*b4*c0012 + b4*b4*b4*c0003, nil
+}
+
+// barycentric 返回点 (x, y) 在三角形 abc 中的面积坐标. 该点可以在三角形之外.
+func barycentric(a, b, c geom.Point, x, y float64) [3]float64 {
+	det := cross(a, b, c)
+	p := geom.Point{X: x, Y: y}
+	l1 := cross(p, b, c) / det
+	l2 := cross(a, p, c) / det
+	return [3]float64{l1, l2, 1 - l1 - l2}
+}
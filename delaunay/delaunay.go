@@ -0,0 +1,393 @@
+/*
+delaunay 包实现了平面上无规则离散点的 Delaunay 三角剖分, 以及基于三角剖分的插值方法:
+
+	分片线性插值: 在每个三角形内按面积坐标对三个顶点的值进行线性插值, 结果是 C0 连续的;
+	Clough-Tocher 插值: 将每个三角形以其形心分为 3 个子三角形, 在每个子三角形内构造三次 Bezier 曲面,
+	结果是 C1 连续的. 各点处的梯度由其周围三角形的梯度估计得出;
+	Sibson 自然邻点插值: 以待求点插入后从各个自然邻点的 Voronoi 单元中所夺取的面积为权, 结果在数据点以外
+	是 C1 连续的, 且对不规则的点距有很好的适应性.
+
+三角剖分采用 Bowyer-Watson 逐点插入算法, 其结果覆盖所有点的凸包. 对于边界凹进的数据区域,
+还可以通过 Concave 方法将凸包边界上过长的边所在的三角形逐步剥除, 从而得到一个凹的边界.
+AlphaShape 方法则求得点集的 alpha 形状的边界(包括其中的孔洞), 可用作场的定义域.
+*/
+package delaunay
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/mesh"
+)
+
+// Triangulation 是一组点的三角剖分.
+type Triangulation struct {
+	Points []geom.Point
+	// Triangles 中每个元素为一个三角形三个顶点在 Points 中的索引, 顶点按逆时针排列.
+	// 与其他点重合的点不属于任何三角形.
+	Triangles [][3]int
+	// Neighbors[i][k] 为与三角形 i 中顶点 k 的对边相邻的三角形的索引, 若该边在边界上, 则为 -1.
+	Neighbors [][3]int
+	// Hull 为边界上的各个点的索引, 按逆时针排列.
+	Hull []int
+	mesh *mesh.Mesh // 用于查找点所在的三角形
+}
+
+// triangle 是剖分过程中的一个三角形.
+type triangle struct {
+	v     [3]int // 逆时针排列的顶点
+	n     [3]int // n[k] 为顶点 v[k] 的对边相邻的三角形
+	alive bool
+}
+
+// New 对点集 points 进行 Delaunay 三角剖分. 若所有点都在一条直线上, 则返回一个错误.
+func New(points []geom.Point) (*Triangulation, error) {
+	if len(points) < 3 {
+		return nil, errors.New("at least 3 points are needed for a triangulation")
+	}
+	// 将坐标归一化到单位正方形内, 以减小舍入误差
+	r := geom.Rect{Xmin: points[0].X, Xmax: points[0].X, Ymin: points[0].Y, Ymax: points[0].Y}
+	for _, p := range points {
+		r.Xmin, r.Xmax = math.Min(r.Xmin, p.X), math.Max(r.Xmax, p.X)
+		r.Ymin, r.Ymax = math.Min(r.Ymin, p.Y), math.Max(r.Ymax, p.Y)
+	}
+	scale := math.Max(r.Xmax-r.Xmin, r.Ymax-r.Ymin)
+	if scale == 0.0 {
+		return nil, errors.New("all points are coincident")
+	}
+	n := len(points)
+	ps := make([]geom.Point, n+3)
+	for i, p := range points {
+		ps[i] = geom.Point{X: (p.X - r.Xmin) / scale, Y: (p.Y - r.Ymin) / scale}
+	}
+	// 包含所有点的超级三角形
+	const big = 1e4
+	ps[n] = geom.Point{X: -big, Y: -big}
+	ps[n+1] = geom.Point{X: big, Y: -big}
+	ps[n+2] = geom.Point{X: 0.5, Y: big}
+
+	b := &builder{ps: ps, tris: []triangle{{v: [3]int{n, n + 1, n + 2}, n: [3]int{-1, -1, -1}, alive: true}}}
+	for _, i := range uniqueOrder(ps[:n]) {
+		b.insert(i)
+	}
+	var tris [][3]int
+	for _, t := range b.tris {
+		if t.alive && t.v[0] < n && t.v[1] < n && t.v[2] < n {
+			tris = append(tris, t.v)
+		}
+	}
+	if len(tris) == 0 {
+		return nil, errors.New("all points are collinear")
+	}
+	tris = fillHull(ps, tris)
+	t := &Triangulation{Points: points}
+	if err := t.setTriangles(tris); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// uniqueOrder 返回各个点的插入顺序. 为了使逐点插入时的查找路径较短, 点按坐标排序;
+// 与前一个点重合的点被舍弃.
+func uniqueOrder(ps []geom.Point) []int {
+	idx := make([]int, len(ps))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool {
+		a, b := ps[idx[i]], ps[idx[j]]
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		return a.Y < b.Y
+	})
+	out := idx[:0]
+	for k, i := range idx {
+		if k > 0 && ps[i] == ps[idx[k-1]] {
+			continue
+		}
+		out = append(out, i)
+	}
+	return out
+}
+
+// builder 实现了 Bowyer-Watson 算法.
+type builder struct {
+	ps   []geom.Point
+	tris []triangle
+	last int // 最近创建的三角形, 作为下次查找的起点
+}
+
+// insert 方法将点 pi 插入到三角剖分中.
+func (b *builder) insert(pi int) {
+	p := b.ps[pi]
+	start := b.locate(p)
+	// 找出外接圆包含该点的所有三角形(空腔), 以及空腔的边界
+	type edge struct{ a, b, outer int }
+	var boundary []edge
+	bad := map[int]bool{start: true}
+	stack := []int{start}
+	for len(stack) > 0 {
+		ti := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		t := &b.tris[ti]
+		for k := 0; k < 3; k++ {
+			ni := t.n[k]
+			if ni >= 0 && !bad[ni] && b.inCircle(ni, p) {
+				bad[ni] = true
+				stack = append(stack, ni)
+			}
+		}
+	}
+	for ti := range bad {
+		t := &b.tris[ti]
+		for k := 0; k < 3; k++ {
+			if ni := t.n[k]; ni < 0 || !bad[ni] {
+				boundary = append(boundary, edge{t.v[(k+1)%3], t.v[(k+2)%3], ni})
+			}
+		}
+		t.alive = false
+	}
+	// 将点与空腔边界上的各边连接为新的三角形
+	byStart := make(map[int]int, len(boundary)) // 边的起点 -> 新三角形
+	byEnd := make(map[int]int, len(boundary))   // 边的终点 -> 新三角形
+	first := len(b.tris)
+	for _, e := range boundary {
+		ti := len(b.tris)
+		b.tris = append(b.tris, triangle{v: [3]int{pi, e.a, e.b}, n: [3]int{e.outer, -1, -1}, alive: true})
+		byStart[e.a] = ti
+		byEnd[e.b] = ti
+		if e.outer >= 0 {
+			o := &b.tris[e.outer]
+			for k := 0; k < 3; k++ {
+				if o.v[k] != e.a && o.v[k] != e.b {
+					o.n[k] = ti
+				}
+			}
+		}
+	}
+	for ti := first; ti < len(b.tris); ti++ {
+		t := &b.tris[ti]
+		t.n[1] = byStart[t.v[2]] // 边 (b, p) 与以 b 为起点的新三角形共享
+		t.n[2] = byEnd[t.v[1]]   // 边 (p, a) 与以 a 为终点的新三角形共享
+	}
+	b.last = first
+}
+
+// locate 方法从最近创建的三角形出发, 沿直线方向查找包含点 p 的三角形.
+func (b *builder) locate(p geom.Point) int {
+	ti := b.last
+	for !b.tris[ti].alive {
+		ti--
+	}
+	for steps := 0; steps < len(b.tris); steps++ {
+		t := &b.tris[ti]
+		moved := false
+		for k := 0; k < 3; k++ {
+			a, c := b.ps[t.v[(k+1)%3]], b.ps[t.v[(k+2)%3]]
+			if cross(a, c, p) < 0 && t.n[k] >= 0 {
+				ti = t.n[k]
+				moved = true
+				break
+			}
+		}
+		if !moved {
+			return ti
+		}
+	}
+	// 由于舍入误差而未能找到时, 逐个检查所有三角形
+	for ti, t := range b.tris {
+		if t.alive && b.inCircle(ti, p) {
+			return ti
+		}
+	}
+	return ti
+}
+
+// inCircle 方法判断点 p 是否在三角形 ti 的外接圆内.
+func (b *builder) inCircle(ti int, p geom.Point) bool {
+	t := &b.tris[ti]
+	return inCircumcircle(b.ps[t.v[0]], b.ps[t.v[1]], b.ps[t.v[2]], p)
+}
+
+// inCircumcircle 判断点 p 是否在逆时针排列的三角形 abc 的外接圆内. 四点共圆(在舍入误差范围内)时返回 false,
+// 这样规则排列的数据点(如有限元网格的节点)的三角剖分只取决于点的插入顺序, 而不取决于舍入误差,
+// 从而不随数据的平移和缩放而改变.
+func inCircumcircle(a, b, c, p geom.Point) bool {
+	ax, ay := a.X-p.X, a.Y-p.Y
+	bx, by := b.X-p.X, b.Y-p.Y
+	cx, cy := c.X-p.X, c.Y-p.Y
+	a2, b2, c2 := ax*ax+ay*ay, bx*bx+by*by, cx*cx+cy*cy
+	det := a2*(bx*cy-cx*by) - b2*(ax*cy-cx*ay) + c2*(ax*by-bx*ay)
+	tol := 1e-10 * (a2*(math.Abs(bx*cy)+math.Abs(cx*by)) + b2*(math.Abs(ax*cy)+math.Abs(cx*ay)) + c2*(math.Abs(ax*by)+math.Abs(bx*ay)))
+	return det > tol
+}
+
+// cross 返回向量 ab 与 ac 的叉积. 若 c 在有向线段 ab 的左侧, 则结果为正.
+func cross(a, b, c geom.Point) float64 {
+	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
+}
+
+// fillHull 在边界的凹进处补充三角形, 使三角剖分覆盖所有点的凸包. 由于超级三角形的顶点并不在无穷远处,
+// 删除与其相连的三角形后, 边界上可能会留下少量凹进之处.
+func fillHull(ps []geom.Point, tris [][3]int) [][3]int {
+	for {
+		next, _ := boundary(tris)
+		added := false
+		for a, b := range next {
+			c, ok := next[b]
+			if !ok || c == a {
+				continue
+			}
+			// 在顶点 b 处右转, 说明边界在此处凹进
+			if cross(ps[a], ps[b], ps[c]) < -1e-12 {
+				tris = append(tris, [3]int{a, c, b})
+				added = true
+				break
+			}
+		}
+		if !added {
+			return tris
+		}
+	}
+}
+
+// boundary 返回三角形 tris 的边界. next[a] = b 表示 a -> b 是一条逆时针方向的边界边, tri[a] 为该边所在的三角形.
+func boundary(tris [][3]int) (next, tri map[int]int) {
+	type edge struct{ a, b int }
+	edges := make(map[edge]int, 3*len(tris))
+	for ti, t := range tris {
+		for k := 0; k < 3; k++ {
+			edges[edge{t[k], t[(k+1)%3]}] = ti
+		}
+	}
+	next, tri = map[int]int{}, map[int]int{}
+	for e, ti := range edges {
+		if _, ok := edges[edge{e.b, e.a}]; !ok {
+			next[e.a] = e.b
+			tri[e.a] = ti
+		}
+	}
+	return next, tri
+}
+
+// setTriangles 方法设置三角形, 并据此计算相邻关系, 边界以及用于查找的网格.
+func (t *Triangulation) setTriangles(tris [][3]int) error {
+	t.Triangles = tris
+	t.Neighbors = make([][3]int, len(tris))
+	type edge struct{ a, b int }
+	edges := make(map[edge]int, 3*len(tris))
+	for ti, tr := range tris {
+		for k := 0; k < 3; k++ {
+			edges[edge{tr[(k+1)%3], tr[(k+2)%3]}] = ti
+		}
+	}
+	for ti, tr := range tris {
+		for k := 0; k < 3; k++ {
+			t.Neighbors[ti][k] = -1
+			if ni, ok := edges[edge{tr[(k+2)%3], tr[(k+1)%3]}]; ok {
+				t.Neighbors[ti][k] = ni
+			}
+		}
+	}
+	next, _ := boundary(tris)
+	t.Hull = t.Hull[:0]
+	start := -1
+	for a := range next {
+		if start < 0 || a < start {
+			start = a
+		}
+	}
+	for a := start; ; {
+		t.Hull = append(t.Hull, a)
+		a = next[a]
+		if a == start || len(t.Hull) > len(next) {
+			break
+		}
+	}
+	elems := make([]mesh.Element, len(tris))
+	for i, tr := range tris {
+		elems[i] = mesh.Element{tr[0], tr[1], tr[2]}
+	}
+	m, err := mesh.New(t.Points, elems)
+	if err != nil {
+		return err
+	}
+	t.mesh = m
+	return nil
+}
+
+// onBoundary 判断点 a 是否在边界上.
+func onBoundary(next map[int]int, a int) bool {
+	_, ok := next[a]
+	return ok
+}
+
+// Concave 方法将边界上长度大于 maxEdge 的边所在的三角形逐步剥除, 使三角剖分的边界能够贴合凹的数据区域.
+// 为了保证剥除后的区域仍是一个单连通的区域, 仅当三角形的第三个顶点不在边界上时才将其剥除.
+func (t *Triangulation) Concave(maxEdge float64) error {
+	if maxEdge <= 0.0 {
+		return fmt.Errorf("invalid maximum edge length %g", maxEdge)
+	}
+	tris := t.Triangles
+	for {
+		next, tri := boundary(tris)
+		removed := map[int]bool{}
+		touched := map[int]bool{} // 剥除过程中新出现在边界上的点
+		for a, b := range next {
+			ti := tri[a]
+			if removed[ti] || t.Points[a].DistTo(&t.Points[b]) <= maxEdge {
+				continue
+			}
+			var c int
+			for _, v := range tris[ti] {
+				if v != a && v != b {
+					c = v
+				}
+			}
+			if onBoundary(next, c) || touched[c] || len(tris)-len(removed) <= 1 {
+				continue
+			}
+			removed[ti] = true
+			touched[c] = true
+		}
+		if len(removed) == 0 {
+			break
+		}
+		kept := make([][3]int, 0, len(tris)-len(removed))
+		for ti, tr := range tris {
+			if !removed[ti] {
+				kept = append(kept, tr)
+			}
+		}
+		tris = kept
+	}
+	return t.setTriangles(tris)
+}
+
+// Locate 方法返回点 (x, y) 所在的三角形的索引 ti, 以及该点在三角形中的面积坐标 b,
+// b 中的元素与三角形的顶点一一对应. 若该点在三角剖分的范围之外, 则返回一个错误.
+func (t *Triangulation) Locate(x, y float64) (ti int, b [3]float64, err error) {
+	ti, w, err := t.mesh.Locate(x, y)
+	if err != nil {
+		return -1, b, err
+	}
+	copy(b[:], w)
+	return ti, b, nil
+}
+
+// Linear 方法返回分片线性插值在点 (x, y) 处的值, vs 为各点处的值.
+func (t *Triangulation) Linear(vs []float64, x, y float64) (float64, error) {
+	if len(vs) != len(t.Points) {
+		return 0.0, fmt.Errorf("%d values given for %d points", len(vs), len(t.Points))
+	}
+	ti, b, err := t.Locate(x, y)
+	if err != nil {
+		return 0.0, err
+	}
+	tr := t.Triangles[ti]
+	return b[0]*vs[tr[0]] + b[1]*vs[tr[1]] + b[2]*vs[tr[2]], nil
+}
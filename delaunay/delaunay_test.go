@@ -0,0 +1,208 @@
+package delaunay_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"stj/fieldline/delaunay"
+	"stj/fieldline/geom"
+)
+
+// randomPoints 生成单位正方形内的 n 个随机点以及正方形的 4 个顶点.
+func randomPoints(n int) []geom.Point {
+	r := rand.New(rand.NewSource(1))
+	ps := []geom.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}
+	for i := 0; i < n; i++ {
+		ps = append(ps, geom.Point{X: r.Float64(), Y: r.Float64()})
+	}
+	return ps
+}
+
+func TestNew(t *testing.T) {
+	ps := randomPoints(500)
+	ps = append(ps, ps[10]) // 重合点
+	tri, err := delaunay.New(ps)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// 由欧拉公式, 三角形个数为 2n - 2 - h
+	n := len(ps) - 1
+	if want := 2*n - 2 - len(tri.Hull); len(tri.Triangles) != want {
+		t.Errorf("got %d triangles, want %d", len(tri.Triangles), want)
+	}
+	area := 0.0
+	for ti, tr := range tri.Triangles {
+		a, b, c := ps[tr[0]], ps[tr[1]], ps[tr[2]]
+		s := 0.5 * ((b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X))
+		if s <= 0 {
+			t.Fatalf("triangle %d is not counterclockwise", ti)
+		}
+		area += s
+		// 空外接圆性质
+		for _, k := range []int{0, 1, 2} {
+			ni := tri.Neighbors[ti][k]
+			if ni < 0 {
+				continue
+			}
+			for _, v := range tri.Triangles[ni] {
+				if v != tr[0] && v != tr[1] && v != tr[2] && inCircle(a, b, c, ps[v]) > 1e-12 {
+					t.Errorf("triangle %d is not Delaunay", ti)
+				}
+			}
+		}
+	}
+	if math.Abs(area-1) > 1e-9 {
+		t.Errorf("the triangles cover an area of %g, want 1", area)
+	}
+	if _, err := delaunay.New([]geom.Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 2}}); err == nil {
+		t.Error("collinear points should be rejected")
+	}
+}
+
+func inCircle(a, b, c, p geom.Point) float64 {
+	ax, ay := a.X-p.X, a.Y-p.Y
+	bx, by := b.X-p.X, b.Y-p.Y
+	cx, cy := c.X-p.X, c.Y-p.Y
+	return (ax*ax+ay*ay)*(bx*cy-cx*by) - (bx*bx+by*by)*(ax*cy-cx*ay) + (cx*cx+cy*cy)*(ax*by-bx*ay)
+}
+
+func TestConcave(t *testing.T) {
+	// L 形区域内的格点, 其凸包包含右上角的空白区域
+	var ps []geom.Point
+	for i := 0; i <= 10; i++ {
+		for j := 0; j <= 10; j++ {
+			if i <= 5 || j <= 5 {
+				ps = append(ps, geom.Point{X: float64(i), Y: float64(j)})
+			}
+		}
+	}
+	tri, err := delaunay.New(ps)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, _, err := tri.Locate(7, 7); err != nil {
+		t.Fatal("the convex hull should contain (7, 7)")
+	}
+	if err := tri.Concave(1.5); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, _, err := tri.Locate(7, 7); err == nil {
+		t.Error("(7, 7) should be outside the concave boundary")
+	}
+	if _, _, err := tri.Locate(2.5, 8.5); err != nil {
+		t.Error("(2.5, 8.5) should be inside the concave boundary")
+	}
+}
+
+func TestInterpolation(t *testing.T) {
+	ps := randomPoints(300)
+	tri, err := delaunay.New(ps)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	linear := func(p geom.Point) float64 { return 2*p.X - 3*p.Y + 1 }
+	smooth := func(p geom.Point) float64 { return math.Sin(3*p.X) * math.Cos(2*p.Y) }
+	lvs := make([]float64, len(ps))
+	svs := make([]float64, len(ps))
+	for i, p := range ps {
+		lvs[i], svs[i] = linear(p), smooth(p)
+	}
+	lct, err := delaunay.NewCloughTocher(tri, lvs)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	sct, _ := delaunay.NewCloughTocher(tri, svs)
+	var linErr, ctErr float64
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 200; i++ {
+		p := geom.Point{X: 0.1 + 0.8*r.Float64(), Y: 0.1 + 0.8*r.Float64()}
+		// 两种方法都能精确重现线性函数
+		v1, err1 := tri.Linear(lvs, p.X, p.Y)
+		v2, err2 := lct.Value(p.X, p.Y)
+		if err1 != nil || err2 != nil || math.Abs(v1-linear(p)) > 1e-9 || math.Abs(v2-linear(p)) > 1e-9 {
+			t.Fatalf("(%g, %g): got %v, %v, want %g", p.X, p.Y, v1, v2, linear(p))
+		}
+		s1, _ := tri.Linear(svs, p.X, p.Y)
+		s2, _ := sct.Value(p.X, p.Y)
+		linErr += math.Abs(s1 - smooth(p))
+		ctErr += math.Abs(s2 - smooth(p))
+	}
+	if ctErr >= linErr {
+		t.Errorf("the Clough-Tocher error %g should be less than the linear error %g", ctErr, linErr)
+	}
+	if v, err := sct.Value(ps[7].X, ps[7].Y); err != nil || math.Abs(v-svs[7]) > 1e-9 {
+		t.Errorf("the interpolation should pass through the data points: %v, %v", v, err)
+	}
+}
+
+func TestSibson(t *testing.T) {
+	ps := randomPoints(200)
+	tri, err := delaunay.New(ps)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	f := func(p geom.Point) float64 { return -p.X + 4*p.Y + 2 }
+	vs := make([]float64, len(ps))
+	for i, p := range ps {
+		vs[i] = f(p)
+	}
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 200; i++ {
+		p := geom.Point{X: r.Float64(), Y: r.Float64()}
+		idx, w, err := tri.NaturalNeighbors(p.X, p.Y)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		// 权重之和为 1, 且以权重对自然邻点的坐标加权平均可得到该点本身(线性精度)
+		var sw, sx, sy float64
+		for k, pi := range idx {
+			sw += w[k]
+			sx += w[k] * ps[pi].X
+			sy += w[k] * ps[pi].Y
+		}
+		if math.Abs(sw-1) > 1e-9 || math.Abs(sx-p.X) > 1e-9 || math.Abs(sy-p.Y) > 1e-9 {
+			t.Fatalf("(%g, %g): wrong weights %v of %v", p.X, p.Y, w, idx)
+		}
+		if v, err := tri.Sibson(vs, p.X, p.Y); err != nil || math.Abs(v-f(p)) > 1e-9 {
+			t.Fatalf("(%g, %g): got %v, %v, want %g", p.X, p.Y, v, err, f(p))
+		}
+	}
+	if v, _ := tri.Sibson(vs, ps[20].X, ps[20].Y); math.Abs(v-vs[20]) > 1e-12 {
+		t.Errorf("got %g at a data point, want %g", v, vs[20])
+	}
+}
+
+func TestAlphaShape(t *testing.T) {
+	// 中间挖去 5x5 个点的格点, 孔洞为 6x6 的正方形切去 4 个角上面积为 0.5 的三角形
+	var ps []geom.Point
+	for i := 0; i <= 10; i++ {
+		for j := 0; j <= 10; j++ {
+			if i < 3 || i > 7 || j < 3 || j > 7 {
+				ps = append(ps, geom.Point{X: float64(i), Y: float64(j)})
+			}
+		}
+	}
+	tri, err := delaunay.New(ps)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if rings, err := tri.AlphaShape(0.0); err != nil || len(rings) != 2 {
+		t.Fatalf("got %d boundary rings, %v", len(rings), err)
+	}
+	// 格点三角形的外接圆半径为 0.707, 孔洞中的三角形的外接圆半径不小于 1.414
+	rings, err := tri.AlphaShape(1.0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var areas []float64
+	for _, r := range rings {
+		areas = append(areas, r.Area())
+	}
+	if len(areas) != 2 || math.Max(areas[0], areas[1]) != 100.0 || math.Min(areas[0], areas[1]) != -34.0 {
+		t.Errorf("got boundary rings with areas %v, want 100 and -34", areas)
+	}
+	if _, err := tri.AlphaShape(0.1); err == nil {
+		t.Error("no triangle should be kept for a tiny alpha")
+	}
+}
@@ -0,0 +1,153 @@
+package delaunay
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"stj/fieldline/geom"
+)
+
+// NaturalNeighbors 方法返回点 (x, y) 的各个自然邻点在 Points 中的索引 idx, 以及它们在 Sibson 插值中的权重 w.
+// 自然邻点是将该点插入三角剖分后与之相连的点, 其权重为插入后该点的 Voronoi 单元从各个自然邻点的 Voronoi 单元中
+// 夺取的面积与该点 Voronoi 单元面积之比. 若该点在三角剖分的范围之外, 则返回一个错误.
+func (t *Triangulation) NaturalNeighbors(x, y float64) (idx []int, w []float64, err error) {
+	start, b, err := t.Locate(x, y)
+	if err != nil {
+		return nil, nil, err
+	}
+	tr := t.Triangles[start]
+	for k := 0; k < 3; k++ {
+		if b[k] > 1-1e-12 { // 与数据点重合
+			return []int{tr[k]}, []float64{1.0}, nil
+		}
+	}
+	p := geom.Point{X: x, Y: y}
+	// 外接圆包含该点的三角形, 即插入该点时的空腔
+	cavity := map[int]bool{start: true}
+	stack := []int{start}
+	for len(stack) > 0 {
+		ti := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, ni := range t.Neighbors[ti] {
+			if ni >= 0 && !cavity[ni] && t.inCircumcircle(ni, p) {
+				cavity[ni] = true
+				stack = append(stack, ni)
+			}
+		}
+	}
+	// 空腔边界上的边, in[v] 和 out[v] 分别为以 v 为终点和起点的边与该点构成的三角形的外接圆圆心
+	in := map[int]geom.Point{}
+	out := map[int]geom.Point{}
+	owned := map[int][]geom.Point{} // 各个自然邻点原 Voronoi 单元中被夺取部分的顶点
+	for ti := range cavity {
+		tr := t.Triangles[ti]
+		cc, ok := circumcenter(t.Points[tr[0]], t.Points[tr[1]], t.Points[tr[2]])
+		if !ok {
+			return t.linearNeighbors(start, b)
+		}
+		for k := 0; k < 3; k++ {
+			owned[tr[k]] = append(owned[tr[k]], cc)
+			if ni := t.Neighbors[ti][k]; ni >= 0 && cavity[ni] {
+				continue
+			}
+			a, c := tr[(k+1)%3], tr[(k+2)%3]
+			pc, ok := circumcenter(p, t.Points[a], t.Points[c])
+			if !ok { // 该点正好在边界的边上
+				return t.linearNeighbors(start, b)
+			}
+			out[a] = pc
+			in[c] = pc
+		}
+	}
+	total := 0.0
+	for v, ps := range owned {
+		a := hullArea(append(ps, in[v], out[v]))
+		idx = append(idx, v)
+		w = append(w, a)
+		total += a
+	}
+	if !(total > 0.0) {
+		return t.linearNeighbors(start, b)
+	}
+	for i := range w {
+		w[i] /= total
+	}
+	return idx, w, nil
+}
+
+// linearNeighbors 方法以点在三角形 ti 中的面积坐标 b 作为权重. 当 Sibson 权重由于几何退化而无法计算时使用.
+func (t *Triangulation) linearNeighbors(ti int, b [3]float64) ([]int, []float64, error) {
+	tr := t.Triangles[ti]
+	return []int{tr[0], tr[1], tr[2]}, []float64{b[0], b[1], b[2]}, nil
+}
+
+// Sibson 方法返回 Sibson 自然邻点插值在点 (x, y) 处的值, vs 为各点处的值.
+func (t *Triangulation) Sibson(vs []float64, x, y float64) (float64, error) {
+	if len(vs) != len(t.Points) {
+		return 0.0, fmt.Errorf("%d values given for %d points", len(vs), len(t.Points))
+	}
+	idx, w, err := t.NaturalNeighbors(x, y)
+	if err != nil {
+		return 0.0, err
+	}
+	v := 0.0
+	for i, pi := range idx {
+		v += w[i] * vs[pi]
+	}
+	return v, nil
+}
+
+// inCircumcircle 方法判断点 p 是否在三角形 ti 的外接圆内.
+func (t *Triangulation) inCircumcircle(ti int, p geom.Point) bool {
+	tr := t.Triangles[ti]
+	return inCircumcircle(t.Points[tr[0]], t.Points[tr[1]], t.Points[tr[2]], p)
+}
+
+// circumcenter 返回三角形 abc 的外接圆圆心. 若三点共线, 则 ok 为 false.
+func circumcenter(a, b, c geom.Point) (cc geom.Point, ok bool) {
+	bx, by := b.X-a.X, b.Y-a.Y
+	cx, cy := c.X-a.X, c.Y-a.Y
+	d := 2 * (bx*cy - by*cx)
+	if math.Abs(d) <= 1e-12*(bx*bx+by*by+cx*cx+cy*cy) {
+		return cc, false
+	}
+	b2, c2 := bx*bx+by*by, cx*cx+cy*cy
+	cc.X = a.X + (cy*b2-by*c2)/d
+	cc.Y = a.Y + (bx*c2-cx*b2)/d
+	return cc, true
+}
+
+// hullArea 返回一组点的凸包的面积.
+func hullArea(ps []geom.Point) float64 {
+	if len(ps) < 3 {
+		return 0.0
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].X != ps[j].X {
+			return ps[i].X < ps[j].X
+		}
+		return ps[i].Y < ps[j].Y
+	})
+	// Andrew 单调链算法
+	hull := make([]geom.Point, 0, 2*len(ps))
+	for _, p := range ps {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(ps) - 2; i >= 0; i-- {
+		p := ps[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	area := 0.0
+	for i := 0; i+1 < len(hull); i++ {
+		area += hull[i].X*hull[i+1].Y - hull[i+1].X*hull[i].Y
+	}
+	return 0.5 * area
+}
@@ -0,0 +1,225 @@
+/*
+fe 包实现了对有限元软件所输出的节点结果列表的导入. 目前支持:
+
+	Abaqus: 由 Report > Field Output 输出的 .rpt 报告文件, 如节点坐标 COORD 和节点应力 S;
+	ANSYS: 由 NLIST 输出的节点坐标列表, 以及由 PRNSOL,S,COMP 输出的节点应力列表.
+
+这些文件都是带有页眉的定宽文本表格, 表头可能在每页重复出现, 表格后还可能附有最大值, 最小值等统计信息.
+本包将节点坐标列表和节点应力列表分别读入, 然后按节点编号将二者连接为一个 field.TensorField.
+*/
+package fe
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"stj/fieldline/field"
+	"stj/fieldline/geom"
+	"stj/fieldline/tensor"
+)
+
+// Format 表示节点结果列表的来源软件.
+type Format int
+
+// Abaqus 和 ANSYS 是目前支持的两种格式.
+const (
+	Abaqus Format = iota
+	ANSYS
+)
+
+// ParseFormat 根据名称(不区分大小写)返回相应的格式.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "abaqus":
+		return Abaqus, nil
+	case "ansys":
+		return ANSYS, nil
+	}
+	return 0, fmt.Errorf("unknown finite element result format %q", name)
+}
+
+// coordCols 和 stressCols 给出各种格式中坐标和应力分量在表头中可能的列名, 列名比较时不区分大小写.
+var (
+	coordCols = map[Format][][]string{
+		Abaqus: {{"COORD.COOR1", "COORD-COOR1", "COOR1"}, {"COORD.COOR2", "COORD-COOR2", "COOR2"}},
+		ANSYS:  {{"X"}, {"Y"}},
+	}
+	stressCols = map[Format][][]string{
+		Abaqus: {{"S.S11", "S-S11", "S11"}, {"S.S22", "S-S22", "S22"}, {"S.S12", "S-S12", "S12"}},
+		ANSYS:  {{"SX"}, {"SY"}, {"SXY"}},
+	}
+)
+
+// Convention 描述了应力列表中的符号约定. 读入的应力总是被转换为以拉为正,
+// 且剪应力 XY 以使单元正面沿坐标轴正向为正的约定(即 Abaqus 和 ANSYS 的默认约定).
+type Convention struct {
+	// CompressionPositive 为 true 时, 表示正应力和剪应力都以压为正(岩土力学中的常用约定),
+	// 读入时所有分量将被取反.
+	CompressionPositive bool
+	// ClockwiseShear 为 true 时, 表示剪应力以使单元顺时针转动为正(如莫尔圆的约定),
+	// 读入时剪应力将被取反.
+	ClockwiseShear bool
+}
+
+// ReadCoords 从 r 中读取节点坐标列表, 返回以节点编号为键的节点坐标.
+func ReadCoords(r io.Reader, f Format) (map[int]geom.Point, error) {
+	rows, err := readListing(r, coordCols[f])
+	if err != nil {
+		return nil, err
+	}
+	ps := make(map[int]geom.Point, len(rows))
+	for id, vs := range rows {
+		ps[id] = geom.Point{X: vs[0], Y: vs[1]}
+	}
+	return ps, nil
+}
+
+// ReadStress 从 r 中读取节点应力列表, 返回以节点编号为键的应力张量. conv 为列表中应力的符号约定.
+func ReadStress(r io.Reader, f Format, conv Convention) (map[int]*tensor.Tensor, error) {
+	rows, err := readListing(r, stressCols[f])
+	if err != nil {
+		return nil, err
+	}
+	ts := make(map[int]*tensor.Tensor, len(rows))
+	for id, vs := range rows {
+		t := tensor.New(vs[0], vs[1], vs[2])
+		if conv.CompressionPositive {
+			t = tensor.New(-t.XX, -t.YY, -t.XY)
+		}
+		if conv.ClockwiseShear {
+			t.XY = -t.XY
+		}
+		ts[id] = t
+	}
+	return ts, nil
+}
+
+// Join 按节点编号将节点坐标和节点应力连接为一个张量场. 没有应力的节点(如未输出结果的节点)将被忽略,
+// 但若某个节点有应力而没有坐标, 则返回一个错误. o 为创建张量场所用的参数, 若为 nil, 则使用默认参数.
+func Join(coords map[int]geom.Point, stress map[int]*tensor.Tensor, o *field.Options) (*field.TensorField, error) {
+	ids := make([]int, 0, len(stress))
+	for id := range stress {
+		if _, ok := coords[id]; !ok {
+			return nil, fmt.Errorf("no coordinates found for node %d", id)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no nodal stress given")
+	}
+	// 按节点编号排序, 使得所生成的张量场与 map 的遍历顺序无关
+	sort.Ints(ids)
+	data := make([]*field.TensorQty, 0, len(ids))
+	for _, id := range ids {
+		p, t := coords[id], stress[id]
+		if o != nil && o.DiscardZeroQty && t.IsZero() {
+			continue
+		}
+		data = append(data, field.NewTensorQty(p.X, p.Y, t.XX, t.YY, t.XY))
+	}
+	return field.NewTensorField(data, o)
+}
+
+// readListing 读取一个节点结果列表, 返回以节点编号为键的各行数据. cols 中每个元素为一个所需列的
+// 各个可能的列名, 返回的每行数据中的各值与 cols 一一对应.
+//
+// 表头是以 "Node" 开头且不含数字的行, 其后的各列名依次与数据行中节点编号之后的各列对应.
+// 表头可以重复出现(如每页的页眉), 每次出现时都将重新确定各列的位置. 数据行是以整数开头且其余各列都是
+// 数字的行, 其他行(如标题, 分隔线, 统计信息等)都被忽略.
+func readListing(r io.Reader, cols [][]string) (map[int][]float64, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	rows := map[int][]float64{}
+	var index []int // index[i] 为 cols[i] 在节点编号之后的列序号
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if isHeader(fields) {
+			if idx, ok := locate(fields[1:], cols); ok {
+				index = idx
+			}
+			continue
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil || index == nil {
+			continue
+		}
+		vs, ok := parseNumbers(fields[1:])
+		if !ok {
+			continue
+		}
+		row := make([]float64, len(index))
+		for i, ci := range index {
+			if ci >= len(vs) {
+				return nil, fmt.Errorf("line %d: missing column %q", lineNum, cols[i][0])
+			}
+			row[i] = vs[ci]
+		}
+		if _, ok := rows[id]; ok {
+			return nil, fmt.Errorf("line %d: duplicate node %d", lineNum, id)
+		}
+		rows[id] = row
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if index == nil {
+		return nil, fmt.Errorf("no table header with column %q found", cols[0][0])
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no nodal data found")
+	}
+	return rows, nil
+}
+
+// isHeader 判断一行是否为表头.
+func isHeader(fields []string) bool {
+	if !strings.EqualFold(fields[0], "NODE") {
+		return false
+	}
+	for _, f := range fields[1:] {
+		if _, err := strconv.ParseFloat(f, 64); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// locate 在表头的各列名 names 中查找 cols 中的各列. 仅当所有列都能找到时, ok 为 true.
+func locate(names []string, cols [][]string) (index []int, ok bool) {
+	index = make([]int, len(cols))
+	for i, aliases := range cols {
+		index[i] = -1
+		for ci, n := range names {
+			for _, a := range aliases {
+				if strings.EqualFold(n, a) {
+					index[i] = ci
+				}
+			}
+		}
+		if index[i] < 0 {
+			return nil, false
+		}
+	}
+	return index, true
+}
+
+// parseNumbers 将各字段解析为数字. 仅当所有字段都是数字时, ok 为 true.
+func parseNumbers(fields []string) (vs []float64, ok bool) {
+	vs = make([]float64, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, false
+		}
+		vs[i] = v
+	}
+	return vs, true
+}
@@ -0,0 +1,138 @@
+package fe_test
+
+import (
+	"strings"
+	"testing"
+
+	"stj/fieldline/fe"
+)
+
+const abaqusCoords = `********************************************************************************
+Field Output Report, written Mon Mar 02 10:11:12 2020
+
+Source 1
+---------
+
+   ODB: /home/user/plate.odb
+   Step: Step-1
+   Frame: Increment      1: Step Time =    1.000
+
+Loc 1 : Nodal values from source 1
+
+Output sorted by column "Node Label".
+
+Field Output reported at nodes for part: PART-1-1
+
+            Node       COORD.COOR1       COORD.COOR2
+           Label           @Loc 1           @Loc 1
+-------------------------------------------------
+              1               0.               0.
+              2               1.               0.
+              3               0.               1.
+              4               1.               1.
+
+
+Minimum                         0.               0.
+     At Node                     1                1
+Maximum                         1.               1.
+     At Node                     4                4
+`
+
+const abaqusStress = `Field Output reported at nodes for part: PART-1-1
+
+            Node            S.S11            S.S22            S.S12
+           Label           @Loc 1           @Loc 1           @Loc 1
+---------------------------------------------------------------------
+              1        -1.00E+06        -2.00E+06         5.00E+05
+              2        -1.10E+06        -2.10E+06         5.10E+05
+              3        -1.20E+06        -2.20E+06         5.20E+05
+
+Minimum                -1.20E+06        -2.20E+06         5.00E+05
+     At Node                     3                3                1
+`
+
+// ansysStress 中的表头在每页重复出现, 且应力按以压为正的约定给出.
+const ansysStress = ` PRINT S    NODAL SOLUTION PER NODE
+
+  ***** POST1 NODAL STRESS LISTING *****
+
+  LOAD STEP=     1  SUBSTEP=     1
+   TIME=    1.0000      LOAD CASE=   0
+
+  THE FOLLOWING X,Y,Z VALUES ARE IN GLOBAL COORDINATES
+
+    NODE     SX          SY          SZ          SXY         SYZ         SXZ
+       1   1.0000      2.0000      0.0000     -0.50000      0.0000      0.0000
+       2   1.1000      2.1000      0.0000     -0.51000      0.0000      0.0000
+
+ *** ANSYS - ENGINEERING ANALYSIS SYSTEM  RELEASE 19.2 ***
+
+    NODE     SX          SY          SZ          SXY         SYZ         SXZ
+       4   1.3000      2.3000      0.0000     -0.53000      0.0000      0.0000
+
+ MINIMUM VALUES
+ NODE          1           1           1           4           1           1
+ VALUE     1.0000      2.0000      0.0000     -0.53000      0.0000      0.0000
+`
+
+const ansysCoords = ` LIST ALL SELECTED NODES.   DSYS=      0
+
+    NODE        X             Y             Z           THXY     THYZ     THZX
+        1   0.0000        0.0000        0.0000          0.00     0.00     0.00
+        2   1.0000        0.0000        0.0000          0.00     0.00     0.00
+        4   1.0000        1.0000        0.0000          0.00     0.00     0.00
+`
+
+func TestReadAbaqus(t *testing.T) {
+	coords, err := fe.ReadCoords(strings.NewReader(abaqusCoords), fe.Abaqus)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(coords) != 4 || coords[4].X != 1 || coords[4].Y != 1 {
+		t.Fatalf("wrong coordinates: %v", coords)
+	}
+	stress, err := fe.ReadStress(strings.NewReader(abaqusStress), fe.Abaqus, fe.Convention{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(stress) != 3 || stress[2].XX != -1.1e6 || stress[2].YY != -2.1e6 || stress[2].XY != 5.1e5 {
+		t.Fatalf("wrong stress: %v", stress)
+	}
+	tf, err := fe.Join(coords, stress, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ts, _ := tf.Near(0, 1, 0)
+	if len(ts) == 0 || ts[0].XX != -1.2e6 {
+		t.Errorf("wrong tensor at node 3: %v", ts)
+	}
+	delete(coords, 3)
+	if _, err := fe.Join(coords, stress, nil); err == nil {
+		t.Error("a node without coordinates should be reported")
+	}
+}
+
+func TestReadANSYS(t *testing.T) {
+	coords, err := fe.ReadCoords(strings.NewReader(ansysCoords), fe.ANSYS)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	conv := fe.Convention{CompressionPositive: true, ClockwiseShear: true}
+	stress, err := fe.ReadStress(strings.NewReader(ansysStress), fe.ANSYS, conv)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(stress) != 3 {
+		t.Fatalf("got %d nodes, want 3", len(stress))
+	}
+	// 正应力取反, 剪应力先后两次取反
+	if s := stress[4]; s.XX != -1.3 || s.YY != -2.3 || s.XY != -0.53 {
+		t.Errorf("wrong stress at node 4: %v", s)
+	}
+	if _, err := fe.Join(coords, stress, nil); err != nil {
+		t.Error(err.Error())
+	}
+	if _, err := fe.ReadStress(strings.NewReader(ansysCoords), fe.ANSYS, conv); err == nil {
+		t.Error("a coordinate listing should not be read as stress")
+	}
+}
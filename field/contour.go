@@ -0,0 +1,87 @@
+package field
+
+import (
+	"errors"
+	"math"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/grid"
+)
+
+// Contour 方法利用移动正方形(Marching Squares)算法计算标量场中值为 v 的等值线.
+// 返回值是由各单元格内的等值线段组成的列表, 线段之间并不进行连接. 在调用此方法前,
+// 必须已通过 GenNodes 方法生成了网格节点数据. 若场设置了定义域, 则只返回定义域内的等值线段. 参考:
+// https://en.wikipedia.org/wiki/Marching_squares
+func (sf *ScalarField) Contour(v float64) (lines []*geom.Line, err error) {
+	if len(sf.nodes) != sf.grid.NodeNum {
+		return nil, errors.New("the nodes of the scalar field have not been generated")
+	}
+	for ci := 0; ci < sf.grid.CellNum; ci++ {
+		switch sf.grid.CellLoc(ci) {
+		case grid.Outside:
+		case grid.Boundary:
+			// 定义域的边界穿过单元格时, 舍弃中点在定义域以外的线段
+			for _, l := range sf.cellContour(ci, v) {
+				if sf.grid.InDomain(0.5*(l.X1+l.X2), 0.5*(l.Y1+l.Y2)) {
+					lines = append(lines, l)
+				}
+			}
+		default:
+			lines = append(lines, sf.cellContour(ci, v)...)
+		}
+	}
+	return lines, nil
+}
+
+// cellContour 方法计算索引为 ci 的单元格内值为 v 的等值线段.
+// 单元格的四条边按如下顺序编号, 节点顺序与 grid.NodeIdxesofCell 相同:
+//
+//	2 --- 2 --- 3
+//	|           |
+//	3           1
+//	|           |
+//	0 --- 0 --- 1
+func (sf *ScalarField) cellContour(ci int, v float64) []*geom.Line {
+	nis := sf.grid.NodeIdxesofCell(ci)
+	ns := [4]*ScalarQty{sf.nodes[nis[0]], sf.nodes[nis[1]], sf.nodes[nis[2]], sf.nodes[nis[3]]}
+	for _, n := range ns {
+		if math.IsNaN(n.V) {
+			return nil
+		}
+	}
+	// 每条边的两个端点在 ns 中的序号
+	edges := [4][2]int{{0, 1}, {1, 3}, {3, 2}, {2, 0}}
+	var pts [4]*geom.Point
+	count := 0
+	for i, e := range edges {
+		a, b := ns[e[0]], ns[e[1]]
+		if (a.V >= v) != (b.V >= v) {
+			pts[i] = geom.NewPoint(posIntrpl(a.X, b.X, a.V, b.V, v), posIntrpl(a.Y, b.Y, a.V, b.V, v))
+			count++
+		}
+	}
+	switch count {
+	case 2:
+		var ps []*geom.Point
+		for _, p := range pts {
+			if p != nil {
+				ps = append(ps, p)
+			}
+		}
+		return []*geom.Line{geom.NewLine(ps[0].X, ps[0].Y, ps[1].X, ps[1].Y)}
+	case 4:
+		// 鞍点情形, 以单元格中心的平均值判断等值线的走向.
+		center := 0.25 * (ns[0].V + ns[1].V + ns[2].V + ns[3].V)
+		if (center >= v) == (ns[0].V >= v) {
+			return []*geom.Line{
+				geom.NewLine(pts[0].X, pts[0].Y, pts[1].X, pts[1].Y),
+				geom.NewLine(pts[2].X, pts[2].Y, pts[3].X, pts[3].Y),
+			}
+		}
+		return []*geom.Line{
+			geom.NewLine(pts[3].X, pts[3].Y, pts[0].X, pts[0].Y),
+			geom.NewLine(pts[1].X, pts[1].Y, pts[2].X, pts[2].Y),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package field
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/grid"
+	"stj/fieldline/vector"
+)
+
+// CriticalPointType 表示向量场临界点的类型, 它由临界点处雅可比矩阵的特征值确定.
+type CriticalPointType int
+
+// 各类临界点附近的流线形态: 结点(Source, Sink)处的流线呈放射状, 鞍点处的流线呈双曲线状,
+// 中心处的流线为闭合曲线, 焦点处的流线呈螺旋状.
+const (
+	Source          CriticalPointType = iota // 源(不稳定结点), 两个特征值均为正实数
+	Sink                                     // 汇(稳定结点), 两个特征值均为负实数
+	Saddle                                   // 鞍点, 两个特征值为一正一负的实数
+	Center                                   // 中心, 两个特征值为共轭的纯虚数
+	RepellingFocus                           // 不稳定焦点, 两个特征值为实部为正的共轭复数
+	AttractingFocus                          // 稳定焦点, 两个特征值为实部为负的共轭复数
+	HigherOrder                              // 高阶临界点, 雅可比矩阵奇异, 其类型无法由特征值确定
+)
+
+var criticalPointTypeNames = []string{"source", "sink", "saddle", "center", "repellingfocus", "attractingfocus", "higherorder"}
+
+func (t CriticalPointType) String() string {
+	if t < 0 || int(t) >= len(criticalPointTypeNames) {
+		return fmt.Sprintf("CriticalPointType(%d)", int(t))
+	}
+	return criticalPointTypeNames[t]
+}
+
+// centerTol 为判断临界点是否为中心时所用的相对容差: 若雅可比矩阵的迹的绝对值不大于特征值的模的 centerTol 倍,
+// 则认为特征值为纯虚数. 不施加容差时, 舍入误差会使中心被随机地判为不稳定或稳定焦点.
+const centerTol = 1e-6
+
+// singularTol 为判断雅可比矩阵是否奇异时所用的相对容差: 若行列式的绝对值不大于矩阵元素最大绝对值平方的
+// singularTol 倍, 则认为矩阵奇异.
+const singularTol = 1e-12
+
+// zeroDistTol 为合并相邻单元格在公共边界上求得的同一个零点时所用的相对距离容差, 以单元格的边长为单位.
+const zeroDistTol = 1e-9
+
+// cellZeros 按单元格记录已求得的零点, 用于合并相邻单元格在公共边界上求得的同一个零点.
+// 同一个零点只可能由共边或共角点的单元格重复求得, 因而只需与这些单元格内的零点比较.
+type cellZeros struct {
+	g     *grid.Grid
+	tol   float64
+	zeros map[int][]geom.Point
+}
+
+func newCellZeros(g *grid.Grid) *cellZeros {
+	return &cellZeros{g: g, tol: zeroDistTol * math.Max(g.XSpan, g.YSpan), zeros: make(map[int][]geom.Point)}
+}
+
+// add 方法记录单元格 ci 内的零点 p. 若 p 与该单元格或其相邻单元格内已记录的某个零点重合, 则不予记录并返回 false.
+func (z *cellZeros) add(ci int, p geom.Point) bool {
+	xi, yi := z.g.CellPos(ci)
+	for cyi := yi - 1; cyi <= yi+1; cyi++ {
+		for cxi := xi - 1; cxi <= xi+1; cxi++ {
+			if cxi < 0 || cyi < 0 || cxi >= z.g.CellXN || cyi >= z.g.CellYN {
+				continue
+			}
+			for _, q := range z.zeros[z.g.CellIdx(cxi, cyi)] {
+				if math.Hypot(q.X-p.X, q.Y-p.Y) <= z.tol {
+					return false
+				}
+			}
+		}
+	}
+	z.zeros[ci] = append(z.zeros[ci], p)
+	return true
+}
+
+// CriticalPoint 是向量场中的一个临界点, 即向量为零的孤立点. 它与 grid.PointRegion 一样代表场中的一个点状奇异构件,
+// 并实现了 grid.Region 接口, 但其位置由单元格内双线性插值的零点求得, 而不限于网格节点.
+type CriticalPoint struct {
+	geom.Point
+	Cell int               // 临界点所在单元格的索引
+	Kind CriticalPointType // 临界点的类型
+	// Jacobian 为临界点处向量的雅可比矩阵, 第一行为 vx 对 x, y 的偏导数, 第二行为 vy 对 x, y 的偏导数.
+	Jacobian [2][2]float64
+	index    float64
+}
+
+// Type 方法返回 grid.PointRegionType, 即临界点是一个点状的奇异构件.
+func (c *CriticalPoint) Type() int {
+	return grid.PointRegionType
+}
+
+// Index 方法返回临界点的庞加莱(Poincare)指数, 即沿包围该点的一条小闭合曲线走一周时向量旋转的圈数.
+// 鞍点的指数为 -1, 结点, 中心和焦点的指数为 +1, 高阶临界点的指数由向量的旋转圈数直接求得.
+func (c *CriticalPoint) Index() float64 {
+	return c.index
+}
+
+// Eigenvalues 方法返回雅可比矩阵的两个特征值. 若特征值为实数, 则 l1 >= l2; 否则二者共轭, 且 l1 的虚部为正.
+func (c *CriticalPoint) Eigenvalues() (l1, l2 complex128) {
+	j := &c.Jacobian
+	tr, det := j[0][0]+j[1][1], j[0][0]*j[1][1]-j[0][1]*j[1][0]
+	d := cmplx.Sqrt(complex(tr*tr-4.0*det, 0.0))
+	return (complex(tr, 0.0) + d) / 2.0, (complex(tr, 0.0) - d) / 2.0
+}
+
+// Eigenvectors 方法返回雅可比矩阵的两个单位特征向量, 分别与 Eigenvalues 方法返回的 l1, l2 对应. 对于鞍点,
+// e1 和 e2 分别是流线离开和趋近临界点的方向. 若特征值为复数, 则返回一个错误; 若雅可比矩阵为数量矩阵(如各向同性的结点),
+// 则任意向量都是特征向量, 这时返回 x 轴和 y 轴方向的单位向量.
+func (c *CriticalPoint) Eigenvectors() (e1, e2 *vector.Vector, err error) {
+	l1, l2 := c.Eigenvalues()
+	if imag(l1) != 0.0 {
+		return nil, nil, errors.New("the Jacobian of the critical point has complex eigenvalues")
+	}
+	j := &c.Jacobian
+	eig := func(l float64, axis *vector.Vector) *vector.Vector {
+		// (J - l*I) 的两行都与特征向量正交, 取模较大的一行求特征向量以减小误差
+		a := vector.New(j[0][1], l-j[0][0])
+		if b := vector.New(l-j[1][1], j[1][0]); b.Norm() > a.Norm() {
+			a = b
+		}
+		u, err := a.Unit()
+		if err != nil {
+			return axis
+		}
+		return u
+	}
+	return eig(real(l1), vector.Bx()), eig(real(l2), vector.By()), nil
+}
+
+// classify 方法由雅可比矩阵的迹和行列式确定临界点的类型. 对于非奇异的雅可比矩阵, 庞加莱指数即为行列式的符号.
+func (c *CriticalPoint) classify() {
+	j := &c.Jacobian
+	tr, det := j[0][0]+j[1][1], j[0][0]*j[1][1]-j[0][1]*j[1][0]
+	m := math.Max(math.Max(math.Abs(j[0][0]), math.Abs(j[0][1])), math.Max(math.Abs(j[1][0]), math.Abs(j[1][1])))
+	switch {
+	case math.Abs(det) <= singularTol*m*m:
+		c.Kind = HigherOrder
+		return
+	case det < 0.0:
+		c.Kind, c.index = Saddle, -1.0
+		return
+	}
+	c.index = 1.0
+	switch disc := tr*tr - 4.0*det; {
+	case disc >= 0.0 && tr > 0.0:
+		c.Kind = Source
+	case disc >= 0.0:
+		c.Kind = Sink
+	case math.Abs(tr) <= centerTol*math.Sqrt(det):
+		c.Kind = Center
+	case tr > 0.0:
+		c.Kind = RepellingFocus
+	default:
+		c.Kind = AttractingFocus
+	}
+}
+
+// windingNumber 函数求向量沿以 (x, y) 为圆心, r 为半径的圆逆时针走一周时旋转的圈数. v 返回任一点的向量,
+// 圆上各点的向量都不应为零.
+func windingNumber(x, y, r float64, v func(x, y float64) (vx, vy float64)) int {
+	const n = 64
+	sum := 0.0
+	vx, vy := v(x+r, y)
+	a0 := math.Atan2(vy, vx)
+	for k := 1; k <= n; k++ {
+		phi := 2.0 * math.Pi * float64(k) / n
+		vx, vy = v(x+r*math.Cos(phi), y+r*math.Sin(phi))
+		a := math.Atan2(vy, vx)
+		d := a - a0
+		d -= 2.0 * math.Pi * math.Round(d/(2.0*math.Pi)) // 相邻两点间的转角取 (-PI, PI] 内的值
+		sum += d
+		a0 = a
+	}
+	return int(math.Round(sum / (2.0 * math.Pi)))
+}
+
+// CriticalPoints 方法求向量场的所有临界点. 在每个单元格内求两个分量的双线性插值同时为零的点, 并由该点处雅可比
+// 矩阵的特征值确定其类型. 对于由有限元网格创建的向量场, 同样使用网格节点处的向量. 该方法应在 GenNodes 之后调用.
+// 定义域以外的临界点被舍弃; 位于单元格边界上的临界点只返回一次, 其雅可比矩阵由索引最小的单元格求得.
+// 若两个分量在某个单元格内有公共的零值曲线, 则曲线上的点不是孤立的临界点, 不予返回.
+// 返回的临界点按其所在单元格的索引排列.
+func (vf *VectorField) CriticalPoints() ([]*CriticalPoint, error) {
+	if len(vf.nodes) == 0 {
+		return nil, errors.New("the nodes of the vector field have not been generated")
+	}
+	g := vf.grid
+	found := newCellZeros(g)
+	var cps []*CriticalPoint
+	for ci := range g.Cells {
+		if g.CellLoc(ci) == grid.Outside {
+			continue
+		}
+		cell := &g.Cells[ci]
+		var u, v [4]float64
+		for k, ni := range g.NodeIdxesofCell(ci) {
+			u[k], v[k] = vf.nodes[ni].Vector.X, vf.nodes[ni].Vector.Y
+		}
+		for _, p := range cell.Zeros(u, v) {
+			if !g.InDomain(p.X, p.Y) || !found.add(ci, p) {
+				continue
+			}
+			c := &CriticalPoint{Point: p, Cell: ci}
+			c.Jacobian[0][0], c.Jacobian[0][1] = cell.Gradient(p.X, p.Y, u)
+			c.Jacobian[1][0], c.Jacobian[1][1] = cell.Gradient(p.X, p.Y, v)
+			c.classify()
+			if c.Kind == HigherOrder {
+				// 在单元格的双线性函数上求旋转圈数, 圆的半径远小于单元格, 以免包含单元格内的另一个零点
+				c.index = float64(windingNumber(p.X, p.Y, 1e-3*math.Min(g.XSpan, g.YSpan), func(x, y float64) (float64, float64) {
+					return cell.Value(x, y, u[0], u[1], u[2], u[3]), cell.Value(x, y, v[0], v[1], v[2], v[3])
+				}))
+			}
+			cps = append(cps, c)
+		}
+	}
+	return cps, nil
+}
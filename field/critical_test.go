@@ -0,0 +1,92 @@
+package field
+
+import (
+	"math"
+	"testing"
+)
+
+// newSampledVectorField 以 step 为间距在矩形 [-w, w]x[-h, h] 内采样向量场 v, 并由此创建一个分片线性插值的向量场.
+func newSampledVectorField(t *testing.T, w, h, step float64, v func(x, y float64) (vx, vy float64)) *VectorField {
+	var data []*VectorQty
+	for x := -w; x <= w+1e-9; x += step {
+		for y := -h; y <= h+1e-9; y += step {
+			vx, vy := v(x, y)
+			data = append(data, NewVectorQty(x, y, vx, vy))
+		}
+	}
+	vf, err := NewVectorField(data, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	vf.SetMethod(LinearMethod)
+	if err := vf.GenNodes(); err != nil {
+		t.Fatal(err.Error())
+	}
+	return vf
+}
+
+// linearMap 返回线性映射 j * (x - x0, y - y0). 分片线性插值和双线性插值都能精确地重现线性场,
+// 因而由其采样所得的场的零点位置和雅可比矩阵都是精确的.
+func linearMap(j [2][2]float64, x0, y0 float64) func(x, y float64) (float64, float64) {
+	return func(x, y float64) (float64, float64) {
+		dx, dy := x-x0, y-y0
+		return j[0][0]*dx + j[0][1]*dy, j[1][0]*dx + j[1][1]*dy
+	}
+}
+
+func TestCriticalPoints(t *testing.T) {
+	for _, tc := range []struct {
+		j     [2][2]float64
+		kind  CriticalPointType
+		index float64
+	}{
+		{[2][2]float64{{1, 0}, {0, 2}}, Source, 1},
+		{[2][2]float64{{-1, 0.5}, {0, -2}}, Sink, 1},
+		{[2][2]float64{{1, 0}, {0, -1}}, Saddle, -1},
+		{[2][2]float64{{0, -1}, {1, 0}}, Center, 1},
+		{[2][2]float64{{0.2, -1}, {1, 0.2}}, RepellingFocus, 1},
+		{[2][2]float64{{-0.2, -1}, {1, -0.2}}, AttractingFocus, 1},
+	} {
+		vf := newSampledVectorField(t, 2.0, 2.0, 0.5, linearMap(tc.j, 0.3, -0.2))
+		cps, err := vf.CriticalPoints()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(cps) != 1 {
+			t.Errorf("%v: got %d critical points, want 1", tc.kind, len(cps))
+			continue
+		}
+		c := cps[0]
+		if math.Hypot(c.X-0.3, c.Y+0.2) > 1e-9 || c.Kind != tc.kind || c.Index() != tc.index {
+			t.Errorf("%v: got a %v with index %g at %v", tc.kind, c.Kind, c.Index(), c.Point)
+		}
+		for r := range c.Jacobian {
+			for k := range c.Jacobian[r] {
+				if math.Abs(c.Jacobian[r][k]-tc.j[r][k]) > 1e-9 {
+					t.Errorf("%v: got Jacobian %v, want %v", tc.kind, c.Jacobian, tc.j)
+				}
+			}
+		}
+	}
+
+	// 临界点在网格节点上时只返回一次
+	vf := newSampledVectorField(t, 2.0, 2.0, 0.5, linearMap([2][2]float64{{1, 0}, {0, -1}}, 0.0, 0.0))
+	node := vf.grid.Nodes[vf.grid.NodeIdx(vf.grid.NodeXN/2, vf.grid.NodeYN/2)]
+	vf = newSampledVectorField(t, 2.0, 2.0, 0.5, linearMap([2][2]float64{{1, 0}, {0, -1}}, node.X, node.Y))
+	if cps, err := vf.CriticalPoints(); err != nil || len(cps) != 1 {
+		t.Errorf("got %d critical points on a grid node, err: %v", len(cps), err)
+	}
+
+	// 雅可比矩阵奇异的临界点的指数由旋转圈数求得, 如 v = (x^2 - y^2, 2xy) 的二阶零点的指数为 2
+	c := &CriticalPoint{Jacobian: [2][2]float64{{0, 0}, {0, 0}}}
+	if c.classify(); c.Kind != HigherOrder {
+		t.Errorf("a zero Jacobian is classified as %v", c.Kind)
+	}
+	if n := windingNumber(0, 0, 0.1, func(x, y float64) (float64, float64) { return x*x - y*y, 2 * x * y }); n != 2 {
+		t.Errorf("got winding number %d of a dipole, want 2", n)
+	}
+	l1, l2 := (&CriticalPoint{Jacobian: [2][2]float64{{0.2, -1}, {1, 0.2}}}).Eigenvalues()
+	if math.Abs(real(l1)-0.2) > 1e-12 || math.Abs(imag(l1)-1) > 1e-12 || l2 != complex(real(l1), -imag(l1)) {
+		t.Errorf("got eigenvalues %v, %v", l1, l2)
+	}
+}
@@ -0,0 +1,166 @@
+package field
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/grid"
+	"stj/fieldline/num"
+)
+
+// DegeneratePointType 表示张量场退化点的类型, 它由退化点处张量各分量的偏导数确定.
+type DegeneratePointType int
+
+// 在退化点附近, 有些超流线沿径向直接通向退化点, 它们将退化点周围划分为若干扇区. 三分点周围的超流线在 3 个双曲型
+// 扇区中绕过退化点; 楔形点周围的超流线则在一个扇区中折返. 楔形点的径向方向有 1 个或 3 个, 有 3 个时其中两个之间
+// 还夹有一个抛物型扇区, 扇区内的超流线都通向退化点.
+const (
+	Trisector             DegeneratePointType = iota // 三分点, 张量指数为 -1/2, 有 3 个径向方向
+	Wedge                                            // 楔形点, 张量指数为 +1/2, 只有 1 个径向方向
+	ParabolicWedge                                   // 带有抛物型扇区的楔形点, 张量指数为 +1/2, 有 3 个径向方向
+	HigherOrderDegenerate                            // 高阶退化点, 判别式为 0, 其类型无法由一阶偏导数确定
+)
+
+var degeneratePointTypeNames = []string{"trisector", "wedge", "parabolicwedge", "higherorder"}
+
+func (t DegeneratePointType) String() string {
+	if t < 0 || int(t) >= len(degeneratePointTypeNames) {
+		return fmt.Sprintf("DegeneratePointType(%d)", int(t))
+	}
+	return degeneratePointTypeNames[t]
+}
+
+// IsWedge 方法判断退化点是否为楔形点(包括带有抛物型扇区的楔形点).
+func (t DegeneratePointType) IsWedge() bool {
+	return t == Wedge || t == ParabolicWedge
+}
+
+// DegeneratePoint 是张量场中的一个退化点, 即两个特征值相等的孤立点, 在这里特征向量的方向不确定, 超流线或在此交汇,
+// 或绕其折返. 它与 CriticalPoint 一样实现了 grid.Region 接口.
+//
+// 记 α = (XX - YY) / 2, β = -XY, 则按本库的约定(参见 tensor.Tensor.EigValDir), 特征向量 1 的方向角 d1 满足
+// (α, β) = r * (cos 2d1, sin 2d1), 其中 r 为两个特征值之差的一半. 退化点即为 α 和 β 同时为零的点.
+type DegeneratePoint struct {
+	geom.Point
+	Cell int                 // 退化点所在单元格的索引
+	Kind DegeneratePointType // 退化点的类型
+	// Jacobian 为退化点处 (α, β) 的雅可比矩阵, 第一行为 α 对 x, y 的偏导数, 第二行为 β 对 x, y 的偏导数.
+	Jacobian [2][2]float64
+	index    float64
+}
+
+// Type 方法返回 grid.PointRegionType, 即退化点是一个点状的奇异构件.
+func (d *DegeneratePoint) Type() int {
+	return grid.PointRegionType
+}
+
+// Index 方法返回退化点的张量指数, 即沿包围该点的一条小闭合曲线走一周时特征向量旋转的圈数. 特征向量的方向角是
+// (α, β) 方向角的一半, 因而张量指数为 (α, β) 旋转圈数的一半: 三分点为 -1/2, 楔形点为 +1/2.
+func (d *DegeneratePoint) Index() float64 {
+	return d.index
+}
+
+// Discriminant 方法返回判别式 δ = ∂α/∂x * ∂β/∂y - ∂α/∂y * ∂β/∂x, 即雅可比矩阵的行列式.
+// δ < 0 时退化点为三分点, δ > 0 时为楔形点, δ = 0 时为高阶退化点.
+func (d *DegeneratePoint) Discriminant() float64 {
+	j := &d.Jacobian
+	return j[0][0]*j[1][1] - j[0][1]*j[1][0]
+}
+
+// radialLines 方法返回退化点附近特征向量沿径向的各条直线的方向角, 取值在 (-PI/2, PI/2] 内.
+// 在方向角为 φ 的直线上, 由 β*cos 2φ = α*sin 2φ, 令 u = tan φ, 得
+// ∂β/∂y*u^3 + (∂β/∂x + 2∂α/∂y)*u^2 + (2∂α/∂x - ∂β/∂y)*u - ∂β/∂x = 0.
+// 三次项系数为 0 时, φ = PI/2 也是一个解.
+func (d *DegeneratePoint) radialLines() []float64 {
+	a, b, c, e := d.Jacobian[0][0], d.Jacobian[0][1], d.Jacobian[1][0], d.Jacobian[1][1]
+	var phis []float64
+	for _, u := range num.CubicRoots(e, c+2.0*b, 2.0*a-e, -c) {
+		phis = append(phis, math.Atan(u))
+	}
+	if e == 0.0 {
+		phis = append(phis, 0.5*math.Pi)
+	}
+	return phis
+}
+
+// RadialDirs 方法返回退化点附近第 family 族(1 或 2)特征向量沿径向的各个方向角, 按由小到大排列, 取值在 (-PI, PI] 内.
+// 沿这些方向离开退化点的超流线即为该族超流线的分界线. 同一条径向直线上, 一侧沿特征向量 1, 另一侧沿特征向量 2.
+func (d *DegeneratePoint) RadialDirs(family int) []float64 {
+	a, b, c, e := d.Jacobian[0][0], d.Jacobian[0][1], d.Jacobian[1][0], d.Jacobian[1][1]
+	var dirs []float64
+	for _, phi := range d.radialLines() {
+		cs, sn := math.Cos(phi), math.Sin(phi)
+		alpha, beta := a*cs+b*sn, c*cs+e*sn
+		// (α, β) 与 (cos 2φ, sin 2φ) 同向时, 特征向量 1 沿方向 φ; 反向时, 特征向量 2 沿方向 φ
+		s := alpha*math.Cos(2.0*phi) + beta*math.Sin(2.0*phi)
+		if s == 0.0 {
+			continue
+		}
+		if (s > 0.0) != (family == 1) {
+			phi += math.Pi
+			if phi > math.Pi {
+				phi -= 2.0 * math.Pi
+			}
+		}
+		dirs = append(dirs, phi)
+	}
+	sort.Float64s(dirs)
+	return dirs
+}
+
+// classify 方法由判别式确定退化点的类型, 并由径向直线的条数区分两类楔形点.
+func (d *DegeneratePoint) classify() {
+	j := &d.Jacobian
+	m := math.Max(math.Max(math.Abs(j[0][0]), math.Abs(j[0][1])), math.Max(math.Abs(j[1][0]), math.Abs(j[1][1])))
+	switch delta := d.Discriminant(); {
+	case math.Abs(delta) <= singularTol*m*m:
+		d.Kind = HigherOrderDegenerate
+	case delta < 0.0:
+		d.Kind = Trisector
+	case len(d.radialLines()) >= 3:
+		d.Kind = ParabolicWedge
+	default:
+		d.Kind = Wedge
+	}
+}
+
+// DegeneratePoints 方法求张量场的所有退化点. 在每个单元格内求 α 和 β 的双线性插值同时为零的点, 由该点处的判别式
+// 确定其类型, 并由 (α, β) 沿包围该点的小圆的旋转圈数求其张量指数. 无论张量的插值方式如何, 都使用网格节点处张量
+// 各分量的双线性插值. 该方法应在 GenNodes 之后调用. 定义域以外的退化点被舍弃; 位于单元格边界上的退化点只返回一次;
+// 若 α 和 β 在某个单元格内有公共的零值曲线, 则曲线上的点不是孤立的退化点, 不予返回.
+// 返回的退化点按其所在单元格的索引排列.
+func (tf *TensorField) DegeneratePoints() ([]*DegeneratePoint, error) {
+	if len(tf.nodes) == 0 {
+		return nil, errors.New("the nodes of the tensor field have not been generated")
+	}
+	g := tf.grid
+	found := newCellZeros(g)
+	var dps []*DegeneratePoint
+	for ci := range g.Cells {
+		if g.CellLoc(ci) == grid.Outside {
+			continue
+		}
+		cell := &g.Cells[ci]
+		var u, v [4]float64
+		for k, ni := range g.NodeIdxesofCell(ci) {
+			u[k], v[k] = 0.5*(tf.nodes[ni].XX-tf.nodes[ni].YY), -tf.nodes[ni].XY
+		}
+		for _, p := range cell.Zeros(u, v) {
+			if !g.InDomain(p.X, p.Y) || !found.add(ci, p) {
+				continue
+			}
+			d := &DegeneratePoint{Point: p, Cell: ci}
+			d.Jacobian[0][0], d.Jacobian[0][1] = cell.Gradient(p.X, p.Y, u)
+			d.Jacobian[1][0], d.Jacobian[1][1] = cell.Gradient(p.X, p.Y, v)
+			d.classify()
+			d.index = 0.5 * float64(windingNumber(p.X, p.Y, 1e-3*math.Min(g.XSpan, g.YSpan), func(x, y float64) (float64, float64) {
+				return cell.Value(x, y, u[0], u[1], u[2], u[3]), cell.Value(x, y, v[0], v[1], v[2], v[3])
+			}))
+			dps = append(dps, d)
+		}
+	}
+	return dps, nil
+}
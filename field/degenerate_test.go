@@ -0,0 +1,91 @@
+package field
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/grid"
+	"stj/fieldline/tensor"
+)
+
+// newSampledTensorField 以 step 为间距在矩形 [-w, w]x[-h, h] 内采样张量场, 并由此创建一个分片线性插值的张量场.
+// 张量的平均应力为 5, 由 ab 返回 α = (XX - YY) / 2 和 β = -XY.
+func newSampledTensorField(t *testing.T, w, h, step float64, ab func(x, y float64) (alpha, beta float64)) *TensorField {
+	var data []*TensorQty
+	for x := -w; x <= w+1e-9; x += step {
+		for y := -h; y <= h+1e-9; y += step {
+			alpha, beta := ab(x, y)
+			data = append(data, NewTensorQty(x, y, 5.0+alpha, 5.0-alpha, -beta))
+		}
+	}
+	tf, err := NewTensorField(data, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tf.SetMethod(LinearMethod)
+	if err := tf.GenNodes(); err != nil {
+		t.Fatal(err.Error())
+	}
+	return tf
+}
+
+func TestDegeneratePoints(t *testing.T) {
+	for _, tc := range []struct {
+		j     [2][2]float64
+		kind  DegeneratePointType
+		index float64
+		dirs  int
+	}{
+		{[2][2]float64{{1, 0}, {0, -1}}, Trisector, -0.5, 3},
+		{[2][2]float64{{0.5, 1}, {1, -0.2}}, Trisector, -0.5, 3},
+		{[2][2]float64{{1, 0}, {0, 1}}, Wedge, 0.5, 1},
+		{[2][2]float64{{0, -1}, {1, 0}}, Wedge, 0.5, 1},
+		{[2][2]float64{{1, 0}, {0, 3}}, ParabolicWedge, 0.5, 3},
+	} {
+		tf := newSampledTensorField(t, 2.0, 2.0, 0.5, linearMap(tc.j, 0.3, -0.2))
+		dps, err := tf.DegeneratePoints()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(dps) != 1 {
+			t.Errorf("%v: got %d degenerate points, want 1", tc.j, len(dps))
+			continue
+		}
+		var d grid.Region = dps[0]
+		if d.Type() != grid.PointRegionType || d.Index() != tc.index {
+			t.Errorf("%v: got a region of type %d with index %g", tc.j, d.Type(), d.Index())
+		}
+		p := dps[0]
+		if math.Hypot(p.X-0.3, p.Y+0.2) > 1e-9 || p.Kind != tc.kind || p.Kind.IsWedge() != (tc.index > 0.0) {
+			t.Errorf("%v: got a %v at %v", tc.j, p.Kind, p.Point)
+		}
+		// 沿各径向方向, 相应一族特征向量的方向角应与径向方向一致
+		for family := 1; family <= 2; family++ {
+			dirs := p.RadialDirs(family)
+			if len(dirs) != tc.dirs {
+				t.Errorf("%v: got radial directions %v of family %d, want %d directions", tc.j, dirs, family, tc.dirs)
+				continue
+			}
+			for _, phi := range dirs {
+				x, y := p.X+0.1*math.Cos(phi), p.Y+0.1*math.Sin(phi)
+				xx, _ := tf.XX(x, y)
+				yy, _ := tf.YY(x, y)
+				xy, _ := tf.XY(x, y)
+				_, _, d1, d2, _ := tensor.New(xx, yy, xy).EigValDir()
+				d := d1
+				if family == 2 {
+					d = d2
+				}
+				if diff := math.Remainder(d-phi, math.Pi); math.Abs(diff) > 1e-9 {
+					t.Errorf("%v: eigenvector %d is at %g along the radial direction %g", tc.j, family, d, phi)
+				}
+			}
+		}
+	}
+
+	// 各向同性的区域不是孤立的退化点
+	tf := newSampledTensorField(t, 2.0, 2.0, 0.5, linearMap([2][2]float64{{0, 0}, {0, 0}}, 0.0, 0.0))
+	if dps, err := tf.DegeneratePoints(); err != nil || len(dps) != 0 {
+		t.Errorf("got %d degenerate points in an isotropic field, err: %v", len(dps), err)
+	}
+}
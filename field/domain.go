@@ -0,0 +1,70 @@
+package field
+
+import (
+	"fmt"
+
+	"stj/fieldline/delaunay"
+	"stj/fieldline/geom"
+	"stj/fieldline/grid"
+)
+
+// Domain 方法返回场的定义域. 若场没有设置定义域, 即整个矩形网格范围都是定义域, 则返回 nil.
+func (f *baseField) Domain() *grid.Domain {
+	return f.grid.Domain
+}
+
+// SetDomain 方法设置场的定义域, 从而使场的范围能够贴合坝体, 隧洞, 带缺口的板等非矩形的区域. 设置后应重新调用
+// GenNodes 方法: 定义域以外的节点不再进行插值, 也就不会由数据点外插出孔洞中的值; 而 V, XX, EV1 等方法对于
+// 定义域以外的点将返回一个错误, 流线和超流线的积分因而在定义域的边界处终止. 由场生成的其他场(如 GenFieldOfComp)
+// 与之共用同一个网格, 因而也共用同一个定义域. d 为 nil 时取消定义域.
+func (f *baseField) SetDomain(d *grid.Domain) {
+	f.grid.SetDomain(d)
+}
+
+// checkDomain 方法检查点 (x, y) 是否在场的定义域内.
+func (f *baseField) checkDomain(x, y float64) error {
+	if !f.grid.InDomain(x, y) {
+		return fmt.Errorf("the point (%g, %g) is out of the domain of the field", x, y)
+	}
+	return nil
+}
+
+// alphaShape 方法求 n 个数据点的 alpha 形状, 并将其作为定义域返回. pos 返回索引为 i 的数据点的坐标.
+func (f *baseField) alphaShape(n int, pos func(i int) (x, y float64), alpha float64) (*grid.Domain, error) {
+	ps := make([]geom.Point, n)
+	for i := range ps {
+		ps[i].X, ps[i].Y = pos(i)
+	}
+	t, err := delaunay.New(ps)
+	if err != nil {
+		return nil, err
+	}
+	rings, err := t.AlphaShape(alpha)
+	if err != nil {
+		return nil, err
+	}
+	return grid.NewDomainOfRings(rings)
+}
+
+// AlphaShape 方法求标量场中数据点的 alpha 形状(一种凹包), 并将其作为一个定义域返回, 可用于 SetDomain 方法.
+// 外接圆半径大于 alpha 的 Delaunay 三角形被视为数据以外的空白区域; alpha <= 0 时根据点距自动确定.
+// 面积最大的区域以外的零散区域被舍弃.
+func (sf *ScalarField) AlphaShape(alpha float64) (*grid.Domain, error) {
+	return sf.alphaShape(len(sf.data), func(i int) (x, y float64) {
+		return sf.data[i].X, sf.data[i].Y
+	}, alpha)
+}
+
+// AlphaShape 方法求张量场中数据点的 alpha 形状, 其用法与 ScalarField 的 AlphaShape 方法相同.
+func (tf *TensorField) AlphaShape(alpha float64) (*grid.Domain, error) {
+	return tf.alphaShape(len(tf.data), func(i int) (x, y float64) {
+		return tf.data[i].X, tf.data[i].Y
+	}, alpha)
+}
+
+// AlphaShape 方法求向量场中数据点的 alpha 形状, 其用法与 ScalarField 的 AlphaShape 方法相同.
+func (vf *VectorField) AlphaShape(alpha float64) (*grid.Domain, error) {
+	return vf.alphaShape(len(vf.data), func(i int) (x, y float64) {
+		return vf.data[i].X, vf.data[i].Y
+	}, alpha)
+}
@@ -0,0 +1,78 @@
+package field_test
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/field"
+	"stj/fieldline/ode"
+)
+
+// TestDomain 检查在中间有方形孔洞的数据中, 由 alpha 形状得到的定义域使孔洞内既没有场值, 也没有等值线,
+// 且超流线在孔洞的边界处终止.
+func TestDomain(t *testing.T) {
+	var ss []*field.ScalarQty
+	var ts []*field.TensorQty
+	for i := 0.0; i <= 20; i++ {
+		for j := 0.0; j <= 20; j++ {
+			if i < 6 || i > 14 || j < 6 || j > 14 {
+				ss = append(ss, field.NewScalarQty(i, j, i))
+				ts = append(ts, field.NewTensorQty(i, j, 2, 1, 0))
+			}
+		}
+	}
+	sf, err := field.NewScalarField(ss, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	d, err := sf.AlphaShape(0.0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(d.Holes) != 1 || !d.Contains(2, 10) || d.Contains(10, 10) {
+		t.Fatalf("got a domain with %d holes", len(d.Holes))
+	}
+	sf.SetDomain(d)
+	if err := sf.GenNodes(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := sf.V(10, 10); err == nil {
+		t.Error("the value in the hole should be rejected")
+	}
+	if v, err := sf.V(2.5, 10); err != nil || math.Abs(v-2.5) > 0.5 {
+		t.Errorf("V(2.5, 10) = %g, %v", v, err)
+	}
+	lines, err := sf.Contour(10)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(lines) == 0 {
+		t.Fatal("no contour found")
+	}
+	for _, l := range lines {
+		if y := 0.5 * (l.Y1 + l.Y2); y > 5.5 && y < 14.5 {
+			t.Errorf("the contour line (%g, %g)-(%g, %g) crosses the hole", l.X1, l.Y1, l.X2, l.Y2)
+		}
+	}
+
+	tf, err := field.NewTensorField(ts, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tf.SetDomain(d)
+	tf.Align()
+	if err := tf.GenNodes(); err != nil {
+		t.Fatal(err.Error())
+	}
+	slope := ode.ODE(func(x, y float64) (float64, error) {
+		d, err := tf.ED1(x, y)
+		return math.Tan(d), err
+	})
+	points, _ := ode.Steps(slope, 2, 10, true, 500, nil)
+	if len(points) == 0 {
+		t.Fatal("no hyperstreamline traced")
+	}
+	if last := points[len(points)-1]; last.X > 5 || last.X < 5-1e-3 {
+		t.Errorf("the hyperstreamline stops at (%g, %g), want it at the boundary of the hole", last.X, last.Y)
+	}
+}
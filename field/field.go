@@ -1,37 +1,28 @@
 package field
 
 import (
+	"errors"
 	"math"
 
+	"stj/fieldline/delaunay"
 	"stj/fieldline/geom"
 	"stj/fieldline/grid"
+	"stj/fieldline/mesh"
+	"stj/fieldline/num"
 )
 
-// DiscardZeroQty 为 true 时, 若从外部导入的某个物理量的所有分量都为 0, 则直接舍弃.
-var DiscardZeroQty = false
-
-// MinIntrplQtyNum, MaxIntrplQtyNum 和 MinIntrplLayer, MinIntrplLayer 这两组数据组合起来
-// 共同形成了 9 种插值判别条件.
-
 // MinIntrplQtyNum 是进行插值时最少需找到的依赖场量个数, 一般是 1.
 const MinIntrplQtyNum = 1
 
-// MaxIntrplQtyNum 是进行插值时最多需找到的依赖场量个数, 可由用户指定.
-var MaxIntrplQtyNum = 8
-
-// MinIntrplLayer 是进行插值时初次查找的层数, 一般应是 0 或 1.
-var MinIntrplLayer = 0
-
-// MaxIntrplLayer 是进行插值是最大应查找的网格层数, 一般和 MaxIntrplQtyNum 量之间满足如下关系:
-// (2*MaxIntrplLayer+1)^2 * AvgPointNumPerCell = MaxIntrplQtyNum
-var MaxIntrplLayer = int(math.Ceil((math.Sqrt(float64(MaxIntrplQtyNum)/float64(grid.AvgQtyNumPerCell)) - 1.0) * 0.5))
-
-// AssignZeroForFailIntrpl 为 true 时, 若在插值失败, 则插入零值. 若该值为 false, 在插值失败时不插入零值, 而是报告一个错误.
-var AssignZeroOnIntrplFail = true
-
 // baseField 结构体相当于所有场结构体的基类.
 type baseField struct {
 	grid *grid.Grid
+	mesh *mesh.Mesh // 若不为 nil, 则场的数据与网格的节点一一对应, 场内的值由形函数插值求得
+	// method 为由离散数据计算网格节点处场量时所用的插值方法, tri 为其中某些方法所需的三角剖分
+	method Method
+	tri    *delaunay.Triangulation
+	index  grid.Index // 用于查找数据点的空间索引, 索引值即数据点在场数据中的位置
+	opts   Options    // 创建场时所用的参数
 }
 
 // Range 方法返回场的矩形坐标范围.
@@ -39,6 +30,155 @@ func (f *baseField) Range() *geom.Rect {
 	return &f.grid.Range
 }
 
+// Grid 方法返回场所使用的网格.
+func (f *baseField) Grid() *grid.Grid {
+	return f.grid
+}
+
+// Index 方法返回场用于查找数据点的空间索引.
+func (f *baseField) Index() grid.Index {
+	return f.index
+}
+
+// SetIndex 方法设置场用于查找数据点的空间索引. 默认的索引为四叉树(grid.Quadtree), 它能够适应点距相差悬殊的数据;
+// 对于点距大致均匀的数据, 也可以使用由 grid.NewGridIndex 创建的网格索引. 索引值应为数据点在场数据中的位置.
+// IDW 插值, RBF 插值, 克里金插值以及张量场的 Align 方法都通过该索引查找数据点.
+func (f *baseField) SetIndex(idx grid.Index) {
+	f.index = idx
+}
+
+// Options 方法返回场的参数.
+func (f *baseField) Options() Options {
+	return f.opts
+}
+
+// SetOptions 方法修改场的参数, 设置后应重新调用 GenNodes 方法. 场的网格在创建时已确定, 因而 Density 不能修改;
+// DiscardZeroQty 只在解析数据时起作用, 修改它不会影响已有的数据; QuadLeafSize 只在创建空间索引时起作用. 若参数无效, 则返回一个错误, 场的参数保持不变.
+func (f *baseField) SetOptions(o Options) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	if o.Density != f.opts.Density {
+		return errors.New("the grid density of a created field cannot be changed")
+	}
+	f.opts = o
+	return nil
+}
+
+// IDWConfig 方法返回场的 IDW 插值参数.
+func (f *baseField) IDWConfig() IDWConfig {
+	return f.opts.IDW
+}
+
+// SetIDWConfig 方法设置场的 IDW 插值参数, 设置后应重新调用 GenNodes 方法. 这些参数也用于 RBF 插值和克里金插值
+// 失败时的退化插值. 若参数无效, 则返回一个错误, 场的参数保持不变.
+func (f *baseField) SetIDWConfig(c IDWConfig) error {
+	o := f.opts
+	o.IDW = c
+	return f.SetOptions(o)
+}
+
+// nearest 方法利用空间索引返回距点 (x, y) 最近的 n 个数据点, 按距离由近到远排列. 当数据点不足 n 个时,
+// 返回所有的数据点.
+func (f *baseField) nearest(x, y float64, n int) ([]grid.Neighbor, error) {
+	if n <= 0 {
+		return nil, errors.New("the number of nearest quantities should be greater than zero")
+	}
+	return f.index.KNearest(x, y, n), nil
+}
+
+// idwNeighbors 方法按场的 IDW 插值参数返回对点 (x, y) 进行插值时所用的数据点及其到该点的各向异性距离,
+// 按距离由近到远排列. pos 返回索引为 i 的数据点的坐标. 若没有找到任何数据点, 则返回 nil.
+func (f *baseField) idwNeighbors(x, y float64, pos func(i int) (x, y float64)) []grid.Neighbor {
+	c := &f.opts.IDW
+	// within 返回各向异性距离不超过 r 的所有数据点. 其欧氏距离不超过 r*c.stretch(), 由此缩小查找范围.
+	within := func(r float64) []grid.Neighbor {
+		ns := f.index.Within(x, y, r*c.stretch())
+		out := ns[:0]
+		for _, n := range ns {
+			px, py := pos(n.Idx)
+			if n.Dist = c.dist(px-x, py-y); n.Dist <= r {
+				out = append(out, n)
+			}
+		}
+		grid.SortNeighbors(out)
+		return out
+	}
+	span := math.Max(f.grid.XSpan, f.grid.YSpan)
+	r := c.Radius
+	if r <= 0.0 {
+		r = (float64(c.maxLayer(f.opts.Density)) + 0.5) * span
+	}
+	var ns []grid.Neighbor
+	if c.Sector > 0 {
+		var counts [4]int
+		for _, n := range within(r) {
+			px, py := pos(n.Idx)
+			u, v := c.offset(px-x, py-y)
+			q := 0
+			if u < 0.0 {
+				q++
+			}
+			if v < 0.0 {
+				q += 2
+			}
+			if counts[q] < c.Sector {
+				counts[q]++
+				ns = append(ns, n)
+			}
+		}
+	} else if ns = within(math.Min((float64(c.MinLayer)+0.5)*span, r)); len(ns) < c.MaxQty {
+		// 欧氏距离最近的 MaxQty 个点中各向异性距离的最大值, 是各向异性距离最近的 MaxQty 个点所在范围的上限
+		d := r
+		if near := f.index.KNearest(x, y, c.MaxQty); len(near) == c.MaxQty {
+			d = 0.0
+			for _, n := range near {
+				px, py := pos(n.Idx)
+				d = math.Max(d, c.dist(px-x, py-y))
+			}
+			d = math.Min(d, r)
+		}
+		if ns = within(d); len(ns) > c.MaxQty {
+			ns = ns[:c.MaxQty]
+		}
+	}
+	if len(ns) < MinIntrplQtyNum {
+		return nil
+	}
+	return ns
+}
+
+// idwWeights 返回以 power 为幂参数对数据点 ns 进行 IDW 插值时各点的权重. 若待求点与某个数据点重合,
+// 则只有该点的权重为 1.
+func idwWeights(ns []grid.Neighbor, power float64) []float64 {
+	ws := make([]float64, len(ns))
+	for i, n := range ns {
+		if num.Equal(n.Dist, 0.0) {
+			for j := range ws {
+				ws[j] = 0.0
+			}
+			ws[i] = 1.0
+			return ws
+		}
+		ws[i] = 1.0 / math.Pow(n.Dist, power)
+	}
+	return ws
+}
+
+// neighborIdxes 返回查找结果中各个点的索引.
+func neighborIdxes(ns []grid.Neighbor) []int {
+	idxes := make([]int, len(ns))
+	for i, n := range ns {
+		idxes[i] = n.Idx
+	}
+	return idxes
+}
+
+// Mesh 方法返回场所使用的有限元网格. 对于由无规则离散分布的数据创建的场, 返回 nil.
+func (f *baseField) Mesh() *mesh.Mesh {
+	return f.mesh
+}
+
 // Field 接口表示一个场, 它可能是一个标量场, 向量场或张量场, 甚至可以是一个点场.
 type Field interface {
 	Range() *geom.Rect
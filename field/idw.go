@@ -2,13 +2,91 @@ package field
 
 import (
 	"errors"
+	"fmt"
 	"math"
 
+	"stj/fieldline/grid"
 	"stj/fieldline/num"
 )
 
-// DefaultIDWPower 为 IDW() 函数默认使用的幂参数. 当其他函数调用 IDW() 时, 可将该值传给 IDW().
-var DefaultIDWPower = 3.0
+// IDWConfig 结构体给出了反距离加权插值(IDW)的参数, 它是场的参数 Options 的一部分, 可由场的 SetIDWConfig 方法修改.
+type IDWConfig struct {
+	// Power 为幂参数, 其含义见 IDW 函数.
+	Power float64
+	// MaxQty 为插值时最多使用的数据点个数, 不应小于 MinIntrplQtyNum.
+	MaxQty int
+	// MinLayer 为初次查找的网格层数, 一般应是 0 或 1: 与待求点的距离不超过 MinLayer+0.5 个单元格边长的数据点
+	// 都参与插值, 即使其个数超过 MaxQty; 若这些点不足 MaxQty 个, 则改用距待求点最近的 MaxQty 个点.
+	MinLayer int
+	// Radius 为搜索半径, 距离超过该值的数据点不参与插值. 若为 0, 则取为 MaxLayer+0.5 个单元格边长,
+	// 其中 MaxLayer 满足 (2*MaxLayer+1)^2 * Density = MaxQty, Density 为场的参数 Options 中的网格密度.
+	Radius float64
+	// Ratio 为各向异性的拉伸比, Angle 为拉伸方向与 x 轴的夹角(弧度). 沿该方向的距离被缩短为原来的 1/Ratio,
+	// 即数据点在该方向上的影响范围是其垂直方向的 Ratio 倍, 适用于成层的岩体, 轧制的板材等. Ratio 为 0 或 1 时
+	// 各向同性. 搜索半径, 查找的层数以及权重都按缩短后的距离计算.
+	Ratio, Angle float64
+	// Sector 若大于 0, 则进行象限搜索: 以沿 Angle 方向及其垂直方向的两条直线将待求点周围分为 4 个象限,
+	// 在搜索半径内每个象限最多取最近的 Sector 个数据点, 以免所用的数据点都偏在待求点的一侧.
+	// 此时 MinLayer 不起作用, MaxQty 仅用于确定默认的搜索半径.
+	Sector int
+}
+
+// Validate 方法检查参数是否有效.
+func (c *IDWConfig) Validate() error {
+	if c.Power < 0.0 {
+		return errors.New("the IDW power should not be negative")
+	}
+	if c.MaxQty < MinIntrplQtyNum {
+		return fmt.Errorf("the maximum interpolation quantity number should not be less than %d", MinIntrplQtyNum)
+	}
+	if c.MinLayer < 0 || c.Radius < 0.0 || c.Ratio < 0.0 || c.Sector < 0 {
+		return errors.New("the IDW search parameters should not be negative")
+	}
+	return nil
+}
+
+// maxLayer 方法返回在每个单元格平均有 density 个数据点的网格中, MaxQty 个数据点平均所占的网格层数.
+func (c *IDWConfig) maxLayer(density float64) int {
+	return int(math.Ceil((math.Sqrt(float64(c.MaxQty)/density) - 1.0) * 0.5))
+}
+
+// offset 方法将坐标差 (dx, dy) 转换到沿 Angle 方向及其垂直方向的坐标系中, 并将沿 Angle 方向的分量缩短为 1/Ratio.
+func (c *IDWConfig) offset(dx, dy float64) (u, v float64) {
+	sin, cos := math.Sincos(c.Angle)
+	u, v = dx*cos+dy*sin, dy*cos-dx*sin
+	if c.Ratio > 0.0 {
+		u /= c.Ratio
+	}
+	return u, v
+}
+
+// dist 方法返回坐标差为 (dx, dy) 的两点间的各向异性距离.
+func (c *IDWConfig) dist(dx, dy float64) float64 {
+	return math.Hypot(c.offset(dx, dy))
+}
+
+// stretch 方法返回两点间的欧氏距离与各向异性距离之比的上限.
+func (c *IDWConfig) stretch() float64 {
+	return math.Max(c.Ratio, 1.0)
+}
+
+// Value 方法按参数 c 的幂参数和各向异性距离, 对标量场量 ss 进行 IDW 插值, 求得点 (x, y) 处的值.
+// ss 中的所有场量都参与插值, 搜索参数不起作用.
+func (c *IDWConfig) Value(ss []*ScalarQty, x, y float64) (float64, error) {
+	if len(ss) == 0 {
+		return 0.0, errors.New("the length of scalar quantity slice should not be zero")
+	}
+	ns := make([]grid.Neighbor, len(ss))
+	for i, s := range ss {
+		ns[i] = grid.Neighbor{Idx: i, Dist: c.dist(s.X-x, s.Y-y)}
+	}
+	var a, b float64
+	for i, w := range idwWeights(ns, c.Power) {
+		a += w * ss[i].V
+		b += w
+	}
+	return a / b, nil
+}
 
 // IDW 函数实现了实现了多元插值算法中的一种: 反距离加权插值(Inverse Distance Weighted).
 // 其中 ScalarQty 是标量场中的量, x, y 是要插值的点坐标, power 是插值的幂参数.
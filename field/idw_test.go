@@ -29,7 +29,7 @@ func TestIDW(t *testing.T) {
 		field.NewScalarQty(180, 210, 131.78),
 	}
 	x, y = 110.0, 150.0
-	val, err = field.IDW(ss, x, y, field.DefaultIDWPower)
+	val, err = field.IDW(ss, x, y, field.DefaultOptions().IDW.Power)
 	if err != nil || math.Abs(val-112.5889) > 1.0e-4 {
 		t.Error("func IDW wrong")
 	}
@@ -48,3 +48,19 @@ func TestIDW(t *testing.T) {
 		t.Error("func IDW wrong")
 	}
 }
+
+func TestIDWConfigValue(t *testing.T) {
+	ss := []*field.ScalarQty{field.NewScalarQty(1, 0, 10), field.NewScalarQty(0, 1, 0)}
+	c := field.IDWConfig{Power: 2.0, MaxQty: 8}
+	if v, err := c.Value(ss, 0, 0); err != nil || math.Abs(v-5.0) > 1.0e-10 {
+		t.Errorf("isotropic IDW wrong, got %v", v)
+	}
+	c.Ratio = 4.0
+	if v, err := c.Value(ss, 0, 0); err != nil || math.Abs(v-160.0/17.0) > 1.0e-10 {
+		t.Errorf("anisotropic IDW wrong, got %v", v)
+	}
+	c.Angle = math.Pi / 2.0
+	if v, err := c.Value(ss, 0, 0); err != nil || math.Abs(v-10.0/17.0) > 1.0e-10 {
+		t.Errorf("rotated anisotropic IDW wrong, got %v", v)
+	}
+}
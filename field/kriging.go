@@ -0,0 +1,451 @@
+package field
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"stj/fieldline/grid"
+	"stj/fieldline/num"
+)
+
+// VariogramModel 表示变差函数的理论模型.
+type VariogramModel int
+
+// 以下模型中 h 为距离, a 为变程. 指数模型和高斯模型的变程取为实际变程, 即变差函数达到基台值 95% 时的距离.
+const (
+	SphericalModel   VariogramModel = iota // 球状模型 1.5*(h/a)-0.5*(h/a)^3, h >= a 时为 1
+	ExponentialModel                       // 指数模型 1-exp(-3*h/a)
+	GaussianModel                          // 高斯模型 1-exp(-3*(h/a)^2)
+)
+
+var variogramModelNames = []string{"spherical", "exponential", "gaussian"}
+
+func (m VariogramModel) String() string {
+	if m < 0 || int(m) >= len(variogramModelNames) {
+		return fmt.Sprintf("VariogramModel(%d)", int(m))
+	}
+	return variogramModelNames[m]
+}
+
+// ParseVariogramModel 根据名称(不区分大小写)返回相应的变差函数模型, 名称为 VariogramModel 的 String 方法的返回值.
+func ParseVariogramModel(name string) (VariogramModel, error) {
+	for i, n := range variogramModelNames {
+		if strings.EqualFold(name, n) {
+			return VariogramModel(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown variogram model %q", name)
+}
+
+// shape 方法返回模型在相对距离 r = h/a 处的值, 其值由 0 增大到 1.
+func (m VariogramModel) shape(r float64) float64 {
+	switch m {
+	case ExponentialModel:
+		return 1.0 - math.Exp(-3.0*r)
+	case GaussianModel:
+		return 1.0 - math.Exp(-3.0*r*r)
+	}
+	if r >= 1.0 {
+		return 1.0
+	}
+	return 1.5*r - 0.5*r*r*r
+}
+
+// Variogram 结构体表示一个理论变差函数.
+type Variogram struct {
+	Model  VariogramModel
+	Nugget float64 // 块金值
+	Sill   float64 // 基台值, 包括块金值在内
+	Range  float64 // 变程
+}
+
+// Gamma 方法返回变差函数在距离 h 处的值. h 为 0 时总是返回 0.
+func (v *Variogram) Gamma(h float64) float64 {
+	if h == 0.0 {
+		return 0.0
+	}
+	return v.Nugget + (v.Sill-v.Nugget)*v.Model.shape(h/v.Range)
+}
+
+// Lag 结构体表示实验变差函数中的一组数据点对.
+type Lag struct {
+	H     float64 // 该组点对距离的平均值
+	Gamma float64 // 该组点对的半方差
+	N     int     // 该组点对的个数
+}
+
+// ExperimentalVariogram 计算标量场量 ss 的实验变差函数. 距离不大于 maxDist 的点对按距离等分为 lagNum 组,
+// 空的组将被舍弃. 若 maxDist <= 0, 则取为数据点坐标范围对角线长度的一半. 该函数遍历所有的点对,
+// 计算量随数据点个数的平方增长.
+func ExperimentalVariogram(ss []*ScalarQty, lagNum int, maxDist float64) ([]Lag, error) {
+	if len(ss) < 2 {
+		return nil, errors.New("at least 2 quantities are needed by the variogram")
+	}
+	if lagNum <= 0 {
+		return nil, errors.New("the number of lags should be greater than zero")
+	}
+	if maxDist <= 0.0 {
+		xmin, xmax, ymin, ymax := ss[0].X, ss[0].X, ss[0].Y, ss[0].Y
+		for _, s := range ss {
+			xmin, xmax = math.Min(xmin, s.X), math.Max(xmax, s.X)
+			ymin, ymax = math.Min(ymin, s.Y), math.Max(ymax, s.Y)
+		}
+		maxDist = 0.5 * math.Hypot(xmax-xmin, ymax-ymin)
+		if maxDist == 0.0 {
+			return nil, errors.New("all the quantities coincide")
+		}
+	}
+	width := maxDist / float64(lagNum)
+	lags := make([]Lag, lagNum)
+	for i := 0; i < len(ss); i++ {
+		for j := i + 1; j < len(ss); j++ {
+			h := math.Hypot(ss[i].X-ss[j].X, ss[i].Y-ss[j].Y)
+			if h > maxDist || h == 0.0 {
+				continue
+			}
+			k := int(h / width)
+			if k >= lagNum {
+				k = lagNum - 1
+			}
+			d := ss[i].V - ss[j].V
+			lags[k].H += h
+			lags[k].Gamma += 0.5 * d * d
+			lags[k].N++
+		}
+	}
+	out := lags[:0]
+	for _, l := range lags {
+		if l.N > 0 {
+			out = append(out, Lag{H: l.H / float64(l.N), Gamma: l.Gamma / float64(l.N), N: l.N})
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no pair of quantities within the maximum distance")
+	}
+	return out, nil
+}
+
+// FitVariogram 以各组点对的个数为权, 利用最小二乘法由实验变差函数 lags 拟合模型 model 的块金值, 基台值和变程.
+// 变程在 (0, 2*最大距离] 内搜索, 对于给定的变程, 块金值和基台值由非负的线性最小二乘解求得.
+func FitVariogram(lags []Lag, model VariogramModel) (*Variogram, error) {
+	if len(lags) == 0 {
+		return nil, errors.New("no lag to fit the variogram")
+	}
+	maxH := 0.0
+	for _, l := range lags {
+		maxH = math.Max(maxH, l.H)
+	}
+	var best *Variogram
+	bestErr := math.Inf(1)
+	const steps = 200
+	for s := 1; s <= steps; s++ {
+		a := 2.0 * maxH * float64(s) / steps
+		// 拟合 gamma = c0 + c*f(h/a) 中的 c0 和 c
+		var sw, sf, sff, sg, sfg float64
+		for _, l := range lags {
+			w, f := float64(l.N), model.shape(l.H/a)
+			sw += w
+			sf += w * f
+			sff += w * f * f
+			sg += w * l.Gamma
+			sfg += w * f * l.Gamma
+		}
+		c0, c := 0.0, 0.0
+		if det := sw*sff - sf*sf; det > 0.0 {
+			c0 = (sff*sg - sf*sfg) / det
+			c = (sw*sfg - sf*sg) / det
+		}
+		if c0 < 0.0 || c <= 0.0 {
+			c0, c = 0.0, 0.0
+			if sff > 0.0 {
+				c = sfg / sff
+			}
+			if c <= 0.0 {
+				c0, c = sg/sw, 0.0
+			}
+		}
+		var e float64
+		for _, l := range lags {
+			d := c0 + c*model.shape(l.H/a) - l.Gamma
+			e += float64(l.N) * d * d
+		}
+		if e < bestErr {
+			bestErr = e
+			best = &Variogram{Model: model, Nugget: c0, Sill: c0 + c, Range: a}
+		}
+	}
+	if best.Sill <= 0.0 {
+		return nil, errors.New("the quantities have no spatial variation")
+	}
+	return best, nil
+}
+
+// Kriging 结构体给出了普通克里金插值的参数. 普通克里金插值是以变差函数描述数据的空间相关性的最优线性无偏估计,
+// 在给出估计值的同时给出估计的方差, 即克里金方差, 适用于钻孔, 地应力测点等稀疏分布的实测数据.
+type Kriging struct {
+	// Variogram 为插值所用的变差函数. 若为 nil, 则由数据的实验变差函数按 Model 自动拟合.
+	Variogram *Variogram
+	Model     VariogramModel
+	// Lags 为自动拟合变差函数时实验变差函数的分组数.
+	Lags int
+	// Neighbors 为局部插值时使用的数据点个数, 即距点最近的 Neighbors 个数据点, 其查找方式与 RBF 的 Neighbors 相同.
+	// 若为 0, 则所有数据点都参与插值: 方程组的系数矩阵只分解一次, 计算量随数据点个数的立方增长, 此后每个点的
+	// 计算量随数据点个数的平方增长, 只适用于数据点不多的场.
+	Neighbors int
+}
+
+// Validate 方法检查参数是否有效.
+func (k *Kriging) Validate() error {
+	if k.Variogram != nil {
+		if k.Variogram.Sill <= 0.0 || k.Variogram.Range <= 0.0 {
+			return errors.New("the sill and range of the variogram should be greater than zero")
+		}
+	} else if k.Model < 0 || int(k.Model) >= len(variogramModelNames) {
+		return fmt.Errorf("unknown variogram model %v", k.Model)
+	}
+	if k.Lags <= 0 {
+		return errors.New("the number of lags should be greater than zero")
+	}
+	if k.Neighbors < 0 {
+		return errors.New("the number of kriging neighbors should not be negative")
+	}
+	return nil
+}
+
+// variogram 方法返回插值所用的变差函数, 必要时由标量场量 ss 自动拟合.
+func (k *Kriging) variogram(ss []*ScalarQty) (*Variogram, error) {
+	if k.Variogram != nil {
+		if k.Variogram.Sill <= 0.0 || k.Variogram.Range <= 0.0 {
+			return nil, errors.New("the sill and range of the variogram should be greater than zero")
+		}
+		return k.Variogram, nil
+	}
+	lags, err := ExperimentalVariogram(ss, k.Lags, 0.0)
+	if err != nil {
+		return nil, err
+	}
+	return FitVariogram(lags, k.Model)
+}
+
+// Estimate 方法利用普通克里金插值, 由标量场量 ss 求得点 (x, y) 处的估计值 v 及其克里金方差 variance.
+// ss 中的所有场量都参与插值, Neighbors 不起作用.
+func (k *Kriging) Estimate(ss []*ScalarQty, x, y float64) (v, variance float64, err error) {
+	if len(ss) == 0 {
+		return 0.0, 0.0, errors.New("the length of scalar quantity slice should not be zero")
+	}
+	vg, err := k.variogram(ss)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	return krige(vg, ss, x, y)
+}
+
+// krige 函数利用变差函数 vg 对标量场量 ss 进行普通克里金插值. 其中的权重 w 满足方程组:
+//
+//	sum(w[j]*gamma(d[i][j])) + mu = gamma(d[i][0]), i = 1, ..., n
+//	sum(w[j]) = 1
+//
+// 其中 d[i][0] 为第 i 个数据点到点 (x, y) 的距离, mu 为拉格朗日乘子, 克里金方差为 sum(w[i]*gamma(d[i][0])) + mu.
+// 为使方程组中各元素的量级相同, 变差函数先除以基台值, 这并不改变权重.
+func krige(vg *Variogram, ss []*ScalarQty, x, y float64) (v, variance float64, err error) {
+	lu, err := krigSystem(vg, ss)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	return krigSolve(lu, vg, ss, x, y)
+}
+
+// krigSystem 函数对 krige 中方程组的系数矩阵进行 LU 分解. 系数矩阵只与数据点有关, 而与待求点无关,
+// 因而同一组数据点对所有的待求点只需分解一次.
+func krigSystem(vg *Variogram, ss []*ScalarQty) (*num.LU, error) {
+	n := len(ss)
+	a := make([][]float64, n+1)
+	for i := range a {
+		a[i] = make([]float64, n+1)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			a[i][j] = vg.Gamma(math.Hypot(ss[i].X-ss[j].X, ss[i].Y-ss[j].Y)) / vg.Sill
+			a[j][i] = a[i][j]
+		}
+		a[i][n], a[n][i] = 1.0, 1.0
+	}
+	return num.Factor(a)
+}
+
+// krigSolve 函数利用由 krigSystem 求得的分解 lu 求解 krige 中的方程组, 得到点 (x, y) 处的估计值及其克里金方差.
+func krigSolve(lu *num.LU, vg *Variogram, ss []*ScalarQty, x, y float64) (v, variance float64, err error) {
+	n := len(ss)
+	b := make([][]float64, n+1)
+	g0 := make([]float64, n)
+	for i := 0; i < n; i++ {
+		g0[i] = vg.Gamma(math.Hypot(ss[i].X-x, ss[i].Y-y)) / vg.Sill
+		b[i] = []float64{g0[i]}
+	}
+	b[n] = []float64{1.0}
+	if err := lu.Solve(b); err != nil {
+		return 0.0, 0.0, err
+	}
+	for i := 0; i < n; i++ {
+		v += b[i][0] * ss[i].V
+		variance += b[i][0] * g0[i]
+	}
+	variance = (variance + b[n][0]) * vg.Sill
+	if variance < 0.0 {
+		variance = 0.0
+	}
+	return v, variance, nil
+}
+
+// krigIntrpl 方法返回按场的参数 Options.Kriging 对 ss 进行插值的函数, ss 与场中的数据点一一对应.
+// 若 Neighbors 为 0, 则方程组的系数矩阵只分解一次; 否则对每个点都由其附近的数据点进行局部插值. 若方程组奇异(如有重合的数据点),
+// 则退化为 IDW 插值, 其方差取为基台值. 若点附近没有任何数据点, 则与 IDW 插值失败时的处理方式相同,
+// 插值失败而插入零值时, 方差也取为基台值.
+func (f *baseField) krigIntrpl(ss []*ScalarQty) (func(x, y float64) (v, variance float64, err error), error) {
+	k := f.opts.Kriging
+	vg, err := k.variogram(ss)
+	if err != nil {
+		return nil, err
+	}
+	fallback := func(ss []*ScalarQty, x, y float64) (float64, float64, error) {
+		v, err := f.opts.IDW.Value(ss, x, y)
+		return v, vg.Sill, err
+	}
+	if k.Neighbors <= 0 {
+		lu, err := krigSystem(vg, ss)
+		return func(x, y float64) (float64, float64, error) {
+			if err != nil {
+				return fallback(ss, x, y)
+			}
+			return krigSolve(lu, vg, ss, x, y)
+		}, nil
+	}
+	return func(x, y float64) (float64, float64, error) {
+		idxes := f.nearQtyIdxes(x, y, k.Neighbors)
+		if len(idxes) == 0 {
+			if !f.opts.AssignZeroOnIntrplFail {
+				return 0.0, 0.0, errors.New("no known point existing around the given point")
+			}
+			return 0.0, vg.Sill, nil
+		}
+		near := make([]*ScalarQty, len(idxes))
+		for i, qi := range idxes {
+			near[i] = ss[qi]
+		}
+		v, variance, err := krige(vg, near, x, y)
+		if err != nil {
+			return fallback(near, x, y)
+		}
+		return v, variance, nil
+	}, nil
+}
+
+// krigValue 方法返回利用普通克里金插值计算网格节点处标量的函数.
+func (sf *ScalarField) krigValue() (func(x, y float64) (float64, error), error) {
+	intrpl, err := sf.krigIntrpl(sf.data)
+	if err != nil {
+		return nil, err
+	}
+	return func(x, y float64) (float64, error) {
+		v, _, err := intrpl(x, y)
+		return v, err
+	}, nil
+}
+
+// krigTensorQty 方法返回利用普通克里金插值计算网格节点处张量场量的函数. 张量的各个分量分别拟合变差函数并进行插值.
+func (tf *TensorField) krigTensorQty() (func(x, y float64) (*TensorQty, error), error) {
+	var fns [3]func(x, y float64) (float64, float64, error)
+	for k, comp := range []int{TXX, TYY, TXY} {
+		ss := make([]*ScalarQty, len(tf.data))
+		for i, t := range tf.data {
+			v, _ := t.comp(comp)
+			ss[i] = &ScalarQty{X: t.X, Y: t.Y, V: v}
+		}
+		fn, err := tf.krigIntrpl(ss)
+		if err != nil {
+			return nil, err
+		}
+		fns[k] = fn
+	}
+	return func(x, y float64) (*TensorQty, error) {
+		var cs [3]float64
+		for k, fn := range fns {
+			v, _, err := fn(x, y)
+			if err != nil {
+				return nil, err
+			}
+			cs[k] = v
+		}
+		return NewTensorQty(x, y, cs[0], cs[1], cs[2]), nil
+	}, nil
+}
+
+// krigVectorQty 方法返回利用普通克里金插值计算网格节点处向量的函数. 向量的两个分量分别拟合变差函数并插值.
+func (vf *VectorField) krigVectorQty() (func(x, y float64) (*VectorQty, error), error) {
+	var fns [2]func(x, y float64) (float64, float64, error)
+	for k := range fns {
+		ss := make([]*ScalarQty, len(vf.data))
+		for i, v := range vf.data {
+			ss[i] = &ScalarQty{X: v.X, Y: v.Y, V: v.Vector.X}
+			if k == 1 {
+				ss[i].V = v.Vector.Y
+			}
+		}
+		fn, err := vf.krigIntrpl(ss)
+		if err != nil {
+			return nil, err
+		}
+		fns[k] = fn
+	}
+	return func(x, y float64) (*VectorQty, error) {
+		var cs [2]float64
+		for k, fn := range fns {
+			v, _, err := fn(x, y)
+			if err != nil {
+				return nil, err
+			}
+			cs[k] = v
+		}
+		return NewVectorQty(x, y, cs[0], cs[1]), nil
+	}, nil
+}
+
+// GenFieldOfKrigingVariance 按场的参数 Options.Kriging 计算标量场各网格节点处的克里金方差, 生成一个新的标量场,
+// 从而可以像其他标量场一样绘制方差的等值线. 新标量场与原标量场的网格大小相同, 其数据点即为网格节点.
+// 原标量场的插值方法可以不是 KrigingMethod. 新标量场与原标量场的定义域相同, 定义域以外的节点处的方差为 NaN.
+func (sf *ScalarField) GenFieldOfKrigingVariance() (*ScalarField, error) {
+	intrpl, err := sf.krigIntrpl(sf.data)
+	if err != nil {
+		return nil, err
+	}
+	g, err := grid.New(sf.grid.Range, sf.grid.CellXN, sf.grid.CellYN)
+	if err != nil {
+		return nil, err
+	}
+	g.SetDomain(sf.grid.Domain)
+	vf := &ScalarField{}
+	vf.grid = g
+	vf.opts = sf.opts
+	vf.data = make([]*ScalarQty, g.NodeNum)
+	for i := range vf.data {
+		x, y := g.Nodes[i].X, g.Nodes[i].Y
+		variance := math.NaN()
+		if g.NodeLoc(i) != grid.Outside {
+			_, variance, err = intrpl(x, y)
+		}
+		if err != nil {
+			return nil, err
+		}
+		vf.data[i] = &ScalarQty{X: x, Y: y, V: variance}
+		if err := g.Add(x, y, i); err != nil {
+			return nil, err
+		}
+	}
+	vf.nodes = vf.data
+	vf.index = newIndex(len(vf.data), func(i int) (x, y float64) {
+		return vf.data[i].X, vf.data[i].Y
+	}, vf.opts.QuadLeafSize)
+	return vf, nil
+}
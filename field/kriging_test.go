@@ -0,0 +1,139 @@
+package field_test
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/field"
+)
+
+func TestVariogram(t *testing.T) {
+	ss := []*field.ScalarQty{
+		field.NewScalarQty(0, 0, 1),
+		field.NewScalarQty(1, 0, 3),
+		field.NewScalarQty(2, 0, 4),
+	}
+	lags, err := field.ExperimentalVariogram(ss, 4, 2.0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// 距离为 1 的两对点的半方差为 (4+1)/4, 距离为 2 的一对点的半方差为 9/2
+	if len(lags) != 2 || lags[0].N != 2 || lags[0].Gamma != 1.25 || lags[1].N != 1 || lags[1].Gamma != 4.5 {
+		t.Errorf("got lags %v", lags)
+	}
+
+	for _, m := range []field.VariogramModel{field.SphericalModel, field.ExponentialModel, field.GaussianModel} {
+		want := field.Variogram{Model: m, Nugget: 0.5, Sill: 2.0, Range: 10.0}
+		lags = lags[:0]
+		for h := 1.0; h <= 15.0; h++ {
+			lags = append(lags, field.Lag{H: h, Gamma: want.Gamma(h), N: 10})
+		}
+		got, err := field.FitVariogram(lags, m)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if math.Abs(got.Nugget-want.Nugget) > 0.05 || math.Abs(got.Sill-want.Sill) > 0.05 || math.Abs(got.Range-want.Range) > 0.2 {
+			t.Errorf("%v: got %+v, want %+v", m, *got, want)
+		}
+		if _, err := field.ParseVariogramModel(m.String()); err != nil {
+			t.Error(err.Error())
+		}
+	}
+}
+
+func TestKriging(t *testing.T) {
+	ss := []*field.ScalarQty{
+		field.NewScalarQty(0, 0, 1),
+		field.NewScalarQty(4, 0, 2),
+		field.NewScalarQty(0, 4, 3),
+		field.NewScalarQty(4, 4, 5),
+		field.NewScalarQty(2, 1, 4),
+	}
+	k := field.Kriging{Variogram: &field.Variogram{Model: field.SphericalModel, Sill: 1.0, Range: 6.0}}
+	v, variance, err := k.Estimate(ss, 4, 0)
+	if err != nil || math.Abs(v-2.0) > 1e-9 || variance > 1e-9 {
+		t.Errorf("at a data point: got %g, %g, %v", v, variance, err)
+	}
+	_, near, _ := k.Estimate(ss, 2.2, 1.2)
+	_, far, _ := k.Estimate(ss, 2, 3.5)
+	if !(near < far && far <= 1.0) {
+		t.Errorf("got kriging variance %g near a data point and %g far from it", near, far)
+	}
+	// 所有权重之和为 1, 常数场被精确重现
+	for _, s := range ss {
+		s.V = 7.0
+	}
+	if v, _, _ := k.Estimate(ss, 3, 2); math.Abs(v-7.0) > 1e-9 {
+		t.Errorf("got %g in a constant field", v)
+	}
+}
+
+// TestKrigingVariance 检查克里金方差场在数据点处为 0, 在远离数据点处较大.
+func TestKrigingVariance(t *testing.T) {
+	var data []*field.ScalarQty
+	for x := 0.0; x <= 8.0; x += 2.0 {
+		for y := 0.0; y <= 8.0; y += 2.0 {
+			data = append(data, field.NewScalarQty(x, y, math.Sin(x/3)+math.Cos(y/4)))
+		}
+	}
+	sf, err := field.NewScalarField(data, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	sf.SetMethod(field.KrigingMethod)
+	if err := sf.GenNodes(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if v, err := sf.V(4, 4); err != nil || math.Abs(v-data[12].V) > 1e-6 {
+		t.Errorf("got %g, %v at a data point, want %g", v, err, data[12].V)
+	}
+	vf, err := sf.GenFieldOfKrigingVariance()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	at, _ := vf.V(4, 4)
+	between, _ := vf.V(5, 5)
+	if min, max, _ := vf.MinMax(); !(at < 1e-6 && between > at && min < max) {
+		t.Errorf("got kriging variance %g at a data point, %g between data points, range [%g, %g]", at, between, min, max)
+	}
+
+	// 所有数据点都参与插值时, 系数矩阵只分解一次, 结果与逐点求解相同
+	o := field.DefaultOptions()
+	o.Kriging.Neighbors = 0
+	global, err := field.NewScalarField(data, o)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	global.SetMethod(field.KrigingMethod)
+	if err := global.GenNodes(); err != nil {
+		t.Fatal(err.Error())
+	}
+	lags, _ := field.ExperimentalVariogram(data, o.Kriging.Lags, 0.0)
+	vg, _ := field.FitVariogram(lags, o.Kriging.Model)
+	k := field.Kriging{Variogram: vg}
+	for _, p := range [][2]float64{{4, 4}, {1, 7}} {
+		v, _ := global.V(p[0], p[1])
+		want, _, err := k.Estimate(data, p[0], p[1])
+		if err != nil || math.Abs(v-want) > 1e-6 {
+			t.Errorf("got %g at (%g, %g) with a global fit, want %g, err: %v", v, p[0], p[1], want, err)
+		}
+	}
+
+	// 网格不从原点开始时, 方差场的数据点仍应位于原场的网格节点处
+	shifted := make([]*field.ScalarQty, len(data))
+	for i, s := range data {
+		shifted[i] = field.NewScalarQty(s.X+1000, s.Y-500, s.V)
+	}
+	if sf, err = field.NewScalarField(shifted, nil); err != nil {
+		t.Fatal(err.Error())
+	}
+	if vf, err = sf.GenFieldOfKrigingVariance(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if *vf.Range() != *sf.Range() {
+		t.Errorf("got range %v of the variance field, want %v", *vf.Range(), *sf.Range())
+	}
+	if v, err := vf.V(1004, -496); err != nil || v > 1e-6 {
+		t.Errorf("got kriging variance %g, %v at a shifted data point", v, err)
+	}
+}
@@ -0,0 +1,151 @@
+package field
+
+import (
+	"errors"
+	"fmt"
+
+	"stj/fieldline/mesh"
+)
+
+// NewMeshTensorField 根据有限元网格 m 及其节点处的张量场量 data 创建一个张量场, data 中的元素与
+// m.Nodes 一一对应. 与 NewTensorField 不同, 这样的张量场保留了节点之间的连接关系, 场内任一点的值由其
+// 所在单元的形函数插值求得, 而不是利用 IDW 方法由附近的离散数据求得, 从而不会抹平应力集中,
+// 也不会跨越材料边界插值. 由于数据必须与节点一一对应, o 中的 DiscardZeroQty 对这样的张量场不起作用.
+// 若 o 为 nil, 则使用默认参数.
+func NewMeshTensorField(m *mesh.Mesh, data []*TensorQty, o *Options) (tf *TensorField, err error) {
+	if len(data) != len(m.Nodes) {
+		return nil, fmt.Errorf("%d tensor quantities given for %d mesh nodes", len(data), len(m.Nodes))
+	}
+	b, err := newBaseField(len(data), func(i int) (x, y float64) {
+		return data[i].X, data[i].Y
+	}, o)
+	if err != nil {
+		return nil, err
+	}
+	tf = &TensorField{baseField: b}
+	tf.mesh = m
+	tf.data = data
+	return tf, nil
+}
+
+// NewMeshScalarField 根据有限元网格 m 及其节点处的标量场量 data 创建一个标量场, data 中的元素与
+// m.Nodes 一一对应. 场内任一点的值由其所在单元的形函数插值求得. o 的含义与 NewMeshTensorField 相同.
+func NewMeshScalarField(m *mesh.Mesh, data []*ScalarQty, o *Options) (sf *ScalarField, err error) {
+	if len(data) != len(m.Nodes) {
+		return nil, fmt.Errorf("%d scalar quantities given for %d mesh nodes", len(data), len(m.Nodes))
+	}
+	b, err := newBaseField(len(data), func(i int) (x, y float64) {
+		return data[i].X, data[i].Y
+	}, o)
+	if err != nil {
+		return nil, err
+	}
+	sf = &ScalarField{baseField: b}
+	sf.mesh = m
+	sf.data = data
+	return sf, nil
+}
+
+// NewMeshVectorField 根据有限元网格 m 及其节点处的向量 data 创建一个向量场, data 中的元素与
+// m.Nodes 一一对应. 场内任一点的向量由其所在单元的形函数插值求得. o 的含义与 NewMeshTensorField 相同.
+func NewMeshVectorField(m *mesh.Mesh, data []*VectorQty, o *Options) (vf *VectorField, err error) {
+	if len(data) != len(m.Nodes) {
+		return nil, fmt.Errorf("%d vector quantities given for %d mesh nodes", len(data), len(m.Nodes))
+	}
+	b, err := newBaseField(len(data), func(i int) (x, y float64) {
+		return data[i].X, data[i].Y
+	}, o)
+	if err != nil {
+		return nil, err
+	}
+	vf = &VectorField{baseField: b}
+	vf.mesh = m
+	vf.data = data
+	return vf, nil
+}
+
+// comp 方法返回张量场量的某个分量, comp 的值只应该是 TXX, TYY, TXY, TEV1, TEV2, TED1 或 TED2.
+func (t *TensorQty) comp(comp int) (float64, error) {
+	switch comp {
+	case TXX:
+		return t.XX, nil
+	case TYY:
+		return t.YY, nil
+	case TXY:
+		return t.XY, nil
+	case TEV1:
+		return t.EV1, nil
+	case TEV2:
+		return t.EV2, nil
+	case TED1:
+		return t.ED1, nil
+	case TED2:
+		return t.ED2, nil
+	}
+	return 0.0, errors.New("unknown tensor component type")
+}
+
+// meshValue 利用有限元网格的形函数求得点 (x, y) 处张量的某个分量.
+func (tf *TensorField) meshValue(x, y float64, comp int) (float64, error) {
+	if _, err := tf.data[0].comp(comp); err != nil {
+		return 0.0, err
+	}
+	return tf.mesh.Interpolate(x, y, func(ni int) float64 {
+		v, _ := tf.data[ni].comp(comp)
+		return v
+	})
+}
+
+// meshTensorQty 利用有限元网格的形函数求得点 (x, y) 处的张量场量. 若该点不在网格的任何单元内,
+// 则与 IDW 插值失败时的处理方式相同. 若插值方式不是 ComponentMode, 则以形函数为权按插值方式对各张量进行平均.
+func (tf *TensorField) meshTensorQty(x, y float64) (*TensorQty, error) {
+	ei, w, err := tf.mesh.Locate(x, y)
+	if err != nil {
+		if !tf.opts.AssignZeroOnIntrplFail {
+			return nil, err
+		}
+		return NewTensorQty(x, y, 0.0, 0.0, 0.0), nil
+	}
+	if tf.mode != ComponentMode {
+		ts := make([]*TensorQty, len(w))
+		for k, ni := range tf.mesh.Elements[ei] {
+			ts[k] = tf.data[ni]
+		}
+		return tf.blend(ts, w, x, y)
+	}
+	var xx, yy, xy float64
+	for k, ni := range tf.mesh.Elements[ei] {
+		xx += w[k] * tf.data[ni].XX
+		yy += w[k] * tf.data[ni].YY
+		xy += w[k] * tf.data[ni].XY
+	}
+	return NewTensorQty(x, y, xx, yy, xy), nil
+}
+
+// meshVectorQty 利用有限元网格的形函数求得点 (x, y) 处的向量. 若该点不在网格的任何单元内,
+// 则与 IDW 插值失败时的处理方式相同.
+func (vf *VectorField) meshVectorQty(x, y float64) (*VectorQty, error) {
+	ei, w, err := vf.mesh.Locate(x, y)
+	if err != nil {
+		if !vf.opts.AssignZeroOnIntrplFail {
+			return nil, err
+		}
+		return NewVectorQty(x, y, 0.0, 0.0), nil
+	}
+	var vx, vy float64
+	for k, ni := range vf.mesh.Elements[ei] {
+		vx += w[k] * vf.data[ni].Vector.X
+		vy += w[k] * vf.data[ni].Vector.Y
+	}
+	return NewVectorQty(x, y, vx, vy), nil
+}
+
+// meshNodeValue 利用有限元网格的形函数求得网格节点 (x, y) 处的标量. 若该点不在网格的任何单元内,
+// 则与 IDW 插值失败时的处理方式相同.
+func (sf *ScalarField) meshNodeValue(x, y float64) (float64, error) {
+	v, err := sf.mesh.Interpolate(x, y, func(ni int) float64 { return sf.data[ni].V })
+	if err != nil && sf.opts.AssignZeroOnIntrplFail {
+		return 0.0, nil
+	}
+	return v, err
+}
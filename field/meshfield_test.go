@@ -0,0 +1,55 @@
+package field_test
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/field"
+	"stj/fieldline/geom"
+	"stj/fieldline/mesh"
+)
+
+// TestMeshTensorField 检查由有限元网格创建的张量场不会在相邻单元之间抹平应力的突变.
+func TestMeshTensorField(t *testing.T) {
+	// 两个四边形单元, 右侧单元中的 XX 远大于左侧单元
+	nodes := []geom.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}, {X: 2, Y: 1}}
+	m, err := mesh.New(nodes, []mesh.Element{{0, 1, 4, 3}, {1, 2, 5, 4}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	xx := []float64{1, 1, 100, 1, 1, 100}
+	data := make([]*field.TensorQty, len(nodes))
+	for i, p := range nodes {
+		data[i] = field.NewTensorQty(p.X, p.Y, xx[i], 2, 0.5)
+	}
+	tf, err := field.NewMeshTensorField(m, data, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, tc := range []struct{ x, y, want float64 }{
+		{0.5, 0.5, 1},
+		{0.9, 0.2, 1},
+		{1.5, 0.7, 50.5},
+	} {
+		v, err := tf.XX(tc.x, tc.y)
+		if err != nil || math.Abs(v-tc.want) > 1e-9 {
+			t.Errorf("XX(%g, %g) = %v, %v, want %g", tc.x, tc.y, v, err, tc.want)
+		}
+	}
+	if v, err := tf.XY(1.3, 0.4); err != nil || math.Abs(v-0.5) > 1e-9 {
+		t.Errorf("XY = %v, %v, want 0.5", v, err)
+	}
+	if _, err := tf.EV1(2.5, 0.5); err == nil {
+		t.Error("a point outside the mesh should be reported")
+	}
+	sf, err := tf.GenFieldOfComp(field.TXX)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if v, err := sf.V(0.5, 0.5); err != nil || math.Abs(v-1) > 1e-9 {
+		t.Errorf("V = %v, %v, want 1", v, err)
+	}
+	if _, err := field.NewMeshTensorField(m, data[1:], nil); err == nil {
+		t.Error("the number of quantities should match the number of mesh nodes")
+	}
+}
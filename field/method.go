@@ -0,0 +1,219 @@
+package field
+
+import (
+	"fmt"
+	"strings"
+
+	"stj/fieldline/delaunay"
+	"stj/fieldline/geom"
+)
+
+// Method 表示由无规则离散分布的数据计算网格节点处场量时所用的插值方法.
+type Method int
+
+// IDWMethod 是默认的插值方法. 其他方法基于数据点的 Delaunay 三角剖分, 不会像 IDW 方法那样在每个数据点
+// 周围产生"牛眼"现象, 但在三角剖分的范围(即数据点的凸包或凹边界)之外无法插值. 其中 SibsonMethod 仅使用
+// 自然邻点进行插值, 而不是像 IDW 方法那样使用按 IDWConfig 的搜索参数查找到的点,
+// 适用于点距很不均匀(如有限元网格局部加密)的数据. RBFMethod 和 KrigingMethod 不需要三角剖分, 其参数分别由
+// 场的参数 Options 中的 RBF 和 Kriging 给出, 前者适用于数据点稀疏而 IDW 方法会把峰值抹平的场, 后者适用于实测数据.
+const (
+	IDWMethod          Method = iota // 反距离加权插值
+	LinearMethod                     // 分片线性插值
+	CloughTocherMethod               // Clough-Tocher C1 插值
+	SibsonMethod                     // Sibson 自然邻点插值
+	RBFMethod                        // 径向基函数插值
+	KrigingMethod                    // 普通克里金插值
+)
+
+var methodNames = []string{"idw", "linear", "cloughtocher", "sibson", "rbf", "kriging"}
+
+func (m Method) String() string {
+	if m < 0 || int(m) >= len(methodNames) {
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+	return methodNames[m]
+}
+
+// ParseMethod 根据名称(不区分大小写)返回相应的插值方法, 名称为 Method 的 String 方法的返回值.
+func ParseMethod(name string) (Method, error) {
+	for i, n := range methodNames {
+		if strings.EqualFold(name, n) {
+			return Method(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown interpolation method %q", name)
+}
+
+// Method 方法返回场的插值方法.
+func (f *baseField) Method() Method {
+	return f.method
+}
+
+// SetMethod 方法设置场的插值方法, 设置后应重新调用 GenNodes 方法. 对于由有限元网格创建的场,
+// 网格节点处的场量总是由形函数插值求得, 插值方法不起作用.
+func (f *baseField) SetMethod(m Method) {
+	f.method = m
+}
+
+// triangulate 方法对 n 个数据点进行 Delaunay 三角剖分, pos 返回索引为 i 的数据点的坐标.
+// 若 maxEdge > 0, 则将边界上长于 maxEdge 的边所在的三角形剥除.
+func (f *baseField) triangulate(n int, pos func(i int) (x, y float64), maxEdge float64) error {
+	ps := make([]geom.Point, n)
+	for i := range ps {
+		ps[i].X, ps[i].Y = pos(i)
+	}
+	t, err := delaunay.New(ps)
+	if err != nil {
+		return err
+	}
+	if maxEdge > 0.0 {
+		if err := t.Concave(maxEdge); err != nil {
+			return err
+		}
+	}
+	f.tri = t
+	return nil
+}
+
+// triIntrpl 方法根据场的插值方法, 返回利用三角剖分对数据点处的值 vs 进行插值的函数.
+// 若点在三角剖分的范围之外, 则与 IDW 插值失败时的处理方式相同.
+func (f *baseField) triIntrpl(vs []float64) (func(x, y float64) (float64, error), error) {
+	if len(vs) != len(f.tri.Points) {
+		return nil, fmt.Errorf("the triangulation has %d points, but the field has %d quantities", len(f.tri.Points), len(vs))
+	}
+	intrpl := func(x, y float64) (float64, error) { return f.tri.Linear(vs, x, y) }
+	switch f.method {
+	case CloughTocherMethod:
+		ct, err := delaunay.NewCloughTocher(f.tri, vs)
+		if err != nil {
+			return nil, err
+		}
+		intrpl = ct.Value
+	case SibsonMethod:
+		intrpl = func(x, y float64) (float64, error) { return f.tri.Sibson(vs, x, y) }
+	}
+	return func(x, y float64) (float64, error) {
+		v, err := intrpl(x, y)
+		if err != nil {
+			if !f.opts.AssignZeroOnIntrplFail {
+				return 0.0, err
+			}
+			return 0.0, nil
+		}
+		return v, nil
+	}, nil
+}
+
+// usesTriangulation 方法判断 GenNodes 是否应使用三角剖分进行插值.
+func (f *baseField) usesTriangulation() bool {
+	switch f.method {
+	case LinearMethod, CloughTocherMethod, SibsonMethod:
+		return f.mesh == nil
+	}
+	return false
+}
+
+// Triangulate 方法对标量场中的数据点进行 Delaunay 三角剖分, 供基于三角剖分的插值方法使用.
+// 若 maxEdge > 0, 则将边界上长于 maxEdge 的边所在的三角形逐步剥除, 使场的边界能够贴合凹的数据区域.
+// 若未调用此方法, GenNodes 将自动进行三角剖分, 其边界为数据点的凸包.
+func (sf *ScalarField) Triangulate(maxEdge float64) error {
+	return sf.triangulate(len(sf.data), func(i int) (x, y float64) {
+		return sf.data[i].X, sf.data[i].Y
+	}, maxEdge)
+}
+
+// Triangulate 方法对张量场中的数据点进行 Delaunay 三角剖分, 其用法与 ScalarField 的 Triangulate 方法相同.
+// 由张量场生成的标量场(如 GenFieldOfComp)与张量场共用同一个三角剖分.
+func (tf *TensorField) Triangulate(maxEdge float64) error {
+	return tf.triangulate(len(tf.data), func(i int) (x, y float64) {
+		return tf.data[i].X, tf.data[i].Y
+	}, maxEdge)
+}
+
+// Triangulate 方法对向量场中的数据点进行 Delaunay 三角剖分, 其用法与 ScalarField 的 Triangulate 方法相同.
+func (vf *VectorField) Triangulate(maxEdge float64) error {
+	return vf.triangulate(len(vf.data), func(i int) (x, y float64) {
+		return vf.data[i].X, vf.data[i].Y
+	}, maxEdge)
+}
+
+// triValue 方法返回利用三角剖分计算网格节点处标量的函数.
+func (sf *ScalarField) triValue() (func(x, y float64) (float64, error), error) {
+	if sf.tri == nil {
+		if err := sf.Triangulate(0.0); err != nil {
+			return nil, err
+		}
+	}
+	vs := make([]float64, len(sf.data))
+	for i, d := range sf.data {
+		vs[i] = d.V
+	}
+	return sf.triIntrpl(vs)
+}
+
+// triTensorQty 方法返回利用三角剖分计算网格节点处张量场量的函数. 张量的各个分量分别进行插值.
+func (tf *TensorField) triTensorQty() (func(x, y float64) (*TensorQty, error), error) {
+	if tf.tri == nil {
+		if err := tf.Triangulate(0.0); err != nil {
+			return nil, err
+		}
+	}
+	var fns [3]func(x, y float64) (float64, error)
+	for k, comp := range []int{TXX, TYY, TXY} {
+		vs := make([]float64, len(tf.data))
+		for i, t := range tf.data {
+			vs[i], _ = t.comp(comp)
+		}
+		fn, err := tf.triIntrpl(vs)
+		if err != nil {
+			return nil, err
+		}
+		fns[k] = fn
+	}
+	return func(x, y float64) (*TensorQty, error) {
+		var cs [3]float64
+		for k, fn := range fns {
+			v, err := fn(x, y)
+			if err != nil {
+				return nil, err
+			}
+			cs[k] = v
+		}
+		return NewTensorQty(x, y, cs[0], cs[1], cs[2]), nil
+	}, nil
+}
+
+// triVectorQty 方法返回利用三角剖分计算网格节点处向量的函数. 向量的两个分量分别进行插值.
+func (vf *VectorField) triVectorQty() (func(x, y float64) (*VectorQty, error), error) {
+	if vf.tri == nil {
+		if err := vf.Triangulate(0.0); err != nil {
+			return nil, err
+		}
+	}
+	var fns [2]func(x, y float64) (float64, error)
+	for k := range fns {
+		vs := make([]float64, len(vf.data))
+		for i, v := range vf.data {
+			vs[i] = v.Vector.X
+			if k == 1 {
+				vs[i] = v.Vector.Y
+			}
+		}
+		fn, err := vf.triIntrpl(vs)
+		if err != nil {
+			return nil, err
+		}
+		fns[k] = fn
+	}
+	return func(x, y float64) (*VectorQty, error) {
+		var cs [2]float64
+		for k, fn := range fns {
+			v, err := fn(x, y)
+			if err != nil {
+				return nil, err
+			}
+			cs[k] = v
+		}
+		return NewVectorQty(x, y, cs[0], cs[1]), nil
+	}, nil
+}
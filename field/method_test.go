@@ -0,0 +1,57 @@
+package field_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"stj/fieldline/field"
+)
+
+func TestParseMethod(t *testing.T) {
+	for _, m := range []field.Method{field.IDWMethod, field.LinearMethod, field.CloughTocherMethod, field.SibsonMethod, field.RBFMethod, field.KrigingMethod} {
+		if got, err := field.ParseMethod(m.String()); err != nil || got != m {
+			t.Errorf("ParseMethod(%q) = %v, %v", m.String(), got, err)
+		}
+	}
+	if _, err := field.ParseMethod("nearest"); err == nil {
+		t.Error("an unknown method should be rejected")
+	}
+}
+
+// TestTriangulationMethods 检查基于三角剖分的插值方法能精确重现线性分布的场, 而 IDW 方法不能.
+func TestTriangulationMethods(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	f := func(x, y float64) float64 { return 2*x - y + 3 }
+	data := []*field.TensorQty{
+		field.NewTensorQty(0, 0, f(0, 0), 1, 0),
+		field.NewTensorQty(4, 0, f(4, 0), 1, 0),
+		field.NewTensorQty(0, 4, f(0, 4), 1, 0),
+		field.NewTensorQty(4, 4, f(4, 4), 1, 0),
+	}
+	for i := 0; i < 200; i++ {
+		x, y := 4*r.Float64(), 4*r.Float64()
+		data = append(data, field.NewTensorQty(x, y, f(x, y), 1, 0))
+	}
+	for _, m := range []field.Method{field.IDWMethod, field.LinearMethod, field.CloughTocherMethod, field.SibsonMethod} {
+		tf, err := field.NewTensorField(data, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		tf.SetMethod(m)
+		if err := tf.GenNodes(); err != nil {
+			t.Fatal(err.Error())
+		}
+		maxErr := 0.0
+		for _, p := range [][2]float64{{0.3, 0.7}, {1.5, 2.5}, {3.1, 0.4}, {2.2, 3.3}} {
+			v, err := tf.XX(p[0], p[1])
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			maxErr = math.Max(maxErr, math.Abs(v-f(p[0], p[1])))
+		}
+		if exact := maxErr < 1e-9; exact != (m != field.IDWMethod) {
+			t.Errorf("%v: maximum error %g", m, maxErr)
+		}
+	}
+}
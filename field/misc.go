@@ -1,12 +1,143 @@
 package field
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
 	"math"
 	"strconv"
 
+	"stj/fieldline/geom"
+	"stj/fieldline/grid"
 	"stj/fieldline/num"
 )
 
+// maxLineLen 是场数据文本中一行的最大长度.
+const maxLineLen = 1 << 20
+
+// readData 从 r 中逐行读取由数值模拟导出的场数据文本, 并对每个恰好包含 n 个数字的行调用 fn.
+// 数字之间以任意个数的逗号(,), 空格( )或水平制表符(\t)及其任意组合分割;
+// 其行尾可以为是任意个数的换行符(\n)和回车符(\r)的任意组合.
+// 不能解析为数字列表或数字个数不等于 n 的行将被直接舍弃. 整个文本并不会被同时读入内存.
+func readData(r io.Reader, n int, fn func(floats []float64)) error {
+	sc := newLineScanner(r)
+	for sc.Scan() {
+		// 空行解析所得的数字个数为 0, 将被舍弃
+		if floats := parseLineData(sc.Bytes()); len(floats) == n {
+			fn(floats)
+		}
+	}
+	return sc.Err()
+}
+
+// newLineScanner 创建一个逐行读取 r 的 bufio.Scanner, 行尾可以是 \n, \r 或 \r\n.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), maxLineLen)
+	sc.Split(scanLines)
+	return sc
+}
+
+// scanLines 是 bufio.Scanner 的分割函数. 它与 bufio.ScanLines 类似, 但也将单独的 \r 当作行尾.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		// \r 之后可能紧跟着 \n, 需要读入更多数据后再判断
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// newBaseField 根据 n 个场量的坐标和参数 o 创建场的网格和空间索引. pos 返回索引为 i 的场量的坐标.
+// 若 o 为 nil, 则使用默认参数.
+func newBaseField(n int, pos func(i int) (x, y float64), o *Options) (baseField, error) {
+	o, err := o.resolve()
+	if err != nil {
+		return baseField{}, err
+	}
+	g, err := newAutoGrid(n, pos, o.Density)
+	if err != nil {
+		return baseField{}, err
+	}
+	return baseField{grid: g, index: newIndex(n, pos, o.QuadLeafSize), opts: *o}, nil
+}
+
+// newIndex 为 n 个场量的坐标创建一个四叉树空间索引, 一个叶节点中最多容纳 leafSize 个场量.
+func newIndex(n int, pos func(i int) (x, y float64), leafSize int) grid.Index {
+	ps := make([]geom.Point, n)
+	for i := range ps {
+		ps[i].X, ps[i].Y = pos(i)
+	}
+	return grid.NewQuadtree(ps, leafSize)
+}
+
+// newAutoGrid 根据 n 个场量的坐标创建一个网格, 网格的范围为所有场量坐标的范围,
+// 每个单元格平均有 density 个场量, 单元格的个数据此自动确定. 各个场量的索引都将被添加到网格中.
+// pos 返回索引为 i 的场量的坐标.
+func newAutoGrid(n int, pos func(i int) (x, y float64), density float64) (*grid.Grid, error) {
+	if n == 0 {
+		return nil, errors.New("no quantity to create a Grid")
+	}
+	xmin, ymin := pos(0)
+	xmax, ymax := xmin, ymin
+	for i := 0; i < n; i++ {
+		x, y := pos(i)
+		if x < xmin {
+			xmin = x
+		}
+		if x > xmax {
+			xmax = x
+		}
+		if y < ymin {
+			ymin = y
+		}
+		if y > ymax {
+			ymax = y
+		}
+	}
+	if xmin >= xmax || ymin >= ymax {
+		return nil, errors.New("wrong region parameters")
+	}
+	xl := xmax - xmin
+	yl := ymax - ymin
+	//  cellXN(xn) 和 cellYN(yn) 由以下方程组求解得出:
+	// xn*span = xl
+	// yn*span = yl
+	// xn*yn*density = n
+	cellXN := int(math.Ceil(math.Sqrt(float64(n) * xl / (density * yl))))
+	cellYN := int(math.Ceil(math.Sqrt(float64(n) * yl / (density * xl))))
+	r, _ := geom.NewRect(xmin, ymin, xmax, ymax)
+	g, err := grid.New(*r, cellXN, cellYN)
+	if err != nil {
+		return nil, errors.New("error occurs when create Grid")
+	}
+	for i := 0; i < n; i++ {
+		x, y := pos(i)
+		if err := g.Add(x, y, i); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
 // parseLineData 对一行文本进行解析, 并返回其中包含的数字列表.
 // 只有在该行中仅包含指定的分隔符和有效的数字时, 才能返回一个数字列表.
 func parseLineData(line []byte) []float64 {
@@ -52,6 +183,16 @@ func isFloatChar(c byte) bool {
 		c == '-' || c == '.' || c == 'e' || c == 'E'
 }
 
+// isZeroQty 判断一个场量的各个分量 vs 是否都为 0.
+func isZeroQty(vs ...float64) bool {
+	for _, v := range vs {
+		if !num.Equal(v, 0.0) {
+			return false
+		}
+	}
+	return true
+}
+
 // relErr 计算 x1, x2 之间的相对误差.
 func relErr(x1, x2 float64) float64 {
 	if num.Equal(x1, 0.0) && num.Equal(x2, 0.0) {
@@ -61,7 +202,7 @@ func relErr(x1, x2 float64) float64 {
 }
 
 // posIntrpl 是一个进行位置插值的辅助函数. 它根据 x 轴上两点坐标 x1, x2 以及对应的两个值 v1, v2,
-// 利用线性插值方法, 计算当取值为 v 时的坐标 x.
+// 利用线性插值方法, 计算当取值为 v 时的坐标 x. 调用者应保证 v1 != v2.
 func posIntrpl(x1, x2, v1, v2, v float64) float64 {
-	return ((v1-v)*x2 + (v-v2)*x1) / (v2 - v1)
+	return ((v-v1)*x2 + (v2-v)*x1) / (v2 - v1)
 }
@@ -0,0 +1,80 @@
+package field_test
+
+import (
+	"math"
+	"os"
+	"testing"
+
+	"stj/fieldline/field"
+)
+
+// transformed 读入 fielddata/stress.dat, 并将其中各点的坐标变换为 (s*x+dx, s*y+dy).
+func transformed(t *testing.T, s, dx, dy float64) []*field.TensorQty {
+	f, err := os.Open("../fielddata/stress.dat")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer f.Close()
+	cols, err := field.ParseColumns("x=xcen,y=ycen,xx=sxx,yy=syy,xy=sxy")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tb, err := field.ReadTableFrom(f, cols)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	x, y := tb.Col(field.RoleX), tb.Col(field.RoleY)
+	xx, yy, xy := tb.Col(field.RoleXX), tb.Col(field.RoleYY), tb.Col(field.RoleXY)
+	data := make([]*field.TensorQty, len(tb.Rows))
+	for i, r := range tb.Rows {
+		data[i] = field.NewTensorQty(s*r[x]+dx, s*r[y]+dy, r[xx], r[yy], r[xy])
+	}
+	return data
+}
+
+// TestTranslationInvariance 检查对 stress.dat 进行平移和等比例缩放(包括平移到负坐标区域)后,
+// 对应点处的插值结果与原数据的插值结果相同.
+func TestTranslationInvariance(t *testing.T) {
+	transforms := []struct{ s, dx, dy float64 }{
+		{1, 1000, -2500},
+		{1, -250.5, -75.25},
+		{0.001, 3.5, 7.25},
+		{1000, -1e5, 2e5},
+	}
+	ps := [][2]float64{{0.3, 0.4}, {12.7, 88.1}, {50, 50}, {99.2, 3.3}, {100, 100}}
+	for _, m := range []field.Method{field.IDWMethod, field.LinearMethod, field.RBFMethod} {
+		values := func(s, dx, dy float64) []float64 {
+			tf, err := field.NewTensorField(transformed(t, s, dx, dy), nil)
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			tf.SetMethod(m)
+			if err := tf.GenNodes(); err != nil {
+				t.Fatal(err.Error())
+			}
+			r := tf.Range()
+			vs := make([]float64, 0, len(ps))
+			for _, p := range ps {
+				// 以原数据的坐标范围 [0, 100] 为参照, 将各点映射到变换后的坐标范围内
+				x := r.Xmin + (r.Xmax-r.Xmin)*p[0]/100
+				y := r.Ymin + (r.Ymax-r.Ymin)*p[1]/100
+				v, err := tf.XX(x, y)
+				if err != nil {
+					t.Fatalf("%v: (%g, %g): %v", m, x, y, err)
+				}
+				vs = append(vs, v)
+			}
+			return vs
+		}
+		want := values(1, 0, 0)
+		for _, tr := range transforms {
+			got := values(tr.s, tr.dx, tr.dy)
+			for i := range want {
+				if math.Abs(got[i]-want[i]) > 1e-6*math.Abs(want[i]) {
+					t.Errorf("%v: scaled by %g and shifted by (%g, %g), got %g at %v, want %g",
+						m, tr.s, tr.dx, tr.dy, got[i], ps[i], want[i])
+				}
+			}
+		}
+	}
+}
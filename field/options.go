@@ -0,0 +1,85 @@
+package field
+
+import (
+	"errors"
+	"fmt"
+
+	"stj/fieldline/grid"
+)
+
+// Options 结构体给出了解析数据, 创建场的网格以及在场内插值时所用的参数. 每个场在创建时复制一份参数,
+// 因而在同一进程中可以用不同的参数同时处理多组数据. 各函数中的 *Options 参数为 nil 时使用 DefaultOptions.
+type Options struct {
+	// Density 为每个网格单元格中数据点的平均个数, 创建场时据此确定网格的大小. 该值越小, 网格越密.
+	Density float64
+	// DiscardZeroQty 为 true 时, 若从外部导入的某个物理量的所有分量都为 0, 则直接舍弃.
+	// 由有限元网格创建的场的数据必须与节点一一对应, 该参数对其不起作用.
+	DiscardZeroQty bool
+	// AssignZeroOnIntrplFail 为 true 时, 若插值失败(如点附近没有任何数据点), 则插入零值; 否则报告一个错误.
+	AssignZeroOnIntrplFail bool
+	// IDW 为反距离加权插值的参数.
+	IDW IDWConfig
+	// RBF 为 RBFMethod 插值方法所用的参数.
+	RBF RBF
+	// Kriging 为 KrigingMethod 插值方法及 GenFieldOfKrigingVariance 所用的参数. 复制参数时其中的 Variogram
+	// 仍指向同一个变差函数, 不应在创建场之后修改它.
+	Kriging Kriging
+	// QuadLeafSize 为场用于查找数据点的四叉树索引的一个叶节点中最多容纳的数据点个数, 为 0 时取
+	// grid.DefaultQuadLeafSize. 该参数只在创建场时起作用.
+	QuadLeafSize int
+	// Workers 为 GenNodes 等方法并发插值时所用的 goroutine 个数, 为 0 时取 CPU 的个数.
+	// 无论取何值, 插值的结果都相同.
+	Workers int
+}
+
+// DefaultOptions 返回一组默认的参数.
+func DefaultOptions() *Options {
+	return &Options{
+		Density:                grid.DefaultDensity,
+		AssignZeroOnIntrplFail: true,
+		IDW:                    IDWConfig{Power: 3.0, MaxQty: 8},
+		RBF:                    RBF{Kernel: ThinPlateKernel, Neighbors: 16},
+		Kriging:                Kriging{Model: SphericalModel, Lags: 15, Neighbors: 16},
+		QuadLeafSize:           grid.DefaultQuadLeafSize,
+	}
+}
+
+// Validate 方法检查各参数是否有效, 以及参数之间是否相互矛盾.
+func (o *Options) Validate() error {
+	if o.Density <= 0.0 {
+		return errors.New("the grid density should be greater than zero")
+	}
+	if o.Workers < 0 {
+		return errors.New("the number of workers should not be negative")
+	}
+	if o.QuadLeafSize < 0 {
+		return errors.New("the leaf size of the quadtree should not be negative")
+	}
+	if err := o.IDW.Validate(); err != nil {
+		return err
+	}
+	if err := o.RBF.Validate(); err != nil {
+		return err
+	}
+	if err := o.Kriging.Validate(); err != nil {
+		return err
+	}
+	// 初次查找的范围不应超过由 MaxQty 确定的最大查找范围, 否则 MaxQty 不起作用
+	if c := &o.IDW; c.Radius == 0.0 && c.Sector == 0 && c.MinLayer > c.maxLayer(o.Density) {
+		return fmt.Errorf("the initial IDW search layer %d exceeds the maximum layer %d derived from the maximum quantity number and the grid density",
+			c.MinLayer, c.maxLayer(o.Density))
+	}
+	return nil
+}
+
+// resolve 方法返回经过检查的参数的一个副本. 若 o 为 nil, 则返回默认参数.
+func (o *Options) resolve() (*Options, error) {
+	if o == nil {
+		return DefaultOptions(), nil
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	c := *o
+	return &c, nil
+}
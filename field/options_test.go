@@ -0,0 +1,74 @@
+package field_test
+
+import (
+	"testing"
+
+	"stj/fieldline/field"
+)
+
+func TestOptions(t *testing.T) {
+	if err := field.DefaultOptions().Validate(); err != nil {
+		t.Errorf("the default options are invalid: %v", err)
+	}
+	bad := []func(o *field.Options){
+		func(o *field.Options) { o.Density = 0.0 },
+		func(o *field.Options) { o.IDW.MaxQty = 0 },
+		func(o *field.Options) { o.Workers = -1 },
+		func(o *field.Options) { o.IDW.MinLayer = 5 }, // 超过由 MaxQty 和 Density 确定的最大层数
+		func(o *field.Options) { o.RBF.Smooth = -1.0 },
+		func(o *field.Options) { o.RBF.Kernel = field.Kernel(10) },
+		func(o *field.Options) { o.Kriging.Variogram = &field.Variogram{Sill: 1.0} },
+		func(o *field.Options) { o.RBF.Neighbors = -1 },
+		func(o *field.Options) { o.Kriging.Neighbors = -1 },
+		func(o *field.Options) { o.Kriging.Lags = 0 },
+		func(o *field.Options) { o.QuadLeafSize = -1 },
+	}
+	for i, modify := range bad {
+		o := field.DefaultOptions()
+		modify(o)
+		if err := o.Validate(); err == nil {
+			t.Errorf("case %d: invalid options passed the validation", i)
+		}
+	}
+
+	// 同一组数据可以同时以不同的参数创建场
+	var data []*field.ScalarQty
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 20; j++ {
+			data = append(data, field.NewScalarQty(float64(i), float64(j), float64(i+j)))
+		}
+	}
+	coarse := field.DefaultOptions()
+	coarse.Density = 8.0
+	sf1, err := field.NewScalarField(data, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	sf2, err := field.NewScalarField(data, coarse)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if sf1.Grid().CellNum <= sf2.Grid().CellNum {
+		t.Errorf("got %d cells with the default density and %d with a lower one", sf1.Grid().CellNum, sf2.Grid().CellNum)
+	}
+	coarse.Density = 1.0 // 创建场之后修改参数不影响该场
+	if sf2.Options().Density != 8.0 {
+		t.Errorf("the options of a field changed with the caller's copy")
+	}
+	o := sf2.Options()
+	o.Density = 2.0
+	if err := sf2.SetOptions(o); err == nil {
+		t.Error("the grid density of a created field should not be changeable")
+	}
+	o = sf2.Options()
+	o.AssignZeroOnIntrplFail = false
+	if err := sf2.SetOptions(o); err != nil || sf1.Options().AssignZeroOnIntrplFail != true {
+		t.Errorf("func SetOptions wrong, err: %v", err)
+	}
+
+	bad1 := field.DefaultOptions()
+	bad1.Density = -1.0
+	if _, err := field.NewScalarField(data, bad1); err == nil {
+		t.Error("func NewScalarField should fail with invalid options")
+	}
+}
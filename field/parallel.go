@@ -0,0 +1,64 @@
+package field
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelChunk 为每个 goroutine 一次领取的索引个数. 相邻索引(如同一行的网格节点)的插值访问相近的数据,
+// 成块领取既减少了同步的开销, 又有利于缓存.
+const parallelChunk = 64
+
+// parallel 函数以 workers 个 goroutine 并发地对 0 到 n-1 的各个索引调用 fn, workers <= 0 时取 CPU 的个数.
+// 各索引按由小到大的顺序成块领取. 某个索引出错后, 比它大的索引不再调用, 比它小的索引仍照常完成, 因此返回的是
+// 出错的索引中最小的那个的错误, 与依次调用 fn 时相同, 而与调度的先后无关. 若 ctx 被取消, 则尽快停止并返回 ctx 的错误.
+// fn 对不同索引的调用可能同时进行, 它只应写入与索引对应的结果.
+func parallel(ctx context.Context, n, workers int, fn func(i int) error) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if m := (n + parallelChunk - 1) / parallelChunk; workers > m {
+		workers = m
+	}
+	var next int64
+	var mu sync.Mutex
+	first, firstErr := n, error(nil) // 出错的最小索引及其错误
+	failed := func(i int) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return i > first
+	}
+	var wg sync.WaitGroup
+	for k := 0; k < workers; k++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				start := int(atomic.AddInt64(&next, parallelChunk)) - parallelChunk
+				for i := start; i < start+parallelChunk && i < n; i++ {
+					if ctx.Err() != nil || failed(i) {
+						return
+					}
+					if err := fn(i); err != nil {
+						mu.Lock()
+						if i < first {
+							first, firstErr = i, err
+						}
+						mu.Unlock()
+						return
+					}
+				}
+				if start >= n {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return firstErr
+}
@@ -0,0 +1,128 @@
+package field
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/tensor"
+)
+
+// TestParallel 检查无论 goroutine 的个数是多少, 出错时总是返回最小索引的错误, 且该索引之前的调用都已完成.
+func TestParallel(t *testing.T) {
+	const n = 1000
+	for _, workers := range []int{0, 1, 3, 8} {
+		var called [n]int32
+		err := parallel(context.Background(), n, workers, func(i int) error {
+			atomic.StoreInt32(&called[i], 1)
+			if i%300 == 299 {
+				return fmt.Errorf("failed at %d", i)
+			}
+			return nil
+		})
+		if err == nil || err.Error() != "failed at 299" {
+			t.Errorf("%d workers: got error %v, want the one at 299", workers, err)
+		}
+		for i := 0; i < 299; i++ {
+			if called[i] == 0 {
+				t.Errorf("%d workers: index %d before the error is not processed", workers, i)
+				break
+			}
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := parallel(ctx, n, 4, func(i int) error { return nil }); err != context.Canceled {
+		t.Errorf("got %v on a canceled context", err)
+	}
+}
+
+func newRotatingTensorField(t *testing.T, workers int) *TensorField {
+	var data []*TensorQty
+	for x := 0.0; x <= 12.0; x++ {
+		for y := 0.0; y <= 12.0; y++ {
+			s := tensor.FromEig(3, 1, 0.2*x+0.1*y)
+			data = append(data, NewTensorQty(x+0.1*math.Sin(y), y, s.XX, s.YY, s.XY))
+		}
+	}
+	o := DefaultOptions()
+	o.Workers = workers
+	tf, err := NewTensorField(data, o)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return tf
+}
+
+// TestGenNodesParallel 检查并发生成的节点与逐个生成的完全相同, 且取消后场的节点保持不变.
+func TestGenNodesParallel(t *testing.T) {
+	serial := newRotatingTensorField(t, 1)
+	if err := serial.GenNodes(); err != nil {
+		t.Fatal(err.Error())
+	}
+	tf := newRotatingTensorField(t, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := tf.GenNodesContext(ctx); err == nil || tf.nodes != nil {
+		t.Errorf("a canceled GenNodesContext returned %v and set %d nodes", err, len(tf.nodes))
+	}
+	for _, m := range []Method{IDWMethod, RBFMethod, KrigingMethod, LinearMethod} {
+		serial.SetMethod(m)
+		tf.SetMethod(m)
+		if err := serial.GenNodes(); err != nil {
+			t.Fatal(err.Error())
+		}
+		if err := tf.GenNodes(); err != nil {
+			t.Fatal(err.Error())
+		}
+		for i, n := range tf.nodes {
+			if *n != *serial.nodes[i] {
+				t.Errorf("%v: node %d is %v, want %v", m, i, *n, *serial.nodes[i])
+				break
+			}
+		}
+	}
+}
+
+// TestQtiesAt 检查批量求值的结果与逐点, 逐分量求值的结果相同.
+func TestQtiesAt(t *testing.T) {
+	tf := newRotatingTensorField(t, 0)
+	if err := tf.GenNodes(); err != nil {
+		t.Fatal(err.Error())
+	}
+	var ps []geom.Point
+	for x := 0.3; x < 12.0; x += 0.7 {
+		for y := 0.2; y < 12.0; y += 0.9 {
+			ps = append(ps, geom.Point{X: x, Y: y})
+		}
+	}
+	accessors := []func(x, y float64) (float64, error){tf.XX, tf.YY, tf.XY, tf.EV1, tf.EV2, tf.ED1, tf.ED2}
+	for _, m := range []TensorIntrplMode{ComponentMode, EigenMode} {
+		tf.SetIntrplMode(m)
+		ts, err := tf.QtiesAt(ps)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		for i, p := range ps {
+			got := []float64{ts[i].XX, ts[i].YY, ts[i].XY, ts[i].EV1, ts[i].EV2, ts[i].ED1, ts[i].ED2}
+			for k, f := range accessors {
+				if v, err := f(p.X, p.Y); err != nil || math.Abs(v-got[k]) > 1e-12 {
+					t.Errorf("%v: component %d at %v is %g, want %g, %v", m, k, p, got[k], v, err)
+				}
+			}
+		}
+	}
+	if _, err := tf.QtiesAt([]geom.Point{{X: 1, Y: 1}, {X: 20, Y: 1}}); err == nil || !strings.HasPrefix(err.Error(), "point 1:") {
+		t.Errorf("got %v for a point outside the field", err)
+	}
+
+	sf := newLinearScalarField(t)
+	vs, err := sf.ValuesAt([]geom.Point{{X: 0.3, Y: 1.7}, {X: 2.0, Y: 0.0}})
+	if err != nil || math.Abs(vs[0]-3.7) > 1e-10 || math.Abs(vs[1]-2.0) > 1e-10 {
+		t.Errorf("func ScalarField.ValuesAt wrong, got %v, err: %v", vs, err)
+	}
+}
@@ -0,0 +1,308 @@
+package field
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"stj/fieldline/num"
+)
+
+// Kernel 表示径向基函数(RBF)插值所用的核函数.
+type Kernel int
+
+// 以下核函数中的 r 为以形状参数 RBF.Epsilon 为单位的距离. 多二次核函数取负值, 以使所有核函数在加上
+// 光滑参数后的系数矩阵都保持良态, 这并不改变插值结果.
+const (
+	ThinPlateKernel           Kernel = iota // 薄板样条 r^2*ln(r)
+	MultiquadricKernel                      // 多二次 -sqrt(1+r^2)
+	InverseMultiquadricKernel               // 逆多二次 1/sqrt(1+r^2)
+	GaussianKernel                          // 高斯 exp(-r^2)
+)
+
+var kernelNames = []string{"thinplate", "multiquadric", "inversemultiquadric", "gaussian"}
+
+func (k Kernel) String() string {
+	if k < 0 || int(k) >= len(kernelNames) {
+		return fmt.Sprintf("Kernel(%d)", int(k))
+	}
+	return kernelNames[k]
+}
+
+// ParseKernel 根据名称(不区分大小写)返回相应的核函数, 名称为 Kernel 的 String 方法的返回值.
+func ParseKernel(name string) (Kernel, error) {
+	for i, n := range kernelNames {
+		if strings.EqualFold(name, n) {
+			return Kernel(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown RBF kernel %q", name)
+}
+
+// phi 方法返回核函数在距离 r 处的值.
+func (k Kernel) phi(r float64) float64 {
+	switch k {
+	case MultiquadricKernel:
+		return -math.Sqrt(1.0 + r*r)
+	case InverseMultiquadricKernel:
+		return 1.0 / math.Sqrt(1.0+r*r)
+	case GaussianKernel:
+		return math.Exp(-r * r)
+	}
+	if r == 0.0 {
+		return 0.0
+	}
+	return r * r * math.Log(r)
+}
+
+// RBF 结构体给出了径向基函数插值的参数. 插值函数为各数据点处的核函数与一次多项式的线性组合, 能够精确重现
+// 线性分布的场, 在数据点稀疏时也不会像 IDW 方法那样把峰值抹平.
+type RBF struct {
+	Kernel Kernel
+	// Epsilon 为形状参数, 即核函数中距离的单位. 若为 0, 则取为参与插值的数据点的平均点距.
+	Epsilon float64
+	// Smooth 为光滑参数. 若为 0, 则插值函数精确通过各数据点; 若大于 0, 则为光滑逼近, 其值越大结果越光滑,
+	// 适用于含有噪声的数据.
+	Smooth float64
+	// Neighbors 为局部插值时使用的数据点个数: 由场的空间索引查找距点最近的 Neighbors 个数据点进行拟合.
+	// 若为 0, 则所有数据点都参与插值, 计算量随数据点个数的立方增长, 只适用于数据点很少的场.
+	Neighbors int
+}
+
+// Validate 方法检查参数是否有效.
+func (r *RBF) Validate() error {
+	if r.Kernel < 0 || int(r.Kernel) >= len(kernelNames) {
+		return fmt.Errorf("unknown RBF kernel %v", r.Kernel)
+	}
+	if r.Epsilon < 0.0 || r.Smooth < 0.0 || r.Neighbors < 0 {
+		return errors.New("the RBF parameters should not be negative")
+	}
+	return nil
+}
+
+// Value 方法利用径向基函数插值, 由标量场量 ss 求得点 (x, y) 处的值. ss 中的所有场量都参与插值, Neighbors 不起作用.
+// 若数据点少于 3 个或全部共线, 则无法确定其中的一次多项式, 此时退化为参数为 c 的 IDW 插值.
+func (r *RBF) Value(c *IDWConfig, ss []*ScalarQty, x, y float64) (float64, error) {
+	if len(ss) == 0 {
+		return 0.0, errors.New("the length of scalar quantity slice should not be zero")
+	}
+	xs, ys := make([]float64, len(ss)), make([]float64, len(ss))
+	vs := make([][]float64, len(ss))
+	for i, s := range ss {
+		xs[i], ys[i], vs[i] = s.X, s.Y, []float64{s.V}
+	}
+	v, err := r.intrpl(c, xs, ys, vs, x, y)
+	if err != nil {
+		return 0.0, err
+	}
+	return v[0], nil
+}
+
+// intrpl 方法由数据点 (xs[i], ys[i]) 处的值 vs[i] 求得点 (x, y) 处的值, 无法拟合时退化为参数为 c 的 IDW 插值.
+func (r *RBF) intrpl(c *IDWConfig, xs, ys []float64, vs [][]float64, x, y float64) ([]float64, error) {
+	m, err := r.fit(xs, ys, vs)
+	if err != nil {
+		return idwComps(c, xs, ys, vs, x, y)
+	}
+	return m.eval(x, y), nil
+}
+
+// rbfModel 为拟合所得的径向基函数插值函数.
+type rbfModel struct {
+	kernel      Kernel
+	xs, ys      []float64 // 数据点的坐标, 已平移到 (x0, y0) 并以 eps 为单位
+	x0, y0, eps float64
+	coefs       [][]float64 // 前 n 行为各数据点处核函数的系数, 后 3 行为一次多项式 1, x, y 的系数, 每列对应一个分量
+}
+
+// fit 方法由数据点 (xs[i], ys[i]) 处的值 vs[i] 拟合插值函数, vs[i] 中可以有多个分量. 各分量使用同一组数据点,
+// 同一形状参数和同一系数矩阵同时求解, 因而以一致的方式拟合. 对于张量场, 由此插值所得的各分量计算出的
+// 主方向也是光滑的, 而不会因各分量的拟合方式不同而产生扭曲.
+func (r *RBF) fit(xs, ys []float64, vs [][]float64) (*rbfModel, error) {
+	n := len(xs)
+	if n < 3 {
+		return nil, errors.New("at least 3 points are needed by the RBF interpolation")
+	}
+	xmin, xmax, ymin, ymax := xs[0], xs[0], ys[0], ys[0]
+	for i := 1; i < n; i++ {
+		xmin, xmax = math.Min(xmin, xs[i]), math.Max(xmax, xs[i])
+		ymin, ymax = math.Min(ymin, ys[i]), math.Max(ymax, ys[i])
+	}
+	eps := r.Epsilon
+	if eps <= 0.0 {
+		w, h := xmax-xmin, ymax-ymin
+		if w*h > 0.0 {
+			eps = math.Sqrt(w * h / float64(n))
+		} else {
+			eps = math.Max(w, h) / float64(n)
+		}
+		if eps == 0.0 {
+			return nil, errors.New("all the points used by the RBF interpolation coincide")
+		}
+	}
+	m := &rbfModel{
+		kernel: r.Kernel,
+		xs:     make([]float64, n),
+		ys:     make([]float64, n),
+		x0:     0.5 * (xmin + xmax),
+		y0:     0.5 * (ymin + ymax),
+		eps:    eps,
+	}
+	for i := 0; i < n; i++ {
+		m.xs[i] = (xs[i] - m.x0) / eps
+		m.ys[i] = (ys[i] - m.y0) / eps
+	}
+	a := make([][]float64, n+3)
+	b := make([][]float64, n+3)
+	for i := range a {
+		a[i] = make([]float64, n+3)
+		b[i] = make([]float64, len(vs[0]))
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			a[i][j] = r.Kernel.phi(math.Hypot(m.xs[i]-m.xs[j], m.ys[i]-m.ys[j]))
+			a[j][i] = a[i][j]
+		}
+		a[i][i] = r.Kernel.phi(0.0) + r.Smooth
+		a[i][n], a[i][n+1], a[i][n+2] = 1.0, m.xs[i], m.ys[i]
+		a[n][i], a[n+1][i], a[n+2][i] = 1.0, m.xs[i], m.ys[i]
+		copy(b[i], vs[i])
+	}
+	if err := num.Solve(a, b); err != nil {
+		return nil, err
+	}
+	m.coefs = b
+	return m, nil
+}
+
+// eval 方法返回插值函数在点 (x, y) 处的各分量.
+func (m *rbfModel) eval(x, y float64) []float64 {
+	x, y = (x-m.x0)/m.eps, (y-m.y0)/m.eps
+	n := len(m.xs)
+	vs := make([]float64, len(m.coefs[n]))
+	for k := range vs {
+		vs[k] = m.coefs[n][k] + m.coefs[n+1][k]*x + m.coefs[n+2][k]*y
+	}
+	for i := 0; i < n; i++ {
+		p := m.kernel.phi(math.Hypot(x-m.xs[i], y-m.ys[i]))
+		for k := range vs {
+			vs[k] += m.coefs[i][k] * p
+		}
+	}
+	return vs
+}
+
+// idwComps 利用参数为 c 的 IDW 方法分别对各分量进行插值.
+func idwComps(c *IDWConfig, xs, ys []float64, vs [][]float64, x, y float64) ([]float64, error) {
+	if len(xs) == 0 {
+		return nil, errors.New("the length of scalar quantity slice should not be zero")
+	}
+	ss := make([]*ScalarQty, len(xs))
+	out := make([]float64, len(vs[0]))
+	for k := range out {
+		for i := range ss {
+			ss[i] = &ScalarQty{X: xs[i], Y: ys[i], V: vs[i][k]}
+		}
+		v, err := c.Value(ss, x, y)
+		if err != nil {
+			return nil, err
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// nearQtyIdxes 方法利用空间索引返回距点 (x, y) 最近的 n 个数据点. 当数据点不足 n 个时, 返回所有的数据点.
+func (f *baseField) nearQtyIdxes(x, y float64, n int) []int {
+	return neighborIdxes(f.index.KNearest(x, y, n))
+}
+
+// rbfIntrpl 方法返回按场的参数 Options.RBF 进行插值的函数. 场中共有 n 个数据点, qty 返回索引为 i 的数据点的坐标
+// 及其各分量的值. 若 Neighbors 为 0, 则只拟合一次插值函数; 否则对每个点都由其附近的数据点
+// 进行局部拟合. 若点附近没有任何数据点, 则与 IDW 插值失败时的处理方式相同.
+func (f *baseField) rbfIntrpl(n int, qty func(i int) (x, y float64, vs []float64)) func(x, y float64) ([]float64, error) {
+	r := f.opts.RBF
+	collect := func(idxes []int) (xs, ys []float64, vs [][]float64) {
+		xs, ys, vs = make([]float64, len(idxes)), make([]float64, len(idxes)), make([][]float64, len(idxes))
+		for i, qi := range idxes {
+			xs[i], ys[i], vs[i] = qty(qi)
+		}
+		return xs, ys, vs
+	}
+	if r.Neighbors <= 0 {
+		idxes := make([]int, n)
+		for i := range idxes {
+			idxes[i] = i
+		}
+		xs, ys, vs := collect(idxes)
+		m, err := r.fit(xs, ys, vs)
+		return func(x, y float64) ([]float64, error) {
+			if err != nil {
+				return idwComps(&f.opts.IDW, xs, ys, vs, x, y)
+			}
+			return m.eval(x, y), nil
+		}
+	}
+	return func(x, y float64) ([]float64, error) {
+		idxes := f.nearQtyIdxes(x, y, r.Neighbors)
+		if len(idxes) == 0 {
+			if !f.opts.AssignZeroOnIntrplFail {
+				return nil, errors.New("no known point existing around the given point")
+			}
+			return nil, nil
+		}
+		xs, ys, vs := collect(idxes)
+		return r.intrpl(&f.opts.IDW, xs, ys, vs, x, y)
+	}
+}
+
+// rbfValue 方法返回利用径向基函数插值计算网格节点处标量的函数.
+func (sf *ScalarField) rbfValue() func(x, y float64) (float64, error) {
+	intrpl := sf.rbfIntrpl(len(sf.data), func(i int) (x, y float64, vs []float64) {
+		return sf.data[i].X, sf.data[i].Y, []float64{sf.data[i].V}
+	})
+	return func(x, y float64) (float64, error) {
+		vs, err := intrpl(x, y)
+		if err != nil || vs == nil {
+			return 0.0, err
+		}
+		return vs[0], nil
+	}
+}
+
+// rbfTensorQty 方法返回利用径向基函数插值计算网格节点处张量场量的函数. 张量的三个分量以一致的方式同时拟合.
+func (tf *TensorField) rbfTensorQty() func(x, y float64) (*TensorQty, error) {
+	intrpl := tf.rbfIntrpl(len(tf.data), func(i int) (x, y float64, vs []float64) {
+		t := tf.data[i]
+		return t.X, t.Y, []float64{t.XX, t.YY, t.XY}
+	})
+	return func(x, y float64) (*TensorQty, error) {
+		vs, err := intrpl(x, y)
+		if err != nil {
+			return nil, err
+		}
+		if vs == nil {
+			return NewTensorQty(x, y, 0.0, 0.0, 0.0), nil
+		}
+		return NewTensorQty(x, y, vs[0], vs[1], vs[2]), nil
+	}
+}
+
+// rbfVectorQty 方法返回利用径向基函数插值计算网格节点处向量的函数. 向量的两个分量以一致的方式同时拟合.
+func (vf *VectorField) rbfVectorQty() func(x, y float64) (*VectorQty, error) {
+	intrpl := vf.rbfIntrpl(len(vf.data), func(i int) (x, y float64, vs []float64) {
+		v := vf.data[i]
+		return v.X, v.Y, []float64{v.Vector.X, v.Vector.Y}
+	})
+	return func(x, y float64) (*VectorQty, error) {
+		vs, err := intrpl(x, y)
+		if err != nil {
+			return nil, err
+		}
+		if vs == nil {
+			return NewVectorQty(x, y, 0.0, 0.0), nil
+		}
+		return NewVectorQty(x, y, vs[0], vs[1]), nil
+	}
+}
@@ -0,0 +1,93 @@
+package field_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"stj/fieldline/field"
+)
+
+func TestParseKernel(t *testing.T) {
+	for _, k := range []field.Kernel{field.ThinPlateKernel, field.MultiquadricKernel, field.InverseMultiquadricKernel, field.GaussianKernel} {
+		if got, err := field.ParseKernel(k.String()); err != nil || got != k {
+			t.Errorf("ParseKernel(%q) = %v, %v", k.String(), got, err)
+		}
+	}
+	if _, err := field.ParseKernel("cubic"); err == nil {
+		t.Error("an unknown kernel should be rejected")
+	}
+}
+
+// TestRBF 检查各核函数都能精确重现线性分布的场, 且在数据点稀疏时比 IDW 方法更好地保留峰值.
+func TestRBF(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	linear := func(x, y float64) float64 { return 2*x - y + 3 }
+	var ss []*field.ScalarQty
+	for i := 0; i < 20; i++ {
+		x, y := 4*r.Float64(), 4*r.Float64()
+		ss = append(ss, field.NewScalarQty(x, y, linear(x, y)))
+	}
+	peak := func(x, y float64) float64 { return math.Exp(-(x-2)*(x-2) - (y-2)*(y-2)) }
+	var ps []*field.ScalarQty
+	for x := 0.0; x <= 4.0; x++ {
+		for y := 0.0; y <= 4.0; y++ {
+			ps = append(ps, field.NewScalarQty(x, y, peak(x, y)))
+		}
+	}
+	idw := field.DefaultOptions().IDW
+	for _, k := range []field.Kernel{field.ThinPlateKernel, field.MultiquadricKernel, field.InverseMultiquadricKernel, field.GaussianKernel} {
+		rbf := field.RBF{Kernel: k}
+		for _, p := range [][2]float64{{0.3, 0.7}, {1.5, 2.5}, {3.1, 0.4}} {
+			v, err := rbf.Value(&idw, ss, p[0], p[1])
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			if math.Abs(v-linear(p[0], p[1])) > 1e-6 {
+				t.Errorf("%v: got %g at (%g, %g), want %g", k, v, p[0], p[1], linear(p[0], p[1]))
+			}
+		}
+		v, _ := rbf.Value(&idw, ps, 2.5, 2.0)
+		w, _ := field.IDW(ps, 2.5, 2.0, idw.Power)
+		if want := peak(2.5, 2.0); math.Abs(v-want) >= math.Abs(w-want) {
+			t.Errorf("%v: got %g near the peak, IDW got %g, want %g", k, v, w, want)
+		}
+	}
+	// 光滑逼近不再精确通过数据点
+	rbf := field.RBF{Smooth: 1.0}
+	if v, _ := rbf.Value(&idw, ps, 2.0, 2.0); v >= 1.0-1e-3 {
+		t.Errorf("got %g at the peak with smoothing", v)
+	}
+}
+
+// TestRBFMethod 检查以 RBFMethod 生成的张量场在稀疏数据的峰值处比 IDW 方法更准确.
+func TestRBFMethod(t *testing.T) {
+	peak := func(x, y float64) float64 { return 10 * math.Exp(-((x-4)*(x-4)+(y-4)*(y-4))/8) }
+	var data []*field.TensorQty
+	for x := 0.0; x <= 8.0; x += 2.0 {
+		for y := 0.0; y <= 8.0; y += 2.0 {
+			data = append(data, field.NewTensorQty(x, y, peak(x, y), -peak(x, y), 0.5*peak(x, y)))
+		}
+	}
+	errs := make(map[field.Method]float64)
+	for _, m := range []field.Method{field.IDWMethod, field.RBFMethod} {
+		tf, err := field.NewTensorField(data, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		tf.SetMethod(m)
+		if err := tf.GenNodes(); err != nil {
+			t.Fatal(err.Error())
+		}
+		for _, p := range [][2]float64{{4, 4}, {3, 5}, {5, 4}} {
+			v, err := tf.XY(p[0], p[1])
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			errs[m] = math.Max(errs[m], math.Abs(v-0.5*peak(p[0], p[1])))
+		}
+	}
+	if errs[field.RBFMethod] >= errs[field.IDWMethod] {
+		t.Errorf("maximum error of RBF %g, of IDW %g", errs[field.RBFMethod], errs[field.IDWMethod])
+	}
+}
@@ -1,9 +1,14 @@
 package field
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"math"
 
+	"stj/fieldline/geom"
 	"stj/fieldline/grid"
 )
 
@@ -41,50 +46,190 @@ func (sf *ScalarField) Mean() (float64, error) {
 	return sum / float64(n), nil
 }
 
-// idwValue 根据已知点数据利用 IDW 插值方法获得点 (x, y) 坐标处的值.
-func (sf *ScalarField) idwValue(x, y float64) (float64, error) {
-	xi, yi, idx, _ := sf.grid.CellPosIdx(x, y)
-	for layer := MinIntrplLayer; layer <= MaxIntrplLayer; layer++ {
-		cells := sf.grid.NearCellsAlt(xi, yi, idx, layer)
-		qtyIdxes := make([]int, 0, int(1.25*grid.AvgQtyNumPerCell*float64(len(cells))))
-		for i := 0; i < len(cells); i++ {
-			qtyIdxes = append(qtyIdxes, cells[i].QtyIdxes...)
+// NewScalarField 根据无规则离散分布的标量场量数据 data 创建一个标量场,
+// 其网格(Grid) 的大小由数据的坐标范围和个数以及参数 o 中的网格密度自动确定. 若 o 为 nil, 则使用默认参数.
+func NewScalarField(data []*ScalarQty, o *Options) (sf *ScalarField, err error) {
+	if len(data) == 0 {
+		return nil, errors.New("no scalar quantity given")
+	}
+	b, err := newBaseField(len(data), func(i int) (x, y float64) {
+		return data[i].X, data[i].Y
+	}, o)
+	if err != nil {
+		return nil, err
+	}
+	sf = &ScalarField{baseField: b}
+	sf.data = data
+	return sf, nil
+}
+
+// ParseScalarData 解析由数值模拟导出的标量场数据文本, 并生成一个 *ScalarField.
+// 该文本的格式为以下形式:
+//
+// x, y, v\n
+//
+// 数字之间的分隔符以及行尾的要求与 ParseTensorData 相同. o 的含义与 ReadScalarData 相同.
+func ParseScalarData(input []byte, o *Options) (sf *ScalarField, err error) {
+	return ReadScalarData(bytes.NewReader(input), o)
+}
+
+// ReadScalarData 从 r 中逐行读取由数值模拟导出的标量场数据文本, 并生成一个 *ScalarField.
+// 文本的格式与 ParseScalarData 相同. 该函数并不将整个文本同时读入内存, 适用于很大的数据文件.
+// o 为解析数据和创建场所用的参数, 若为 nil, 则使用默认参数.
+func ReadScalarData(r io.Reader, o *Options) (sf *ScalarField, err error) {
+	if o, err = o.resolve(); err != nil {
+		return nil, err
+	}
+	var data []*ScalarQty
+	// 如果每行解析出的文本数不等于 3, 则并不满足标量数据需求, 直接舍弃
+	err = readData(r, 3, func(floats []float64) {
+		if !o.DiscardZeroQty || !isZeroQty(floats[2]) {
+			data = append(data, NewScalarQty(floats[0], floats[1], floats[2]))
 		}
-		num := len(qtyIdxes)
-		fail := layer >= MaxIntrplLayer && num < MinIntrplQtyNum
-		succ := num >= MaxIntrplQtyNum || ((num >= MinIntrplQtyNum && num < MaxIntrplQtyNum) && layer > MinIntrplLayer)
-		if fail {
-			if !AssignZeroOnIntrplFail {
-				return 0.0, errors.New("no known point existing around the given point")
-			}
-			return 0.0, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("no valid data parsed")
+	}
+	return NewScalarField(data, o)
+}
+
+// MinMax 方法返回标量场中所有标量的最小值和最大值. 该方法会舍弃非值(NaN) 标量.
+func (sf *ScalarField) MinMax() (min, max float64, err error) {
+	n := 0
+	for _, d := range sf.data {
+		if math.IsNaN(d.V) {
+			continue
 		}
-		if succ {
-			ss := make([]*ScalarQty, len(qtyIdxes))
-			for i := 0; i < len(qtyIdxes); i++ {
-				ss[i] = sf.data[qtyIdxes[i]]
-			}
-			return IDW(ss, x, y, DefaultIDWPower)
+		if n == 0 || d.V < min {
+			min = d.V
+		}
+		if n == 0 || d.V > max {
+			max = d.V
+		}
+		n++
+	}
+	if n == 0 {
+		return 0.0, 0.0, errors.New("no valid quantity existing in the scalar field")
+	}
+	return min, max, nil
+}
+
+// V 方法通过空间插值方法获得标量场内任意点 (x, y) 处的值.
+func (sf *ScalarField) V(x, y float64) (v float64, err error) {
+	if err := sf.checkDomain(x, y); err != nil {
+		return 0.0, err
+	}
+	if sf.mesh != nil {
+		return sf.mesh.Interpolate(x, y, func(ni int) float64 { return sf.data[ni].V })
+	}
+	if len(sf.nodes) == 0 {
+		return 0.0, errors.New("the nodes of the scalar field have not been generated")
+	}
+	cell, nodeIdxes, err := sf.grid.CellNodeIdxes(x, y)
+	if err != nil {
+		return 0.0, err
+	}
+	ll := sf.nodes[nodeIdxes[0]].V
+	ul := sf.nodes[nodeIdxes[1]].V
+	lu := sf.nodes[nodeIdxes[2]].V
+	uu := sf.nodes[nodeIdxes[3]].V
+	return cell.Value(x, y, ll, ul, lu, uu), nil
+}
+
+// ValuesAt 方法求标量场内各点 ps 处的值, 它与对每个点调用 V 方法的结果相同, 但各点在多个 goroutine 中同时求值,
+// goroutine 的个数由场的参数 Workers 确定. 若某点求值失败, 则返回的错误中指明该点在 ps 中的索引.
+func (sf *ScalarField) ValuesAt(ps []geom.Point) ([]float64, error) {
+	vs := make([]float64, len(ps))
+	err := parallel(context.Background(), len(ps), sf.opts.Workers, func(i int) (err error) {
+		if vs[i], err = sf.V(ps[i].X, ps[i].Y); err != nil {
+			return fmt.Errorf("point %d: %v", i, err)
 		}
-		// 不满足 fail 或 succ 条件, 就只能满足继续条件了, 这是加大一层 layer 继续查找.
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return 0.0, errors.New("no quantities found around the given point")
+	return vs, nil
+}
+
+// idwValue 根据已知点数据利用 IDW 插值方法获得点 (x, y) 坐标处的值.
+func (sf *ScalarField) idwValue(x, y float64) (float64, error) {
+	ns := sf.idwNeighbors(x, y, func(i int) (x, y float64) {
+		return sf.data[i].X, sf.data[i].Y
+	})
+	if ns == nil {
+		if !sf.opts.AssignZeroOnIntrplFail {
+			return 0.0, errors.New("no known point existing around the given point")
+		}
+		return 0.0, nil
+	}
+	var a, b float64
+	for i, w := range idwWeights(ns, sf.opts.IDW.Power) {
+		a += w * sf.data[ns[i].Idx].V
+		b += w
+	}
+	return a / b, nil
+}
+
+// NearN 方法返回距点 (x, y) 最近的 n 个标量及其到该点的距离, 按距离由近到远排列, 距离相等时按数据的顺序排列.
+// 当场中的数据不足 n 个时, 返回所有的标量. n 应大于 0.
+func (sf *ScalarField) NearN(x, y float64, n int) (ss []*ScalarQty, dists []float64, err error) {
+	ns, err := sf.nearest(x, y, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	ss, dists = make([]*ScalarQty, len(ns)), make([]float64, len(ns))
+	for i, n := range ns {
+		ss[i], dists[i] = sf.data[n.Idx], n.Dist
+	}
+	return ss, dists, nil
 }
 
 // GenNodes 根据张量场中无规则离散分布的张量场量数据 data, 通过反距离加权插值方法,
 // 计算各个单元格节点处的张量场量, 从而构建出可以进行双线性插值的张量场网格.
+// 若通过 SetMethod 选择了其他插值方法, 则使用该方法进行插值; 对于由有限元网格创建的标量场,
+// 节点处的值由形函数插值求得. 若场设置了定义域, 则定义域以外且不与之相交的单元格的节点不进行插值, 其值为 NaN.
+// 各节点在多个 goroutine 中同时插值, goroutine 的个数由场的参数 Workers 确定, 其结果与逐个插值相同.
 func (sf *ScalarField) GenNodes() (err error) {
-	sf.nodes = make([]*ScalarQty, sf.grid.NodeNum)
-	for i := 0; i < sf.grid.NodeNum; i++ {
-		xi, yi := sf.grid.NodePos(i)
-		x := float64(xi) * sf.grid.XSpan
-		y := float64(yi) * sf.grid.YSpan
-		sf.nodes[i] = &ScalarQty{X: x, Y: y}
-		sf.nodes[i].V, err = sf.idwValue(x, y)
-		if err != nil {
+	return sf.GenNodesContext(context.Background())
+}
+
+// GenNodesContext 方法与 GenNodes 方法相同, 但可以通过 ctx 中途取消. 取消或插值失败时返回一个错误,
+// 且场原有的节点数据保持不变.
+func (sf *ScalarField) GenNodesContext(ctx context.Context) (err error) {
+	intrpl := sf.idwValue
+	switch {
+	case sf.mesh != nil:
+		intrpl = sf.meshNodeValue
+	case sf.method == RBFMethod:
+		intrpl = sf.rbfValue()
+	case sf.method == KrigingMethod:
+		if intrpl, err = sf.krigValue(); err != nil {
+			return err
+		}
+	case sf.usesTriangulation():
+		if intrpl, err = sf.triValue(); err != nil {
 			return err
 		}
 	}
+	nodes := make([]*ScalarQty, sf.grid.NodeNum)
+	err = parallel(ctx, len(nodes), sf.opts.Workers, func(i int) (err error) {
+		x, y := sf.grid.Nodes[i].X, sf.grid.Nodes[i].Y
+		nodes[i] = &ScalarQty{X: x, Y: y}
+		if sf.grid.NodeLoc(i) == grid.Outside {
+			nodes[i].V = math.NaN()
+			return nil
+		}
+		nodes[i].V, err = intrpl(x, y)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	sf.nodes = nodes
 	return nil
 }
 
@@ -1 +1,152 @@
 package field
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/grid"
+)
+
+// newLinearScalarField 创建一个定义在 [0, 2]x[0, 2] 上的标量场, 其节点值为 v = x + 2y.
+func newLinearScalarField(t *testing.T) *ScalarField {
+	r, _ := geom.NewRect(0.0, 0.0, 2.0, 2.0)
+	g, err := grid.New(*r, 2, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	sf := &ScalarField{}
+	sf.grid = g
+	sf.nodes = make([]*ScalarQty, g.NodeNum)
+	for i := 0; i < g.NodeNum; i++ {
+		x, y := g.Nodes[i].X, g.Nodes[i].Y
+		sf.nodes[i] = NewScalarQty(x, y, x+2.0*y)
+	}
+	return sf
+}
+
+func TestScalarFieldV(t *testing.T) {
+	sf := newLinearScalarField(t)
+	v, err := sf.V(0.3, 1.7)
+	if err != nil || math.Abs(v-3.7) > 1.0e-10 {
+		t.Errorf("func ScalarField.V wrong, got %v, err: %v", v, err)
+	}
+	if _, err := sf.V(3.0, 1.0); err == nil {
+		t.Error("func ScalarField.V should fail outside the field")
+	}
+}
+
+func TestContour(t *testing.T) {
+	sf := newLinearScalarField(t)
+	lines, err := sf.Contour(3.0)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(lines) == 0 {
+		t.Fatal("no contour line found")
+	}
+	for _, l := range lines {
+		if math.Abs(l.X1+2.0*l.Y1-3.0) > 1.0e-10 || math.Abs(l.X2+2.0*l.Y2-3.0) > 1.0e-10 {
+			t.Errorf("contour segment (%v, %v)-(%v, %v) is not on the level", l.X1, l.Y1, l.X2, l.Y2)
+		}
+	}
+	lines, _ = sf.Contour(100.0)
+	if len(lines) != 0 {
+		t.Error("contour out of the value range should be empty")
+	}
+}
+
+func TestParseScalarData(t *testing.T) {
+	input := []byte("x, y, t\n0, 0, 10\r\n1, 0, 20\n\n0, 2, 30\n1, 2, 40, 50\n")
+	sf, err := ParseScalarData(input, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(sf.data) != 3 {
+		t.Fatalf("got %d scalar quantities, want 3", len(sf.data))
+	}
+	r := sf.Range()
+	if r.Xmin != 0.0 || r.Xmax != 1.0 || r.Ymin != 0.0 || r.Ymax != 2.0 {
+		t.Errorf("wrong field range: %v", *r)
+	}
+	if mean, _ := sf.Mean(); mean != 20.0 {
+		t.Errorf("wrong mean value: %v", mean)
+	}
+	if _, err := ParseScalarData([]byte("1, 2\n3, 4\n"), nil); err == nil {
+		t.Error("func ParseScalarData should fail without valid data")
+	}
+}
+
+func TestScalarFieldNearN(t *testing.T) {
+	var data []*ScalarQty
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			data = append(data, NewScalarQty(float64(i), float64(j), float64(10*i+j)))
+		}
+	}
+	sf, err := NewScalarField(data, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ss, ds, err := sf.NearN(4.2, 5.3, 5)
+	if err != nil || len(ss) != 5 || len(ds) != 5 {
+		t.Fatalf("func ScalarField.NearN wrong, got %d quantities, err: %v", len(ss), err)
+	}
+	want := []float64{45, 46, 55, 56, 35}
+	for i, s := range ss {
+		if s.V != want[i] || math.Abs(ds[i]-math.Hypot(s.X-4.2, s.Y-5.3)) > 1.0e-12 {
+			t.Errorf("func ScalarField.NearN wrong at %d, got %v at distance %v", i, s.V, ds[i])
+		}
+		if i > 0 && ds[i] < ds[i-1] {
+			t.Errorf("func ScalarField.NearN not sorted at %d", i)
+		}
+	}
+	if ss, _, _ := sf.NearN(4.2, 5.3, 1000); len(ss) != len(data) {
+		t.Errorf("func ScalarField.NearN should return all %d quantities, got %d", len(data), len(ss))
+	}
+	if _, _, err := sf.NearN(4.2, 5.3, 0); err == nil {
+		t.Error("func ScalarField.NearN should fail when n <= 0")
+	}
+}
+
+func TestIDWNeighbors(t *testing.T) {
+	var data []*ScalarQty
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 10; j++ {
+			data = append(data, NewScalarQty(float64(i), float64(j), float64(10*i+j)))
+		}
+	}
+	sf, err := NewScalarField(data, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	pos := func(i int) (x, y float64) { return data[i].X, data[i].Y }
+	cases := []struct {
+		c    IDWConfig
+		want []int
+	}{
+		{IDWConfig{Power: 2, MaxQty: 8, Radius: 3, Sector: 1}, []int{45, 46, 55, 56}},
+		{IDWConfig{Power: 2, MaxQty: 3, Ratio: 10}, []int{45, 55, 35}},
+		{IDWConfig{Power: 2, MaxQty: 3, Ratio: 10, Angle: math.Pi / 2}, []int{45, 46, 44}},
+	}
+	for _, cs := range cases {
+		if err := sf.SetIDWConfig(cs.c); err != nil {
+			t.Fatal(err.Error())
+		}
+		ns := sf.idwNeighbors(4.2, 5.3, pos)
+		if cs.c.Sector > 0 && len(ns) != len(cs.want) {
+			t.Errorf("sector search %+v got %d points, want %d", cs.c, len(ns), len(cs.want))
+		}
+		if len(ns) < len(cs.want) {
+			t.Fatalf("search %+v got only %d points", cs.c, len(ns))
+		}
+		for i, idx := range cs.want {
+			if ns[i].Idx != idx {
+				t.Errorf("search %+v got %v at %d, want %d", cs.c, ns[i].Idx, i, idx)
+			}
+		}
+	}
+	if err := sf.SetIDWConfig(IDWConfig{Power: 2}); err == nil {
+		t.Error("func SetIDWConfig should fail when MaxQty is zero")
+	}
+}
@@ -0,0 +1,216 @@
+package field
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/ode"
+	"stj/fieldline/vector"
+)
+
+// SeparatrixEnd 表示向量场或张量场的分界线终止的原因.
+type SeparatrixEnd int
+
+const (
+	EndAtCriticalPoint SeparatrixEnd = iota // 到达一个临界点或退化点(可以是其出发的点本身)
+	EndAtClosedOrbit                        // 趋近于一条闭合轨道(极限环)或与自身闭合
+	EndAtBoundary                           // 到达场或定义域的边界
+	EndAtMaxSteps                           // 达到最大的积分步数
+)
+
+var separatrixEndNames = []string{"criticalpoint", "closedorbit", "boundary", "maxsteps"}
+
+func (e SeparatrixEnd) String() string {
+	if e < 0 || int(e) >= len(separatrixEndNames) {
+		return fmt.Sprintf("SeparatrixEnd(%d)", int(e))
+	}
+	return separatrixEndNames[e]
+}
+
+// 以下各距离都以网格单元格较短的边长为单位.
+const (
+	separatrixSeedDist = 0.01 // 分界线的起点与鞍点的距离
+	separatrixStopDist = 0.05 // 分界线与临界点的距离小于该值时, 认为已到达临界点
+	separatrixLoopDist = 0.05 // 分界线与其已经过的一段的距离小于该值且方向相同时, 认为已闭合
+)
+
+// Separatrix 是由鞍点出发的一条分界线, 它将向量场划分为流线走势不同的区域. 每个鞍点有 4 条分界线: 2 条沿正特征值的
+// 特征向量方向离开鞍点, 2 条沿负特征值的特征向量方向趋近鞍点.
+type Separatrix struct {
+	// From 和 To 分别为分界线起点的鞍点和终点的临界点在 Skeleton.Points 中的索引.
+	// 若分界线不终止于临界点, 则 To 为 -1.
+	From, To int
+	// Outgoing 为 true 时, 分界线沿流动方向离开鞍点; 否则分界线上的流动趋近鞍点.
+	Outgoing bool
+	End      SeparatrixEnd
+	// Points 为分界线上的点, 从鞍点开始排列. 若分界线终止于临界点, 则最后一点即为该临界点.
+	Points []geom.Point
+}
+
+// Skeleton 是向量场的拓扑骨架. 它是一个图: 临界点为图的节点, 分界线为连接节点的边.
+// 骨架将向量场划分为若干区域, 每个区域内的流线有相同的起点和终点.
+type Skeleton struct {
+	Points       []*CriticalPoint
+	Separatrices []*Separatrix
+}
+
+// Edges 方法返回与索引为 i 的临界点相连的各条分界线在 Separatrices 中的索引. 由鞍点出发且回到该鞍点的分界线
+// 只计一次.
+func (s *Skeleton) Edges(i int) []int {
+	var es []int
+	for k, sep := range s.Separatrices {
+		if sep.From == i || sep.To == i {
+			es = append(es, k)
+		}
+	}
+	return es
+}
+
+// Skeleton 方法求向量场的拓扑骨架: 先求出场的所有临界点, 再由每个鞍点沿雅可比矩阵的两个特征向量的正反方向
+// 积分出 4 条分界线. 分界线在到达另一个(或同一个)临界点, 趋近闭合轨道或到达场的边界时终止, 最多积分 nMax 步.
+// o 为积分参数, 若为 nil, 则使用默认参数. 各条分界线在多个 goroutine 中同时积分, goroutine 的个数由场的参数
+// Workers 确定, 结果与积分的先后无关. 该方法应在 GenNodes 之后调用.
+func (vf *VectorField) Skeleton(o *ode.Options, nMax int) (*Skeleton, error) {
+	if nMax <= 0 {
+		return nil, errors.New("the maximum number of integration steps should be greater than zero")
+	}
+	if o != nil {
+		if err := o.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	cps, err := vf.CriticalPoints()
+	if err != nil {
+		return nil, err
+	}
+	sk := &Skeleton{Points: cps}
+	var dirs []*vector.Vector // 各条分界线离开鞍点的方向
+	for i, c := range cps {
+		if c.Kind != Saddle {
+			continue
+		}
+		e1, e2, err := c.Eigenvectors()
+		if err != nil {
+			return nil, err
+		}
+		for k, e := range []*vector.Vector{e1, e1.Reverse(), e2, e2.Reverse()} {
+			sk.Separatrices = append(sk.Separatrices, &Separatrix{From: i, To: -1, Outgoing: k < 2})
+			dirs = append(dirs, e)
+		}
+	}
+	nodes := make([]geom.Point, len(cps))
+	for i, c := range cps {
+		nodes[i] = c.Point
+	}
+	span := math.Min(vf.grid.XSpan, vf.grid.YSpan)
+	err = parallel(context.Background(), len(dirs), vf.opts.Workers, func(k int) error {
+		s := sk.Separatrices[k]
+		s.Points, s.End, s.To = traceSeparatrix(vf.Slope, nodes, s.From, dirs[k], span, o, nMax)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sk, nil
+}
+
+// traceSeparatrix 函数以 f 为斜率, 由奇点 nodes[from] 沿方向 e 积分一条分界线. 分界线在到达 nodes 中的另一个奇点
+// (或离开后又回到 nodes[from]), 与自身闭合, 到达边界或积分 nMax 步后终止. span 为网格单元格较短的边长, 各个判断距离
+// 都以它为单位. 返回的点从 nodes[from] 开始排列; 若分界线终止于奇点 nodes[to], 则最后一点即为该奇点, 否则 to 为 -1.
+// f 只应是一个斜率场, 积分所得的曲线总是背离 nodes[from], 与向量或特征向量的指向无关.
+func traceSeparatrix(f ode.ODE, nodes []geom.Point, from int, e *vector.Vector, span float64, o *ode.Options, nMax int) (points []geom.Point, end SeparatrixEnd, to int) {
+	start := nodes[from]
+	seed := geom.Point{X: start.X + separatrixSeedDist*span*e.X, Y: start.Y + separatrixSeedDist*span*e.Y}
+	points = []geom.Point{start, seed}
+	to = -1
+	slope, err := f(seed.X, seed.Y)
+	if err != nil {
+		return points, EndAtBoundary, to
+	}
+	// 积分器按斜率确定最初沿 x 轴还是 y 轴推进, 应使其背离出发的奇点
+	forward := e.Y > 0.0
+	if math.Abs(slope) <= 1.0 {
+		forward = e.X > 0.0
+	}
+	stopDist, loopDist := separatrixStopDist*span, separatrixLoopDist*span
+	left := false     // 分界线是否已离开其出发的奇点, 此后回到该奇点才算终止
+	var arc []float64 // 由种子点到积分所得各点的弧长
+	past := newSegBuckets(4.0*loopDist, loopDist)
+	ps, stopped := ode.NewIntegrator(o).StepsUntil(f, seed.X, seed.Y, forward, nMax, func(ps []geom.Point) bool {
+		p, q := seed, ps[len(ps)-1]
+		if len(ps) > 1 {
+			p = ps[len(ps)-2]
+		}
+		l := math.Hypot(q.X-p.X, q.Y-p.Y)
+		if len(arc) > 0 {
+			l += arc[len(arc)-1]
+		}
+		arc = append(arc, l)
+		for i, c := range nodes {
+			if (i != from || left) && geom.SegDist(c.X, c.Y, p, q) < stopDist {
+				end, to = EndAtCriticalPoint, i
+				return true
+			}
+		}
+		left = left || math.Hypot(q.X-start.X, q.Y-start.Y) > 2.0*stopDist
+		// 与此前经过的, 方向相同的一段相距很近时, 认为分界线已闭合. 沿分界线相距不远的各段总是相距很近, 不予检查.
+		dx, dy := q.X-p.X, q.Y-p.Y
+		if len(ps) > 1 {
+			past.add(len(ps)-2, p, q)
+		}
+		for _, i := range past.near(q) {
+			a, b := ps[i], ps[i+1]
+			if l-arc[i+1] > 4.0*loopDist && geom.SegDist(q.X, q.Y, a, b) < loopDist && dx*(b.X-a.X)+dy*(b.Y-a.Y) > 0.0 {
+				end = EndAtClosedOrbit
+				return true
+			}
+		}
+		return false
+	})
+	points = append(points, ps...)
+	switch {
+	case stopped && end == EndAtCriticalPoint:
+		points = append(points, nodes[to])
+	case !stopped && len(ps) == nMax:
+		end = EndAtMaxSteps
+	case !stopped:
+		end = EndAtBoundary
+	}
+	return points, end, to
+}
+
+// segBuckets 将一条折线的各段登记到边长为 size 的方格中, 用于查找与某点的距离可能小于 r 的线段, 以免每次都
+// 与此前所有的线段比较. 每段都登记在其外接矩形向外扩展 r 后所覆盖的所有方格中.
+type segBuckets struct {
+	size, r float64
+	cells   map[[2]int][]int
+}
+
+func newSegBuckets(size, r float64) *segBuckets {
+	return &segBuckets{size: size, r: r, cells: make(map[[2]int][]int)}
+}
+
+// cell 方法返回点 (x, y) 所在方格的列, 行数.
+func (b *segBuckets) cell(x, y float64) [2]int {
+	return [2]int{int(math.Floor(x / b.size)), int(math.Floor(y / b.size))}
+}
+
+// add 方法登记由点 p 到点 q 的线段, i 为该线段的索引.
+func (b *segBuckets) add(i int, p, q geom.Point) {
+	lo := b.cell(math.Min(p.X, q.X)-b.r, math.Min(p.Y, q.Y)-b.r)
+	hi := b.cell(math.Max(p.X, q.X)+b.r, math.Max(p.Y, q.Y)+b.r)
+	for yi := lo[1]; yi <= hi[1]; yi++ {
+		for xi := lo[0]; xi <= hi[0]; xi++ {
+			k := [2]int{xi, yi}
+			b.cells[k] = append(b.cells[k], i)
+		}
+	}
+}
+
+// near 方法返回与点 q 的距离可能小于 r 的各线段的索引.
+func (b *segBuckets) near(q geom.Point) []int {
+	return b.cells[b.cell(q.X, q.Y)]
+}
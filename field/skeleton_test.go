@@ -0,0 +1,93 @@
+package field
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/vector"
+)
+
+// TestSkeleton 检查向量场 v = (x^2 - 1, 0.5 - y) 的拓扑骨架: (1, 0.5) 处为鞍点, (-1, 0.5) 处为汇. 鞍点向左的分界线终止于汇,
+// 其余 3 条分界线终止于场的边界.
+func TestSkeleton(t *testing.T) {
+	vf := newSampledVectorField(t, 2.0, 2.0, 0.2, func(x, y float64) (float64, float64) { return x*x - 1.0, 0.5 - y })
+	sk, err := vf.Skeleton(nil, 1000)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	saddle, sink := -1, -1
+	for i, c := range sk.Points {
+		switch {
+		case c.Kind == Saddle && math.Hypot(c.X-1.0, c.Y-0.5) < 0.05:
+			saddle = i
+		case c.Kind == Sink && math.Hypot(c.X+1.0, c.Y-0.5) < 0.05:
+			sink = i
+		}
+	}
+	if len(sk.Points) != 2 || saddle < 0 || sink < 0 {
+		t.Fatalf("got critical points %v", sk.Points)
+	}
+	if len(sk.Separatrices) != 4 {
+		t.Fatalf("got %d separatrices, want 4", len(sk.Separatrices))
+	}
+	for k, s := range sk.Separatrices {
+		if s.From != saddle || len(s.Points) < 3 {
+			t.Errorf("separatrix %d starts from %d with %d points", k, s.From, len(s.Points))
+			continue
+		}
+		o, p := s.Points[0], s.Points[1]
+		switch {
+		case s.Outgoing && p.X < o.X: // 向左离开鞍点
+			if s.End != EndAtCriticalPoint || s.To != sink {
+				t.Errorf("separatrix %d ends at %v %d, want the sink", k, s.End, s.To)
+			}
+		case s.End != EndAtBoundary || s.To != -1:
+			t.Errorf("separatrix %d ends at %v %d, want the boundary", k, s.End, s.To)
+		}
+		if !s.Outgoing && math.Abs(p.X-o.X) > math.Abs(p.Y-o.Y) {
+			t.Errorf("incoming separatrix %d leaves the saddle towards %v", k, p)
+		}
+	}
+	if es := sk.Edges(sink); len(es) != 1 {
+		t.Errorf("got %d edges of the sink, want 1", len(es))
+	}
+}
+
+// TestHomoclinicSkeleton 检查哈密顿向量场 v = (y, x - x^3) 的骨架: 原点处的鞍点的 4 条分界线构成两个同宿轨道,
+// 分别包围 (1, 0) 和 (-1, 0) 处的中心, 因而每条分界线都回到鞍点本身.
+func TestHomoclinicSkeleton(t *testing.T) {
+	vf := newSampledVectorField(t, 2.0, 1.5, 0.1, func(x, y float64) (float64, float64) { return y, x - x*x*x })
+	sk, err := vf.Skeleton(nil, 2000)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	var kinds []CriticalPointType
+	for _, c := range sk.Points {
+		kinds = append(kinds, c.Kind)
+	}
+	if len(sk.Separatrices) != 4 {
+		t.Fatalf("got %d separatrices of critical points %v, want 4", len(sk.Separatrices), kinds)
+	}
+	for k, s := range sk.Separatrices {
+		if s.End != EndAtCriticalPoint || s.To != s.From {
+			t.Errorf("separatrix %d ends at %v %d", k, s.End, s.To)
+		}
+	}
+}
+
+// TestTraceClosedOrbit 检查由极限环 r = 1 以内出发的分界线盘旋到极限环上后, 被判断为闭合.
+func TestTraceClosedOrbit(t *testing.T) {
+	slope := func(x, y float64) (float64, error) {
+		k := 1.0 - x*x - y*y
+		return (x + y*k) / (-y + x*k), nil
+	}
+	nodes := []geom.Point{{X: 0.5, Y: 0.0}}
+	ps, end, to := traceSeparatrix(slope, nodes, 0, vector.New(0.0, 1.0), 0.1, nil, 20000)
+	if end != EndAtClosedOrbit || to != -1 {
+		t.Fatalf("the separatrix ends at %v %d after %d points", end, to, len(ps))
+	}
+	if q := ps[len(ps)-1]; math.Abs(math.Hypot(q.X, q.Y)-1.0) > 0.01 {
+		t.Errorf("the separatrix closes at %v, off the limit cycle", q)
+	}
+}
@@ -0,0 +1,321 @@
+package field
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Role 表示数据表中的一列数据在场中所充当的角色, 如坐标, 张量分量, 向量分量或标量值.
+type Role int
+
+// RoleX, RoleY 等是数据表中各列可以充当的角色.
+const (
+	RoleX Role = iota
+	RoleY
+	RoleXX
+	RoleYY
+	RoleXY
+	RoleVx
+	RoleVy
+	RoleV
+)
+
+// roleNames 是各个角色的名称, 其顺序与角色常量的顺序相同.
+var roleNames = []string{"x", "y", "xx", "yy", "xy", "vx", "vy", "v"}
+
+// String 方法返回角色的名称.
+func (r Role) String() string {
+	if r < 0 || int(r) >= len(roleNames) {
+		return "Role(" + strconv.Itoa(int(r)) + ")"
+	}
+	return roleNames[r]
+}
+
+// Columns 将各个角色映射到数据表中的列. 列可以用表头中的列名指定(不区分大小写),
+// 也可以用从 1 开始的列序号指定. 若给定的字符串与表头中的某个列名相同, 则优先按列名处理.
+// 数据表中未被映射的列将被忽略.
+type Columns map[Role]string
+
+// ParseColumns 解析形如 "x=X, y=Y, xx=S11, yy=S22, xy=S12" 的列映射字符串.
+// 等号左侧为角色名称(x, y, xx, yy, xy, vx, vy 或 v), 右侧为列名或列序号.
+func ParseColumns(s string) (Columns, error) {
+	cols := Columns{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[1]) == "" {
+			return nil, fmt.Errorf("invalid column mapping %q", item)
+		}
+		name := strings.ToLower(strings.TrimSpace(kv[0]))
+		role := Role(-1)
+		for i, rn := range roleNames {
+			if rn == name {
+				role = Role(i)
+			}
+		}
+		if role < 0 {
+			return nil, fmt.Errorf("unknown column role %q", name)
+		}
+		if _, ok := cols[role]; ok {
+			return nil, fmt.Errorf("column role %q is mapped more than once", name)
+		}
+		cols[role] = strings.TrimSpace(kv[1])
+	}
+	if len(cols) == 0 {
+		return nil, errors.New("no column mapping given")
+	}
+	return cols, nil
+}
+
+// LineError 表示数据表中某一行数据的错误.
+type LineError struct {
+	Line int // 从 1 开始的行号
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Table 是从以分隔符分隔的数据文本中读出的一个数据表. 其中仅包含由 Columns 所映射的各列数据.
+type Table struct {
+	// Header 为表头中的各个列名, 若数据文本中不包含表头, 则为 nil.
+	Header []string
+	// Roles 为数据表中包含的各个角色, 按角色常量的顺序排列.
+	Roles []Role
+	// Rows 为数据表中的各行数据, 每行中的各个值与 Roles 中的角色一一对应.
+	Rows [][]float64
+	// Rejected 为因数据缺失或无法解析为数字而被舍弃的行, 以及表头之前被跳过的标题等说明行.
+	Rejected []*LineError
+
+	index     []int // index[i] 是 Roles[i] 在数据文本中的列序号(从 0 开始)
+	headerErr error // 最近一个被跳过的说明行无法作为表头的原因
+}
+
+// ReadTable 读取一个以分隔符分隔的数据文本, 并按 cols 提取其中的各列数据.
+// 数据之前包含不能解析为数字的字段的行被当作表头的候选: 第一个能够确定 cols 中所有列的行被当作表头,
+// 在此之前的行(如有限元软件导出的报表开头的标题等说明行)被跳过, 并记录在 Rejected 中. 以 # 开头的行被当作注释而忽略.
+// 同一行中的字段以逗号(,)分隔; 若不含逗号, 则以水平制表符(\t)分隔; 若也不含制表符, 则以空白字符分隔.
+// 映射的列中含有缺失值或无法解析为数字的行将被舍弃, 并记录在返回的 Table 的 Rejected 中.
+func ReadTable(input []byte, cols Columns) (t *Table, err error) {
+	return ReadTableFrom(bytes.NewReader(input), cols)
+}
+
+// ReadTableFrom 从 r 中逐行读取一个以分隔符分隔的数据文本, 并按 cols 提取其中的各列数据.
+// 文本的格式与 ReadTable 相同. 该函数并不将整个文本同时读入内存, 适用于很大的数据文件.
+func ReadTableFrom(r io.Reader, cols Columns) (t *Table, err error) {
+	t = &Table{}
+	for role := range cols {
+		t.Roles = append(t.Roles, role)
+	}
+	sort.Slice(t.Roles, func(i, j int) bool { return t.Roles[i] < t.Roles[j] })
+	sc := newLineScanner(r)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		if err := t.addLine(sc.Text(), lineNum, cols); err != nil {
+			return nil, err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if t.index == nil {
+		if t.headerErr != nil {
+			return nil, t.headerErr
+		}
+		return nil, errors.New("no data found in the table")
+	}
+	return t, nil
+}
+
+// addLine 方法解析数据文本中的一行. 在确定表头以及各角色所对应的列之前, 无法作为表头的说明行被跳过.
+func (t *Table) addLine(line string, lineNum int, cols Columns) error {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+	fields := splitFields(line)
+	if t.index == nil {
+		if allNumeric(fields) {
+			// 数据已经开始, 此前的说明行都不能作为表头
+			if t.headerErr != nil {
+				return t.headerErr
+			}
+			t.Header = nil
+		} else {
+			t.Header = fields
+		}
+		index, err := t.resolve(cols, len(fields))
+		if err != nil {
+			if t.Header == nil {
+				return err
+			}
+			t.Header, t.headerErr = nil, err
+			t.Rejected = append(t.Rejected, &LineError{Line: lineNum, Err: fmt.Errorf("skipped before the header: %v", err)})
+			return nil
+		}
+		t.index = index
+		if t.Header != nil {
+			return nil
+		}
+	}
+	row := make([]float64, len(t.index))
+	for i, ci := range t.index {
+		if ci >= len(fields) {
+			t.Rejected = append(t.Rejected, &LineError{Line: lineNum,
+				Err: fmt.Errorf("missing column %d for %q", ci+1, t.Roles[i])})
+			return nil
+		}
+		v, err := strconv.ParseFloat(fields[ci], 64)
+		if err != nil {
+			t.Rejected = append(t.Rejected, &LineError{Line: lineNum,
+				Err: fmt.Errorf("invalid number %q for %q", fields[ci], t.Roles[i])})
+			return nil
+		}
+		row[i] = v
+	}
+	t.Rows = append(t.Rows, row)
+	return nil
+}
+
+// resolve 方法根据表头确定 cols 中各个角色所对应的列序号. n 为第一个非空行中的字段个数.
+func (t *Table) resolve(cols Columns, n int) ([]int, error) {
+	index := make([]int, len(t.Roles))
+	for i, role := range t.Roles {
+		name := cols[role]
+		index[i] = -1
+		for ci, h := range t.Header {
+			if strings.EqualFold(h, name) {
+				index[i] = ci
+				break
+			}
+		}
+		if index[i] >= 0 {
+			continue
+		}
+		ci, err := strconv.Atoi(name)
+		if err != nil {
+			if t.Header == nil {
+				return nil, fmt.Errorf("column %q for %q can not be found: the table has no header", name, role)
+			}
+			return nil, fmt.Errorf("column %q for %q can not be found in the header", name, role)
+		}
+		if ci < 1 || (t.Header != nil && ci > n) {
+			return nil, fmt.Errorf("column number %d for %q is out of range", ci, role)
+		}
+		index[i] = ci - 1
+	}
+	return index, nil
+}
+
+// Col 方法返回角色 role 在 Roles 中的位置, 若数据表中不包含该角色, 则返回 -1.
+func (t *Table) Col(role Role) int {
+	for i, r := range t.Roles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// cols 方法返回 roles 中各个角色在 Roles 中的位置. 若数据表缺少其中某个角色, 则返回一个错误.
+func (t *Table) cols(roles ...Role) ([]int, error) {
+	cs := make([]int, len(roles))
+	for i, role := range roles {
+		if cs[i] = t.Col(role); cs[i] < 0 {
+			return nil, fmt.Errorf("no column is mapped for %q", role)
+		}
+	}
+	return cs, nil
+}
+
+// ScalarField 方法由数据表中的 X, Y 和 V 列, 按参数 o 生成一个标量场. 若 o 为 nil, 则使用默认参数.
+func (t *Table) ScalarField(o *Options) (*ScalarField, error) {
+	o, err := o.resolve()
+	if err != nil {
+		return nil, err
+	}
+	cs, err := t.cols(RoleX, RoleY, RoleV)
+	if err != nil {
+		return nil, err
+	}
+	var data []*ScalarQty
+	for _, r := range t.Rows {
+		if !o.DiscardZeroQty || !isZeroQty(r[cs[2]]) {
+			data = append(data, NewScalarQty(r[cs[0]], r[cs[1]], r[cs[2]]))
+		}
+	}
+	return NewScalarField(data, o)
+}
+
+// VectorField 方法由数据表中的 X, Y, Vx 和 Vy 列, 按参数 o 生成一个向量场. 若 o 为 nil, 则使用默认参数.
+func (t *Table) VectorField(o *Options) (*VectorField, error) {
+	o, err := o.resolve()
+	if err != nil {
+		return nil, err
+	}
+	cs, err := t.cols(RoleX, RoleY, RoleVx, RoleVy)
+	if err != nil {
+		return nil, err
+	}
+	var data []*VectorQty
+	for _, r := range t.Rows {
+		if !o.DiscardZeroQty || !isZeroQty(r[cs[2]], r[cs[3]]) {
+			data = append(data, NewVectorQty(r[cs[0]], r[cs[1]], r[cs[2]], r[cs[3]]))
+		}
+	}
+	return NewVectorField(data, o)
+}
+
+// TensorField 方法由数据表中的 X, Y, XX, YY 和 XY 列, 按参数 o 生成一个张量场. 若 o 为 nil, 则使用默认参数.
+func (t *Table) TensorField(o *Options) (*TensorField, error) {
+	o, err := o.resolve()
+	if err != nil {
+		return nil, err
+	}
+	cs, err := t.cols(RoleX, RoleY, RoleXX, RoleYY, RoleXY)
+	if err != nil {
+		return nil, err
+	}
+	var data []*TensorQty
+	for _, r := range t.Rows {
+		if !o.DiscardZeroQty || !isZeroQty(r[cs[2]], r[cs[3]], r[cs[4]]) {
+			data = append(data, NewTensorQty(r[cs[0]], r[cs[1]], r[cs[2]], r[cs[3]], r[cs[4]]))
+		}
+	}
+	return NewTensorField(data, o)
+}
+
+// splitFields 将一行文本分割为多个字段, 并去除各字段两端的空白字符.
+func splitFields(line string) []string {
+	var fields []string
+	switch {
+	case strings.Contains(line, ","):
+		fields = strings.Split(line, ",")
+	case strings.Contains(line, "\t"):
+		fields = strings.Split(line, "\t")
+	default:
+		return strings.Fields(line)
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+// allNumeric 判断 fields 中的所有字段是否都能解析为数字.
+func allNumeric(fields []string) bool {
+	for _, f := range fields {
+		if _, err := strconv.ParseFloat(f, 64); err != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,102 @@
+package field_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"stj/fieldline/field"
+)
+
+func TestParseColumns(t *testing.T) {
+	cols, err := field.ParseColumns("x=X, y = 3, XX=S11 ,yy=S22,xy=S12")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	want := field.Columns{field.RoleX: "X", field.RoleY: "3", field.RoleXX: "S11", field.RoleYY: "S22", field.RoleXY: "S12"}
+	if len(cols) != len(want) {
+		t.Fatalf("got %d columns, want %d", len(cols), len(want))
+	}
+	for r, c := range want {
+		if cols[r] != c {
+			t.Errorf("column of %v is %q, want %q", r, cols[r], c)
+		}
+	}
+	for _, s := range []string{"", "x", "z=1", "x=1,x=2", "x="} {
+		if _, err := field.ParseColumns(s); err == nil {
+			t.Errorf("func ParseColumns should reject %q", s)
+		}
+	}
+}
+
+func TestReadTable(t *testing.T) {
+	input := []byte(`Node, Elem, S12, X, Y, S11, S22, Type
+1, 10, 0.5, 0.0, 0.0, 1.0, 2.0, CPS4
+2, 10, 0.6, 1.0, 0.0, 1.1, 2.1, CPS4
+
+# comment
+3, 11, 0.7, 0.0, 1.0, n/a, 2.2, CPS4
+4, 11, 0.8, 1.0, 1.0, 1.3
+5, 11, 0.9, 0.5, 0.5, 1.4, 2.4, CPS4
+`)
+	cols := field.Columns{field.RoleX: "x", field.RoleY: "Y", field.RoleXX: "S11", field.RoleYY: "7", field.RoleXY: "S12"}
+	tb, err := field.ReadTable(input, cols)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(tb.Header) != 8 || tb.Header[3] != "X" {
+		t.Errorf("wrong header: %v", tb.Header)
+	}
+	if len(tb.Rows) != 3 {
+		t.Fatalf("got %d rows, want 3", len(tb.Rows))
+	}
+	if len(tb.Rejected) != 2 || tb.Rejected[0].Line != 6 || tb.Rejected[1].Line != 7 {
+		t.Errorf("wrong rejected lines: %v", tb.Rejected)
+	}
+	row := tb.Rows[2]
+	if row[tb.Col(field.RoleX)] != 0.5 || row[tb.Col(field.RoleYY)] != 2.4 || row[tb.Col(field.RoleXY)] != 0.9 {
+		t.Errorf("wrong row data: %v", row)
+	}
+	if _, err := tb.TensorField(nil); err != nil {
+		t.Error(err.Error())
+	}
+	if _, err := tb.VectorField(nil); err == nil {
+		t.Error("a table without vector columns should not generate a vector field")
+	}
+
+	if _, err := field.ReadTable(input, field.Columns{field.RoleX: "Z"}); err == nil {
+		t.Error("func ReadTable should fail if a column can not be found")
+	}
+	// 表头之前的标题等说明行被跳过
+	report := []byte("Stress report\nStep 1, Increment 5: time 1.0\n\n" + string(input))
+	tb, err = field.ReadTable(report, cols)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(tb.Header) != 8 || len(tb.Rows) != 3 {
+		t.Errorf("got header %v and %d rows after a preamble", tb.Header, len(tb.Rows))
+	}
+	if len(tb.Rejected) != 4 || tb.Rejected[0].Line != 1 || tb.Rejected[1].Line != 2 {
+		t.Errorf("wrong rejected lines after a preamble: %v", tb.Rejected)
+	}
+	if _, err := field.ReadTable([]byte("1 2 3\n"), field.Columns{field.RoleX: "X"}); err == nil {
+		t.Error("func ReadTable should fail if a column name is given without header")
+	}
+}
+
+func TestReadTableFile(t *testing.T) {
+	input, err := ioutil.ReadFile("../fielddata/stress2.dat")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	cols, _ := field.ParseColumns("x=x,y=y,xx=xx,yy=yy,xy=xy")
+	tb, err := field.ReadTable(input, cols)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(tb.Rejected) != 0 {
+		t.Errorf("unexpected rejected lines: %v", tb.Rejected)
+	}
+	if tf, err := tb.TensorField(nil); err != nil || tf == nil {
+		t.Errorf("can not generate tensor field: %v", err)
+	}
+}
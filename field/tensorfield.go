@@ -1,12 +1,15 @@
 package field
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
 	"math"
 
 	"stj/fieldline/geom"
 	"stj/fieldline/grid"
-	"stj/fieldline/num"
 	"stj/fieldline/tensor"
 )
 
@@ -58,6 +61,7 @@ type TensorField struct {
 	data    []*TensorQty // 初始给定的无规则分布的离散数据
 	nodes   []*TensorQty // 网格点上的数据
 	aligned bool
+	mode    TensorIntrplMode // 插值方式
 }
 
 // Aligned 判断张量场中各个特征值, 流线函数的导数是否已进行过对齐处理.
@@ -68,200 +72,159 @@ func (tf *TensorField) Aligned(t *TensorQty) bool {
 }
 
 // idwTensorQty 根据张量场中原始无规则离散分布的 data 数据, 利反距离加权插值(IDW)方法获得任一点的张量场量.
+// 张量的各个分量以相同的权重分别插值; 若插值方式不是 ComponentMode, 则以这些权重按插值方式对各张量进行平均.
 func (tf *TensorField) idwTensorQty(x, y float64) (tq *TensorQty, err error) {
-	xi, yi, idx, _ := tf.grid.CellPosIdx(x, y)
-	for layer := MinIntrplLayer; layer <= MaxIntrplLayer; layer++ {
-		cells := tf.grid.NearCellsAlt(xi, yi, idx, layer)
-		qtyIdxes := make([]int, 0, int(1.25*grid.AvgQtyNumPerCell*float64(len(cells))))
-		for i := 0; i < len(cells); i++ {
-			qtyIdxes = append(qtyIdxes, cells[i].QtyIdxes...)
+	ns := tf.idwNeighbors(x, y, func(i int) (x, y float64) {
+		return tf.data[i].X, tf.data[i].Y
+	})
+	if ns == nil {
+		if !tf.opts.AssignZeroOnIntrplFail {
+			return nil, errors.New("no known point existing around the given point")
 		}
-		num := len(qtyIdxes)
-		/*
-			cond1 := layer == MinIntrplLayer && num < MinIntrplQtyNum                           // 继续
-			cond2 := layer == MinIntrplLayer && num >= MinIntrplQtyNum && num < MaxIntrplQtyNum // 继续
-			cond3 := layer == MinIntrplLayer && num >= MaxIntrplQtyNum                          // 成功
-
-			cond4 := layer > MinIntrplLayer && layer < MaxIntrplLayer && num < MinIntrplQtyNum  // 继续
-			cond5 := layer > MinIntrplLayer && layer < MaxIntrplLayer && num >= MinIntrplQtyNum && num < MaxIntrplQtyNum // 成功
-			cond6 := layer > MinIntrplLayer && layer < MaxIntrplLayer && num >= MaxIntrplQtyNum // 成功
-
-			cond7 := layer >= MaxIntrplLayer && num < MinIntrplQtyNum                          // 失败
-			cond8 := layer >= MaxIntrplLayer && num >= MinIntrplQtyNum && num < MaxIntrplQtyNum // 成功
-			cond9 := layer >= MaxIntrplLayer && num >= MaxIntrplQtyNum                         // 成功
-		*/
-		// 以下 2 个条件根据注释中的条件合并而来
-		fail := layer >= MaxIntrplLayer && num < MinIntrplQtyNum
-		succ := num >= MaxIntrplQtyNum || ((num >= MinIntrplQtyNum && num < MaxIntrplQtyNum) && layer > MinIntrplLayer)
-		if fail {
-			if !AssignZeroOnIntrplFail {
-				return nil, errors.New("no known point existing around the given point")
-			}
-			return NewTensorQty(x, y, 0.0, 0.0, 0.0), nil
-		}
-		if succ {
-			return tf.idwIntrplTenQty(qtyIdxes, x, y)
-		}
-		// 不满足 fail 或 succ 条件, 就只能满足继续条件了, 这是加大一层 layer 继续查找.
+		return NewTensorQty(x, y, 0.0, 0.0, 0.0), nil
 	}
-	return nil, errors.New("no quantities found around the given point")
-}
-
-// idwIntrplTenQty 利用 idwIntrpl 进行插值, 并组合获得一个张量场量.
-func (tf *TensorField) idwIntrplTenQty(qtyIdxes []int, x, y float64) (tq *TensorQty, err error) {
-	xx, err := tf.idwIntrpl(qtyIdxes, x, y, TXX)
-	if err != nil {
-		return nil, err
+	ws := idwWeights(ns, tf.opts.IDW.Power)
+	if tf.mode != ComponentMode {
+		return tf.blend(tf.getTensorQties(neighborIdxes(ns)), ws, x, y)
 	}
-	yy, _ := tf.idwIntrpl(qtyIdxes, x, y, TYY)
-	xy, _ := tf.idwIntrpl(qtyIdxes, x, y, TXY)
-	tq = NewTensorQty(x, y, xx, yy, xy)
-	return tq, nil
-}
-
-// idwIntrpl 利用 IDW 方法进行插值, 获得一个浮点数.
-func (tf *TensorField) idwIntrpl(qtyIdxes []int, x, y float64, compType int) (v float64, err error) {
-	ss := make([]*ScalarQty, len(qtyIdxes))
-	for i := 0; i < len(qtyIdxes); i++ {
-		v := 0.0
-		switch compType {
-		case TXX:
-			v = tf.data[qtyIdxes[i]].XX
-		case TYY:
-			v = tf.data[qtyIdxes[i]].YY
-		case TXY:
-			v = tf.data[qtyIdxes[i]].XY
-		case TEV1:
-			v = tf.data[qtyIdxes[i]].EV1
-		case TEV2:
-			v = tf.data[qtyIdxes[i]].EV2
-		case TED1:
-			v = tf.data[qtyIdxes[i]].ED1
-		case TED2:
-			v = tf.data[qtyIdxes[i]].ED2
-		}
-		ss[i] = &ScalarQty{X: tf.data[qtyIdxes[i]].X, Y: tf.data[qtyIdxes[i]].Y, V: v}
+	var xx, yy, xy, sw float64
+	for i, w := range ws {
+		t := tf.data[ns[i].Idx]
+		xx += w * t.XX
+		yy += w * t.YY
+		xy += w * t.XY
+		sw += w
 	}
-	return IDW(ss, x, y, DefaultIDWPower)
+	return NewTensorQty(x, y, xx/sw, yy/sw, xy/sw), nil
 }
 
 // XX 方法通过空间插值方法获得张量场内任意点 (x, y) 处的 XX 值.
 func (tf *TensorField) XX(x, y float64) (v float64, err error) {
-	cell, err := tf.grid.Cell(x, y)
-	if err != nil {
-		return 0.0, err
-	}
-	nodeIdxes, err := tf.grid.NodeIdxes(x, y)
-	if err != nil {
-		return 0.0, err
-	}
-	ll := tf.nodes[nodeIdxes[0]].XX
-	ul := tf.nodes[nodeIdxes[1]].XX
-	lu := tf.nodes[nodeIdxes[2]].XX
-	uu := tf.nodes[nodeIdxes[3]].XX
-	return cell.Value(x, y, ll, ul, lu, uu), nil
+	return tf.compValue(x, y, TXX)
 }
 
 // YY 方法通过空间插值方法获得张量场内任意点 (x, y) 处的 YY 值.
 func (tf *TensorField) YY(x, y float64) (v float64, err error) {
-	cell, err := tf.grid.Cell(x, y)
-	if err != nil {
-		return 0.0, err
-	}
-	nodeIdxes, err := tf.grid.NodeIdxes(x, y)
-	if err != nil {
-		return 0.0, err
-	}
-	ll := tf.nodes[nodeIdxes[0]].YY
-	ul := tf.nodes[nodeIdxes[1]].YY
-	lu := tf.nodes[nodeIdxes[2]].YY
-	uu := tf.nodes[nodeIdxes[3]].YY
-	return cell.Value(x, y, ll, ul, lu, uu), nil
+	return tf.compValue(x, y, TYY)
 }
 
 // XY 方法通过空间插值方法获得张量场内任意点 (x, y) 处的 XY 值.
 func (tf *TensorField) XY(x, y float64) (v float64, err error) {
-	cell, err := tf.grid.Cell(x, y)
-	if err != nil {
-		return 0.0, err
-	}
-	nodeIdxes, err := tf.grid.NodeIdxes(x, y)
-	if err != nil {
-		return 0.0, err
-	}
-	ll := tf.nodes[nodeIdxes[0]].XY
-	ul := tf.nodes[nodeIdxes[1]].XY
-	lu := tf.nodes[nodeIdxes[2]].XY
-	uu := tf.nodes[nodeIdxes[3]].XY
-	return cell.Value(x, y, ll, ul, lu, uu), nil
+	return tf.compValue(x, y, TXY)
 }
 
-// EV1 方法通过空间插值方法获得张量场内任意点 (x, y) 处的特征值 EV1.
+// EV1 方法通过空间插值方法获得张量场内任意点 (x, y) 处的 特征值 EV1.
 func (tf *TensorField) EV1(x, y float64) (v float64, err error) {
-	cell, err := tf.grid.Cell(x, y)
-	if err != nil {
-		return 0.0, err
-	}
-	nodeIdxes, err := tf.grid.NodeIdxes(x, y)
-	if err != nil {
-		return 0.0, err
-	}
-	ll := tf.nodes[nodeIdxes[0]].EV1
-	ul := tf.nodes[nodeIdxes[1]].EV1
-	lu := tf.nodes[nodeIdxes[2]].EV1
-	uu := tf.nodes[nodeIdxes[3]].EV1
-	return cell.Value(x, y, ll, ul, lu, uu), nil
+	return tf.compValue(x, y, TEV1)
 }
 
-// EV2 方法通过空间插值方法获得张量场内任意点 (x, y) 处的特征值 EV2.
+// EV2 方法通过空间插值方法获得张量场内任意点 (x, y) 处的 特征值 EV2.
 func (tf *TensorField) EV2(x, y float64) (v float64, err error) {
-	cell, err := tf.grid.Cell(x, y)
-	if err != nil {
-		return 0.0, err
-	}
-	nodeIdxes, err := tf.grid.NodeIdxes(x, y)
-	if err != nil {
-		return 0.0, err
-	}
-	ll := tf.nodes[nodeIdxes[0]].EV2
-	ul := tf.nodes[nodeIdxes[1]].EV2
-	lu := tf.nodes[nodeIdxes[2]].EV2
-	uu := tf.nodes[nodeIdxes[3]].EV2
-	return cell.Value(x, y, ll, ul, lu, uu), nil
+	return tf.compValue(x, y, TEV2)
 }
 
-// ED1 方法通过空间插值方法获得张量场内任意点 (x, y) 处的特征向量方向角 ED1.
+// ED1 方法通过空间插值方法获得张量场内任意点 (x, y) 处的 特征向量方向角 ED1.
 func (tf *TensorField) ED1(x, y float64) (v float64, err error) {
-	cell, err := tf.grid.Cell(x, y)
-	if err != nil {
+	return tf.compValue(x, y, TED1)
+}
+
+// ED2 方法通过空间插值方法获得张量场内任意点 (x, y) 处的 特征向量方向角 ED2.
+func (tf *TensorField) ED2(x, y float64) (v float64, err error) {
+	return tf.compValue(x, y, TED2)
+}
+
+// compValue 方法通过空间插值方法获得张量场内任意点 (x, y) 处张量场量的某个分量, comp 的值只应该是
+// TXX, TYY, TXY, TEV1, TEV2, TED1 或 TED2. 若要求同一点处的多个分量, 用 QtyAt 方法只需查找一次单元格.
+func (tf *TensorField) compValue(x, y float64, comp int) (float64, error) {
+	if err := tf.checkDomain(x, y); err != nil {
 		return 0.0, err
 	}
-	nodeIdxes, err := tf.grid.NodeIdxes(x, y)
+	if tf.mode != ComponentMode {
+		return tf.blendedValue(x, y, comp)
+	}
+	if tf.mesh != nil {
+		return tf.meshValue(x, y, comp)
+	}
+	if len(tf.nodes) == 0 {
+		return 0.0, errors.New("the nodes of the tensor field have not been generated")
+	}
+	cell, nodeIdxes, err := tf.grid.CellNodeIdxes(x, y)
 	if err != nil {
 		return 0.0, err
 	}
-	ll := tf.nodes[nodeIdxes[0]].ED1
-	ul := tf.nodes[nodeIdxes[1]].ED1
-	lu := tf.nodes[nodeIdxes[2]].ED1
-	uu := tf.nodes[nodeIdxes[3]].ED1
-	return cell.Value(x, y, ll, ul, lu, uu), nil
+	var vs [4]float64
+	for k, ni := range nodeIdxes {
+		if vs[k], err = tf.nodes[ni].comp(comp); err != nil {
+			return 0.0, err
+		}
+	}
+	return cell.Value(x, y, vs[0], vs[1], vs[2], vs[3]), nil
 }
 
-// ED2 方法通过空间插值方法获得张量场内任意点 (x, y) 处的特征向量方向角 ED2.
-func (tf *TensorField) ED2(x, y float64) (v float64, err error) {
-	cell, err := tf.grid.Cell(x, y)
+// QtyAt 方法通过空间插值方法获得张量场内任意点 (x, y) 处的张量场量, 其各个分量与 XX, EV1, ED1 等方法的返回值相同,
+// 但只需查找一次单元格. 若插值方式不是 ComponentMode, 则返回的张量与其特征值和方向角是一致的; 否则各个分量分别插值,
+// 因而特征值和方向角不一定由张量求得.
+func (tf *TensorField) QtyAt(x, y float64) (*TensorQty, error) {
+	if err := tf.checkDomain(x, y); err != nil {
+		return nil, err
+	}
+	if tf.mode != ComponentMode {
+		return tf.blendedTensorQty(x, y)
+	}
+	if tf.mesh != nil {
+		ei, w, err := tf.mesh.Locate(x, y)
+		if err != nil {
+			return nil, err
+		}
+		ts := make([]*TensorQty, len(w))
+		for k, ni := range tf.mesh.Elements[ei] {
+			ts[k] = tf.data[ni]
+		}
+		return weightedQty(ts, w, x, y), nil
+	}
+	if len(tf.nodes) == 0 {
+		return nil, errors.New("the nodes of the tensor field have not been generated")
+	}
+	cell, nodeIdxes, err := tf.grid.CellNodeIdxes(x, y)
 	if err != nil {
-		return 0.0, err
+		return nil, err
 	}
-	nodeIdxes, err := tf.grid.NodeIdxes(x, y)
+	w := cell.Weights(x, y)
+	ts := []*TensorQty{tf.nodes[nodeIdxes[0]], tf.nodes[nodeIdxes[1]], tf.nodes[nodeIdxes[2]], tf.nodes[nodeIdxes[3]]}
+	return weightedQty(ts, w[:], x, y), nil
+}
+
+// weightedQty 函数求张量 ts 以 ws 为权在点 (x, y) 处的加权和, 张量的各个分量, 特征值和方向角分别求和.
+func weightedQty(ts []*TensorQty, ws []float64, x, y float64) *TensorQty {
+	t := &TensorQty{}
+	t.X, t.Y = x, y
+	for k, w := range ws {
+		t.XX += w * ts[k].XX
+		t.YY += w * ts[k].YY
+		t.XY += w * ts[k].XY
+		t.EV1 += w * ts[k].EV1
+		t.EV2 += w * ts[k].EV2
+		t.ED1 += w * ts[k].ED1
+		t.ED2 += w * ts[k].ED2
+	}
+	_, _, _, _, t.Singular = t.EigValDir()
+	return t
+}
+
+// QtiesAt 方法求张量场内各点 ps 处的张量场量, 它与对每个点调用 QtyAt 方法的结果相同, 但各点在多个 goroutine 中
+// 同时求值, goroutine 的个数由场的参数 Workers 确定. 若某点求值失败, 则返回的错误中指明该点在 ps 中的索引.
+func (tf *TensorField) QtiesAt(ps []geom.Point) ([]*TensorQty, error) {
+	ts := make([]*TensorQty, len(ps))
+	err := parallel(context.Background(), len(ps), tf.opts.Workers, func(i int) (err error) {
+		if ts[i], err = tf.QtyAt(ps[i].X, ps[i].Y); err != nil {
+			return fmt.Errorf("point %d: %v", i, err)
+		}
+		return nil
+	})
 	if err != nil {
-		return 0.0, err
+		return nil, err
 	}
-	ll := tf.nodes[nodeIdxes[0]].ED2
-	ul := tf.nodes[nodeIdxes[1]].ED2
-	lu := tf.nodes[nodeIdxes[2]].ED2
-	uu := tf.nodes[nodeIdxes[3]].ED2
-	return cell.Value(x, y, ll, ul, lu, uu), nil
+	return ts, nil
 }
 
 // Near 方法返回点 (x, y) 所在的单元格, 以及与该单元格紧邻的其他 layer 层单元格中所包含的所有张量.
@@ -273,27 +236,18 @@ func (tf *TensorField) Near(x, y float64, layer int) (ts []*TensorQty, err error
 	return tf.getTensorQties(qtyIdxes), nil
 }
 
-// NearN 方法返回点 (x, y) 附近的约 n 个张量. 当场中的数据数量不足 n 个时, 则返回所有这些张量数据;
-// 反之, 返回最靠近该点的大于等于 n 个数据.
-func (tf *TensorField) NearN(x, y float64, n int) (ts []*TensorQty, err error) {
-	var qtyIdxes []int
-	if n < len(tf.data) {
-		for layer := 0; ; layer++ {
-			qtyIdxes, err = tf.grid.NearQtyIdxes(x, y, layer)
-			if err != nil {
-				return nil, err
-			}
-			if len(qtyIdxes) >= n {
-				break
-			}
-		}
-	} else {
-		qtyIdxes = make([]int, len(tf.data))
-		for i := 0; i < len(tf.data); i++ {
-			qtyIdxes[i] = i
-		}
+// NearN 方法返回距点 (x, y) 最近的 n 个张量及其到该点的距离, 按距离由近到远排列, 距离相等时按数据的顺序排列.
+// 当场中的数据不足 n 个时, 返回所有的张量. n 应大于 0.
+func (tf *TensorField) NearN(x, y float64, n int) (ts []*TensorQty, dists []float64, err error) {
+	ns, err := tf.nearest(x, y, n)
+	if err != nil {
+		return nil, nil, err
 	}
-	return tf.getTensorQties(qtyIdxes), nil
+	ts, dists = make([]*TensorQty, len(ns)), make([]float64, len(ns))
+	for i, n := range ns {
+		ts[i], dists[i] = tf.data[n.Idx], n.Dist
+	}
+	return ts, dists, nil
 }
 
 // getTensorQties 方法根据给定的张量场量索引返回一个张量场量列表.
@@ -305,9 +259,13 @@ func (tf *TensorField) getTensorQties(qtyIdxes []int) []*TensorQty {
 	return ts
 }
 
+// alignNeighborNum 为 Align 方法每次对齐时所用的数据点个数, 包括作为参照的已对齐的点.
+const alignNeighborNum = 9
+
 // Align 对张量场进行对齐处理. 使同一族流线的对应的特征值和特征向量导数在
 // TensorQty 对象中具有相同的排列位置. 在对张量场中的特征值和特征向量方向进
 // 行插值之前, 一般需要先进行 Align 处理.
+// 对齐由第一个数据点开始, 利用空间索引由已对齐的点向其最近的点逐步扩展, 因而不受数据点疏密的影响.
 func (tf *TensorField) Align() {
 	if len(tf.data) <= 1 {
 		if len(tf.data) == 1 {
@@ -317,33 +275,44 @@ func (tf *TensorField) Align() {
 		return
 	}
 	// 将第一个点的 aligned 字段设为 true, 作为后续设置的引子(参照)
-	for idx := 0; idx < len(tf.grid.Cells); idx++ {
-		if len(tf.grid.Cells[idx].QtyIdxes) != 0 {
-			//tf.data[tf.grid.cells[idx].qtyIdxes[0]].SwapEig()
-			tf.data[tf.grid.Cells[idx].QtyIdxes[0]].aligned = true
+	tf.data[0].aligned = true
+	queue := []int{0}
+	for next := 1; ; {
+		for len(queue) > 0 {
+			t := tf.data[queue[0]]
+			queue = queue[1:]
+			queue = tf.alignNear(queue, t.X, t.Y, alignNeighborNum)
+		}
+		// 其余的点与已对齐的点不相连(如相距较远的几块数据), 由下一个未对齐的点向外扩大查找的范围,
+		// 直到找到已对齐的点为止
+		for next < len(tf.data) && tf.data[next].aligned {
+			next++
+		}
+		if next == len(tf.data) {
 			break
 		}
-	}
-	for yi := 0; yi < tf.grid.CellYN; yi++ { // 逐行扫描
-		for xi := 0; xi < tf.grid.CellXN; xi++ { // 每行的中每列
-			idx := yi*tf.grid.CellXN + xi
-			// 如果点太稀疏， 在一层中也找不到 2 个点, 则加大扫描的范围(层数),
-			// 直到在一次搜索时能找到 2 个及以上点为止
-			for layer := 1; ; layer++ {
-				cells := tf.grid.NearCellsAlt(xi, yi, idx, layer)
-				qtyIdxes := make([]int, 0, int(1.25*grid.AvgQtyNumPerCell*float64(len(cells))))
-				for i := 0; i < len(cells); i++ {
-					qtyIdxes = append(qtyIdxes, cells[i].QtyIdxes...)
-				}
-				if tf.align(qtyIdxes) {
-					break // 跳出循环, 不再搜索下一层
-				}
-			}
+		for k := 2 * alignNeighborNum; len(queue) == 0; k *= 2 {
+			queue = tf.alignNear(queue, tf.data[next].X, tf.data[next].Y, k)
 		}
 	}
 	tf.aligned = true
 }
 
+// alignNear 方法对点 (x, y) 附近的 k 个点进行对齐操作, 并将新对齐的点添加到 queue 中.
+func (tf *TensorField) alignNear(queue []int, x, y float64, k int) []int {
+	idxes := neighborIdxes(tf.index.KNearest(x, y, k))
+	var fresh []int
+	for _, i := range idxes {
+		if !tf.data[i].aligned {
+			fresh = append(fresh, i)
+		}
+	}
+	if len(fresh) == 0 || !tf.align(idxes) {
+		return queue
+	}
+	return append(queue, fresh...)
+}
+
 // align 对 ID 为 qtyIdxes 的一系列张量点进行对齐操作. 只要这些点中有一个点的方向已
 // 确定(aligned = true), 就可以进行对齐. 如果成功, 则返回 true; 否则返回 false.
 // 最初以特征向量斜率(ES1 和 ES2)为依据进行对齐操作, 但当特征向量和 y 轴平行时, 斜率
@@ -377,7 +346,7 @@ func (tf *TensorField) align(qtyIdxes []int) bool {
 		id := qtyIdxes[i]
 		if !tf.data[id].aligned {
 			// 预计在待求点处的值
-			ed1, _ := IDW(ss, tf.data[id].X, tf.data[id].Y, DefaultIDWPower)
+			ed1, _ := IDW(ss, tf.data[id].X, tf.data[id].Y, tf.opts.IDW.Power)
 			// 预估的 ed1 和实际的特征向量之间的夹角不能太大, 或者说, 不能超过 PI/2.
 			if includedAngle(ed1, tf.data[id].ED1) > includedAngle(ed1, tf.data[id].ED2) {
 				tf.data[id].SwapEig()
@@ -418,27 +387,54 @@ func includedAngle(a, b float64) float64 {
 
 // GenNodes 根据张量场中无规则离散分布的张量场量数据 data, 通过反距离加权插值方法,
 // 计算各个单元格节点处的张量场量, 从而构建出可以进行双线性插值的张量场网格.
+// 若通过 SetMethod 选择了其他插值方法, 则使用该方法进行插值; 对于由有限元网格创建的张量场,
+// 节点处的张量场量由形函数插值求得. 若场设置了定义域, 则定义域以外且不与之相交的单元格的节点不进行插值, 其张量为零.
+// 各节点在多个 goroutine 中同时插值, goroutine 的个数由场的参数 Workers 确定, 其结果与逐个插值相同.
 // 该方法必须在张量场已经执行过对齐(Align) 操作之后调用.
 func (tf *TensorField) GenNodes() (err error) {
-	n := (tf.grid.NodeXN) * (tf.grid.NodeYN) // 节点总数
-	tf.nodes = make([]*TensorQty, n)
-	for i := 0; i < n; i++ {
-		xi, yi := tf.grid.NodePos(i)
-		x := float64(xi) * tf.grid.XSpan
-		y := float64(yi) * tf.grid.YSpan
-		tf.nodes[i], err = tf.idwTensorQty(x, y)
-		if err != nil {
+	return tf.GenNodesContext(context.Background())
+}
+
+// GenNodesContext 方法与 GenNodes 方法相同, 但可以通过 ctx 中途取消. 取消或插值失败时返回一个错误,
+// 且场原有的节点数据保持不变.
+func (tf *TensorField) GenNodesContext(ctx context.Context) (err error) {
+	intrpl := tf.idwTensorQty
+	switch {
+	case tf.mesh != nil:
+		intrpl = tf.meshTensorQty
+	case tf.method == RBFMethod:
+		intrpl = tf.rbfTensorQty()
+	case tf.method == KrigingMethod:
+		if intrpl, err = tf.krigTensorQty(); err != nil {
+			return err
+		}
+	case tf.usesTriangulation():
+		if intrpl, err = tf.triTensorQty(); err != nil {
 			return err
 		}
 	}
+	nodes := make([]*TensorQty, tf.grid.NodeNum)
+	err = parallel(ctx, len(nodes), tf.opts.Workers, func(i int) (err error) {
+		x, y := tf.grid.Nodes[i].X, tf.grid.Nodes[i].Y
+		if tf.grid.NodeLoc(i) == grid.Outside {
+			nodes[i] = NewTensorQty(x, y, 0.0, 0.0, 0.0)
+			return nil
+		}
+		nodes[i], err = intrpl(x, y)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	tf.nodes = nodes
 	return nil
 }
 
 // GenFieldOfEVDiff 依据张量场各个张量特征值之差的绝对值, 生成一个新的标量场.
-// 该标量场与张量场具有相同的网格(Grid).
+// 该标量场与张量场具有相同的网格(Grid)和有限元网格(Mesh).
 func (tf *TensorField) GenFieldOfEVDiff() *ScalarField {
 	df := &ScalarField{}
-	df.grid = tf.grid
+	df.baseField = tf.baseField
 	df.data = make([]*ScalarQty, len(tf.data))
 	for i := 0; i < len(df.data); i++ {
 		df.data[i] = &ScalarQty{X: tf.data[i].X, Y: tf.data[i].Y, V: math.Abs(tf.data[i].EV1 - tf.data[i].EV2)}
@@ -447,88 +443,74 @@ func (tf *TensorField) GenFieldOfEVDiff() *ScalarField {
 	return df
 }
 
+// GenFieldOfComp 依据张量场各个张量的某个分量生成一个新的标量场. comp 的值只应该是
+// TXX, TYY, TXY, TEV1, TEV2, TED1 或 TED2. 该标量场与张量场具有相同的网格(Grid)和有限元网格(Mesh).
+// 对于特征值和特征向量方向角分量, 张量场一般应先执行过对齐(Align) 操作.
+func (tf *TensorField) GenFieldOfComp(comp int) (*ScalarField, error) {
+	sf := &ScalarField{}
+	sf.baseField = tf.baseField
+	sf.data = make([]*ScalarQty, len(tf.data))
+	for i := 0; i < len(sf.data); i++ {
+		t := tf.data[i]
+		v, err := t.comp(comp)
+		if err != nil {
+			return nil, err
+		}
+		sf.data[i] = &ScalarQty{X: t.X, Y: t.Y, V: v}
+	}
+	if err := sf.GenNodes(); err != nil {
+		return nil, err
+	}
+	return sf, nil
+}
+
+// NewTensorField 根据无规则离散分布的张量场量数据 data 创建一个张量场,
+// 其网格(Grid) 的大小由数据的坐标范围和个数以及参数 o 中的网格密度自动确定. 若 o 为 nil, 则使用默认参数.
+func NewTensorField(data []*TensorQty, o *Options) (tf *TensorField, err error) {
+	if len(data) == 0 {
+		return nil, errors.New("no tensor quantity given")
+	}
+	b, err := newBaseField(len(data), func(i int) (x, y float64) {
+		return data[i].X, data[i].Y
+	}, o)
+	if err != nil {
+		return nil, err
+	}
+	tf = &TensorField{baseField: b}
+	tf.data = data
+	return tf, nil
+}
+
 // ParseTensorData 解析由数值模拟导出的张量场数据文本, 并生成一个 *TensorField.
 // 该文本的格式为以下形式:
 //
 // x, y, sxx, syy, sxy\n
 //
 // 数字之间以任意个数的逗号(,), 空格( )或水平制表符(\t)及其任意组合分割;
-// 其行尾可以为是任意个数的换行符(\n)和回车符(\r)的任意组合.
-func ParseTensorData(input []byte) (tf *TensorField, err error) {
+// 其行尾可以为是任意个数的换行符(\n)和回车符(\r)的任意组合. o 的含义与 ReadTensorData 相同.
+func ParseTensorData(input []byte, o *Options) (tf *TensorField, err error) {
+	return ReadTensorData(bytes.NewReader(input), o)
+}
+
+// ReadTensorData 从 r 中逐行读取由数值模拟导出的张量场数据文本, 并生成一个 *TensorField.
+// 文本的格式与 ParseTensorData 相同. 该函数并不将整个文本同时读入内存, 适用于很大的数据文件.
+// o 为解析数据和创建场所用的参数, 若为 nil, 则使用默认参数.
+func ReadTensorData(r io.Reader, o *Options) (tf *TensorField, err error) {
+	if o, err = o.resolve(); err != nil {
+		return nil, err
+	}
 	var data []*TensorQty
-	var beg, end int // 行首和行尾游标
-	var floats []float64
-	length := len(input)
-	for beg < length {
-		// 逐行扫描将 end 游标移至行尾
-		c := input[end]
-		for end < length && c != '\n' && c != '\r' {
-			c = input[end]
-			end++
-		}
-		line := input[beg:end] // 达到文本末尾, 包含直到文本末尾的所有字符
-		if c == '\n' || c == '\r' {
-			line = line[:len(line)-1]
-		}
-		floats = parseLineData(line)
-		if len(floats) == 5 { // 如果每行解析出的文本数不等于 5, 则并不满足张量数据需求, 直接舍弃
-			isZeroTensor := num.Equal(floats[2], 0.0) && num.Equal(floats[3], 0.0) && num.Equal(floats[4], 0.0)
-			if !DiscardZeroQty || (DiscardZeroQty && !isZeroTensor) {
-				data = append(data, NewTensorQty(floats[0], floats[1], floats[2], floats[3], floats[4]))
-			}
+	// 如果每行解析出的文本数不等于 5, 则并不满足张量数据需求, 直接舍弃
+	err = readData(r, 5, func(floats []float64) {
+		if !o.DiscardZeroQty || !isZeroQty(floats[2:]...) {
+			data = append(data, NewTensorQty(floats[0], floats[1], floats[2], floats[3], floats[4]))
 		}
-		// 跳过行尾的回车或换行, 并跳过仅包含回车或换行的空行
-		if end < length {
-			for c := input[end]; end < length && (c == '\n' || c == '\r'); {
-				c = input[end]
-				end++
-			}
-		}
-		beg = end
+	})
+	if err != nil {
+		return nil, err
 	}
 	if len(data) == 0 {
 		return nil, errors.New("no valid data parsed")
 	}
-	xmin, ymin := data[0].X, data[0].Y
-	xmax, ymax := xmin, ymin
-	for i := 0; i < len(data); i++ {
-		if data[i].X < xmin {
-			xmin = data[i].X
-		}
-		if data[i].X > xmax {
-			xmax = data[i].X
-		}
-		if data[i].Y < ymin {
-			ymin = data[i].Y
-		}
-		if data[i].Y > ymax {
-			ymax = data[i].Y
-		}
-	}
-	if xmin >= xmax || ymin >= ymax {
-		return nil, errors.New("wrong region parameters")
-	}
-	tf = &TensorField{}
-	tf.data = data
-	xl := xmax - xmin
-	yl := ymax - ymin
-	//  cellXN(xn) 和 cellYN(yn) 由以下方程组求解得出:
-	// xn*span = xl
-	// yn*span = yl
-	// xn*yn*grid.AvgQtyNumPerCell = len(data)
-	cellXN := int(math.Ceil(math.Sqrt(float64(len(data)) * xl / (grid.AvgQtyNumPerCell * yl))))
-	cellYN := int(math.Ceil(math.Sqrt(float64(len(data)) * yl / (grid.AvgQtyNumPerCell * xl))))
-	r, _ := geom.NewRect(xmin, ymin, xmax, ymax)
-	g, err := grid.New(*r, cellXN, cellYN)
-	if err != nil {
-		return nil, errors.New("error occurs when create Grid")
-	}
-	tf.grid = g
-	for i := 0; i < len(data); i++ {
-		tf.grid.Add(data[i].X, data[i].Y, i)
-	}
-	if err != nil {
-		panic(err.Error())
-	}
-	return tf, nil
+	return NewTensorField(data, o)
 }
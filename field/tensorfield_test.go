@@ -1,26 +1,21 @@
 package field
 
 import (
+	"bytes"
 	"fmt"
-	"os"
+	"io"
+	"io/ioutil"
+	"strings"
 	"testing"
 )
 
 func TestParseTensorData(t *testing.T) {
-	file, err := os.Open("../fielddata/stress.dat")
-	defer file.Close()
+	input, err := ioutil.ReadFile("../fielddata/stress.dat")
 	if err != nil {
-		t.Error("file not existing")
+		t.Fatal("file not existing")
 	}
 
-	input := make([]byte, 1000000)
-	count, err := file.Read(input)
-	if err != nil {
-		t.Error(err.Error())
-		return
-	}
-
-	tf, err := ParseTensorData(input[:count])
+	tf, err := ParseTensorData(input, nil)
 	if tf == nil {
 		t.Errorf(err.Error())
 		return
@@ -69,3 +64,47 @@ func TestParseTensorData(t *testing.T) {
 		}
 	}
 }
+
+// lineReader 是一个逐行生成张量数据文本的 io.Reader, 共生成 n 行数据.
+type lineReader struct {
+	n, i int
+	buf  bytes.Buffer
+}
+
+func (r *lineReader) Read(p []byte) (int, error) {
+	for r.buf.Len() < len(p) && r.i < r.n {
+		// 交替使用不同的行尾
+		eol := []string{"\n", "\r\n", "\r"}[r.i%3]
+		fmt.Fprintf(&r.buf, "%d, %d, %d, 2.0, 0.5%s", r.i%1000, r.i/1000, r.i, eol)
+		r.i++
+	}
+	if r.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return r.buf.Read(p)
+}
+
+func TestReadTensorData(t *testing.T) {
+	// 生成的文本大于 1,000,000 字节, 所有数据都应被读入.
+	n := 100000
+	tf, err := ReadTensorData(&lineReader{n: n}, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(tf.data) != n {
+		t.Fatalf("got %d tensors, want %d", len(tf.data), n)
+	}
+	last := tf.data[n-1]
+	if last.X != 999 || last.Y != 99 || last.XX != float64(n-1) {
+		t.Errorf("wrong last tensor: %v, %v, %v", last.X, last.Y, last.XX)
+	}
+	r := tf.Range()
+	if r.Xmax != 999 || r.Ymax != 99 {
+		t.Errorf("wrong field range: %v", *r)
+	}
+
+	tf, err = ReadTensorData(strings.NewReader("x, y, xx, yy, xy\r\n\r\n1, 2, 3, 4, 5\r7, 8, 9, 10, 11"), nil)
+	if err != nil || len(tf.data) != 2 || tf.data[1].X != 7 {
+		t.Errorf("func ReadTensorData wrong: %v", err)
+	}
+}
@@ -0,0 +1,127 @@
+package field
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"stj/fieldline/tensor"
+)
+
+// TensorIntrplMode 表示张量场对张量进行插值(加权平均)的方式.
+type TensorIntrplMode int
+
+// ComponentMode 是默认的插值方式, 它分别对 XX, YY 和 XY 分量进行插值, 而特征值和经过对齐(Align)的特征向量
+// 方向角也各自分别插值, 因此由插值所得的分量计算出的主方向与插值所得的方向角可能并不一致, 使超流线在二者相差
+// 较大的单元格中发生摆动. 其他两种方式先由各张量的特征分解求得点处的张量, 再由其计算特征值和方向角,
+// 因此张量, 特征值和方向角总是一致的.
+const (
+	ComponentMode    TensorIntrplMode = iota // 按分量插值
+	LogEuclideanMode                         // 对数欧氏插值, 用于正定或负定张量, 对于不定张量则与 EigenMode 相同
+	EigenMode                                // 分别对特征值和特征向量方向角插值
+)
+
+var tensorIntrplModeNames = []string{"component", "logeuclidean", "eigen"}
+
+func (m TensorIntrplMode) String() string {
+	if m < 0 || int(m) >= len(tensorIntrplModeNames) {
+		return fmt.Sprintf("TensorIntrplMode(%d)", int(m))
+	}
+	return tensorIntrplModeNames[m]
+}
+
+// ParseTensorIntrplMode 根据名称(不区分大小写)返回相应的张量插值方式, 名称为 TensorIntrplMode 的 String 方法的返回值.
+func ParseTensorIntrplMode(name string) (TensorIntrplMode, error) {
+	for i, n := range tensorIntrplModeNames {
+		if strings.EqualFold(name, n) {
+			return TensorIntrplMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tensor interpolation mode %q", name)
+}
+
+// IntrplMode 方法返回张量场的插值方式.
+func (tf *TensorField) IntrplMode() TensorIntrplMode {
+	return tf.mode
+}
+
+// SetIntrplMode 方法设置张量场的插值方式. 插值方式用于 IDW 方法和有限元网格的形函数插值, 以及 XX, EV1, ED1
+// 等方法由网格节点求任一点处的值; 其他插值方法(如 LinearMethod, RBFMethod)在计算网格节点处的张量场量时
+// 仍按分量插值. 设置后应重新调用 GenNodes 方法.
+func (tf *TensorField) SetIntrplMode(m TensorIntrplMode) {
+	tf.mode = m
+}
+
+// blend 方法按张量场的插值方式, 求张量 ts 以 ws 为权在点 (x, y) 处的加权平均值. 所得张量的特征值和方向角
+// 以 ts 中权重最大的张量为参照进行对齐, 即二者的 ED1 属于同一族特征向量, 且相差不超过 PI/2.
+func (tf *TensorField) blend(ts []*TensorQty, ws []float64, x, y float64) (*TensorQty, error) {
+	tt := make([]*tensor.Tensor, len(ts))
+	ref := 0
+	for i, t := range ts {
+		tt[i] = &t.Tensor
+		if ws[i] > ws[ref] {
+			ref = i
+		}
+	}
+	var m *tensor.Tensor
+	if tf.mode == LogEuclideanMode {
+		m, _ = tensor.LogEuclideanMean(tt, ws)
+	}
+	if m == nil {
+		var err error
+		if m, err = tensor.EigenMean(tt, ws); err != nil {
+			return nil, err
+		}
+	}
+	t := NewTensorQty(x, y, m.XX, m.YY, m.XY)
+	r := ts[ref]
+	if includedAngle(r.ED1, t.ED1) > includedAngle(r.ED1, t.ED2) {
+		t.SwapEig()
+	}
+	k := math.Round((r.ED1 - t.ED1) / math.Pi)
+	t.ED1 += k * math.Pi
+	t.ED2 += k * math.Pi
+	t.aligned = r.aligned
+	return t, nil
+}
+
+// blendedTensorQty 方法按张量场的插值方式求点 (x, y) 处的张量场量. 对于由有限元网格创建的张量场, 以形函数为权
+// 对单元各节点处的张量进行平均; 否则以双线性插值的系数为权对单元格四个节点处的张量进行平均.
+func (tf *TensorField) blendedTensorQty(x, y float64) (*TensorQty, error) {
+	var ts []*TensorQty
+	var ws []float64
+	if tf.mesh != nil {
+		ei, w, err := tf.mesh.Locate(x, y)
+		if err != nil {
+			return nil, err
+		}
+		for _, ni := range tf.mesh.Elements[ei] {
+			ts = append(ts, tf.data[ni])
+		}
+		ws = w
+	} else {
+		if len(tf.nodes) == 0 {
+			return nil, errors.New("the nodes of the tensor field have not been generated")
+		}
+		cell, nodeIdxes, err := tf.grid.CellNodeIdxes(x, y)
+		if err != nil {
+			return nil, err
+		}
+		w := cell.Weights(x, y)
+		ws = w[:]
+		for _, ni := range nodeIdxes {
+			ts = append(ts, tf.nodes[ni])
+		}
+	}
+	return tf.blend(ts, ws, x, y)
+}
+
+// blendedValue 方法按张量场的插值方式求点 (x, y) 处张量场量的某个分量.
+func (tf *TensorField) blendedValue(x, y float64, comp int) (float64, error) {
+	t, err := tf.blendedTensorQty(x, y)
+	if err != nil {
+		return 0.0, err
+	}
+	return t.comp(comp)
+}
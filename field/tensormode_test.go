@@ -0,0 +1,61 @@
+package field_test
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/field"
+	"stj/fieldline/tensor"
+)
+
+// TestTensorIntrplMode 检查在主方向逐渐旋转而特征值不变的张量场中, 按特征值插值所得的特征值保持不变,
+// 对数欧氏插值所得的行列式保持不变, 且二者的特征向量方向角都与插值所得的张量分量一致;
+// 而按分量插值所得的较大特征值会减小.
+func TestTensorIntrplMode(t *testing.T) {
+	var data []*field.TensorQty
+	for x := 0.0; x <= 8.0; x++ {
+		for y := 0.0; y <= 8.0; y++ {
+			s := tensor.FromEig(3, 1, 0.3*x+0.2*y)
+			data = append(data, field.NewTensorQty(x, y, s.XX, s.YY, s.XY))
+		}
+	}
+	ps := [][2]float64{{1.5, 2.5}, {3.3, 0.7}, {6.2, 5.9}, {4.5, 4.5}}
+	for _, m := range []field.TensorIntrplMode{field.ComponentMode, field.LogEuclideanMode, field.EigenMode} {
+		if got, err := field.ParseTensorIntrplMode(m.String()); err != nil || got != m {
+			t.Errorf("ParseTensorIntrplMode(%q) = %v, %v", m.String(), got, err)
+		}
+		tf, err := field.NewTensorField(data, nil)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		tf.SetIntrplMode(m)
+		tf.Align()
+		if err := tf.GenNodes(); err != nil {
+			t.Fatal(err.Error())
+		}
+		minEV1, minDet := math.Inf(1), math.Inf(1)
+		for _, p := range ps {
+			xx, _ := tf.XX(p[0], p[1])
+			yy, _ := tf.YY(p[0], p[1])
+			xy, _ := tf.XY(p[0], p[1])
+			ev1, _ := tf.EV1(p[0], p[1])
+			ed1, _ := tf.ED1(p[0], p[1])
+			minEV1 = math.Min(minEV1, ev1)
+			minDet = math.Min(minDet, xx*yy-xy*xy)
+			if m == field.ComponentMode {
+				continue
+			}
+			v1, _, d1, _, _ := tensor.New(xx, yy, xy).EigValDir()
+			if math.Abs(v1-ev1) > 1e-9 || math.Abs(math.Sin(d1-ed1)) > 1e-9 {
+				t.Errorf("%v: (%g, %g) has eigenvalue %g and direction %g, but the components give %g and %g",
+					m, p[0], p[1], ev1, ed1, v1, d1)
+			}
+		}
+		switch {
+		case m == field.ComponentMode && minEV1 > 3-1e-3,
+			m == field.LogEuclideanMode && math.Abs(minDet-3) > 1e-6,
+			m == field.EigenMode && math.Abs(minEV1-3) > 1e-6:
+			t.Errorf("%v: minimum major eigenvalue %g, minimum determinant %g", m, minEV1, minDet)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package field
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/ode"
+	"stj/fieldline/vector"
+)
+
+// TensorSeparatrix 是由张量场的退化点出发的一条分界线, 它是沿径向离开退化点的一条超流线. 三分点的每族特征向量
+// 各有 3 条分界线, 楔形点的每族特征向量各有 1 条或 3 条分界线.
+type TensorSeparatrix struct {
+	// From 和 To 分别为分界线起点和终点的退化点在 TensorSkeleton.Points 中的索引.
+	// 若分界线不终止于退化点, 则 To 为 -1.
+	From, To int
+	// Family 为分界线所沿的特征向量族, 1 为特征值较大的一族, 2 为特征值较小的一族.
+	Family int
+	End    SeparatrixEnd
+	// Points 为分界线上的点, 从退化点开始排列. 若分界线终止于退化点, 则最后一点即为该退化点.
+	Points []geom.Point
+}
+
+// TensorSkeleton 是张量场的拓扑骨架. 它是一个图: 退化点为图的节点, 分界线为连接节点的边. 两族超流线各有自己的骨架,
+// 骨架将场划分为若干区域, 每个区域内同一族的超流线有相同的走势. 在应力场中, 它给出了主应力迹线(传力路径)的整体结构.
+type TensorSkeleton struct {
+	Points       []*DegeneratePoint
+	Separatrices []*TensorSeparatrix
+}
+
+// Edges 方法返回与索引为 i 的退化点相连的各条分界线在 Separatrices 中的索引. 由退化点出发且回到该点的分界线
+// 只计一次.
+func (s *TensorSkeleton) Edges(i int) []int {
+	var es []int
+	for k, sep := range s.Separatrices {
+		if sep.From == i || sep.To == i {
+			es = append(es, k)
+		}
+	}
+	return es
+}
+
+// Family 方法返回由第 family 族(1 或 2)特征向量的分界线构成的骨架, 其节点与 s 相同.
+func (s *TensorSkeleton) Family(family int) *TensorSkeleton {
+	f := &TensorSkeleton{Points: s.Points}
+	for _, sep := range s.Separatrices {
+		if sep.Family == family {
+			f.Separatrices = append(f.Separatrices, sep)
+		}
+	}
+	return f
+}
+
+// eigSlope 方法返回第 family 族特征向量方向角的正切, 即该族超流线的斜率. 特征向量由插值所得的张量分量求得,
+// 而不是直接插值节点处的方向角, 因而在退化点附近也与 DegeneratePoints 所用的分量一致.
+func (tf *TensorField) eigSlope(family int) ode.ODE {
+	return ode.ODE(func(x, y float64) (float64, error) {
+		t, err := tf.QtyAt(x, y)
+		if err != nil {
+			return 0.0, err
+		}
+		_, _, d1, d2, _ := t.EigValDir()
+		if family == 2 {
+			d1 = d2
+		}
+		return math.Tan(d1), nil
+	})
+}
+
+// Skeleton 方法求张量场的拓扑骨架: 先求出场的所有退化点, 再由每个三分点和楔形点沿两族特征向量的各个径向方向
+// 积分出分界线. 分界线在到达另一个(或同一个)退化点, 趋近闭合的超流线或到达场的边界时终止, 最多积分 nMax 步.
+// 高阶退化点的径向方向无法由一阶偏导数确定, 不由其积分分界线. o 为积分参数, 若为 nil, 则使用默认参数.
+// 各条分界线在多个 goroutine 中同时积分, goroutine 的个数由场的参数 Workers 确定, 结果与积分的先后无关.
+// 该方法应在 GenNodes 之后调用.
+func (tf *TensorField) Skeleton(o *ode.Options, nMax int) (*TensorSkeleton, error) {
+	if nMax <= 0 {
+		return nil, errors.New("the maximum number of integration steps should be greater than zero")
+	}
+	if o != nil {
+		if err := o.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	dps, err := tf.DegeneratePoints()
+	if err != nil {
+		return nil, err
+	}
+	sk := &TensorSkeleton{Points: dps}
+	var dirs []*vector.Vector // 各条分界线离开退化点的方向
+	nodes := make([]geom.Point, len(dps))
+	for i, d := range dps {
+		nodes[i] = d.Point
+		if d.Kind == HigherOrderDegenerate {
+			continue
+		}
+		for family := 1; family <= 2; family++ {
+			for _, phi := range d.RadialDirs(family) {
+				sk.Separatrices = append(sk.Separatrices, &TensorSeparatrix{From: i, To: -1, Family: family})
+				dirs = append(dirs, vector.New(math.Cos(phi), math.Sin(phi)))
+			}
+		}
+	}
+	slopes := []ode.ODE{tf.eigSlope(1), tf.eigSlope(2)}
+	span := math.Min(tf.grid.XSpan, tf.grid.YSpan)
+	err = parallel(context.Background(), len(dirs), tf.opts.Workers, func(k int) error {
+		s := sk.Separatrices[k]
+		s.Points, s.End, s.To = traceSeparatrix(slopes[s.Family-1], nodes, s.From, dirs[k], span, o, nMax)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sk, nil
+}
@@ -0,0 +1,51 @@
+package field
+
+import (
+	"math"
+	"testing"
+)
+
+// TestTensorSkeleton 检查由 α = x^2 - 1, β = y - 0.5 确定的张量场的拓扑骨架: (-1, 0.5) 处为三分点, (1, 0.5) 处为楔形点,
+// 二者之间由直线 y = 0.5 上特征向量 2 的分界线相连, 其余分界线都终止于场的边界.
+func TestTensorSkeleton(t *testing.T) {
+	tf := newSampledTensorField(t, 2.0, 2.0, 0.2, func(x, y float64) (float64, float64) { return x*x - 1.0, y - 0.5 })
+	sk, err := tf.Skeleton(nil, 2000)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	tri, wedge := -1, -1
+	for i, d := range sk.Points {
+		switch {
+		case d.Kind == Trisector && math.Hypot(d.X+1.0, d.Y-0.5) < 0.05:
+			tri = i
+		case d.Kind == Wedge && math.Hypot(d.X-1.0, d.Y-0.5) < 0.05:
+			wedge = i
+		}
+	}
+	if len(sk.Points) != 2 || tri < 0 || wedge < 0 {
+		t.Fatalf("got degenerate points %v", sk.Points)
+	}
+	if len(sk.Separatrices) != 8 || len(sk.Edges(tri)) != 7 || len(sk.Edges(wedge)) != 3 {
+		t.Fatalf("got %d separatrices, %d edges of the trisector and %d edges of the wedge",
+			len(sk.Separatrices), len(sk.Edges(tri)), len(sk.Edges(wedge)))
+	}
+	for k, s := range sk.Separatrices {
+		switch {
+		case s.Family == 2 && (s.From == tri && s.To == wedge || s.From == wedge && s.To == tri):
+			if s.End != EndAtCriticalPoint {
+				t.Errorf("separatrix %d ends at %v", k, s.End)
+			}
+			for _, p := range s.Points {
+				if math.Abs(p.Y-0.5) > 1e-6 {
+					t.Errorf("separatrix %d between the degenerate points leaves the line y = 0.5 at %v", k, p)
+					break
+				}
+			}
+		case s.End != EndAtBoundary || s.To != -1:
+			t.Errorf("separatrix %d of family %d from %d ends at %v %d", k, s.Family, s.From, s.End, s.To)
+		}
+	}
+	if f := sk.Family(1); len(f.Separatrices) != 4 || len(f.Points) != 2 {
+		t.Errorf("got %d separatrices of the major eigenvectors, want 4", len(f.Separatrices))
+	}
+}
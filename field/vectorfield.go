@@ -1,8 +1,14 @@
 package field
 
 import (
-	"math"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 
+	"stj/fieldline/geom"
+	"stj/fieldline/grid"
 	"stj/fieldline/vector"
 )
 
@@ -15,18 +21,244 @@ type VectorQty struct {
 }
 
 // NewVectorQty 根据输入值创建一个向量场量. 其中 x, y 是场量坐标, vx, vy 是向量分量.
-func (v *VectorQty) NewVectorQty(x, y, vx, vy float64) *VectorQty {
+func NewVectorQty(x, y, vx, vy float64) *VectorQty {
 	vq := &VectorQty{}
 	vq.X = x
 	vq.Y = y
 	vq.Vector.X = vx
 	vq.Vector.Y = vy
 	vq.N = vq.Vector.Norm()
-	alpha, _, err := vq.Vector.Dir()
+	s, err := vq.Vector.Slp()
 	if err != nil {
 		vq.S = 1.0
 	} else {
-		vq.S = math.Tan(alpha)
+		vq.S = s
 	}
 	return vq
 }
+
+// VectorField 结构体实现了一个向量场.
+type VectorField struct {
+	baseField
+	data  []*VectorQty // 初始给定的无规则分布的离散数据
+	nodes []*VectorQty // 网格点上的数据
+}
+
+// NewVectorField 根据无规则离散分布的向量场量数据 data 创建一个向量场,
+// 其网格(Grid) 的大小由数据的坐标范围和个数以及参数 o 中的网格密度自动确定. 若 o 为 nil, 则使用默认参数.
+func NewVectorField(data []*VectorQty, o *Options) (vf *VectorField, err error) {
+	if len(data) == 0 {
+		return nil, errors.New("no vector quantity given")
+	}
+	b, err := newBaseField(len(data), func(i int) (x, y float64) {
+		return data[i].X, data[i].Y
+	}, o)
+	if err != nil {
+		return nil, err
+	}
+	vf = &VectorField{baseField: b}
+	vf.data = data
+	return vf, nil
+}
+
+// NearN 方法返回距点 (x, y) 最近的 n 个向量及其到该点的距离, 按距离由近到远排列, 距离相等时按数据的顺序排列.
+// 当场中的数据不足 n 个时, 返回所有的向量. n 应大于 0.
+func (vf *VectorField) NearN(x, y float64, n int) (vs []*VectorQty, dists []float64, err error) {
+	ns, err := vf.nearest(x, y, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	vs, dists = make([]*VectorQty, len(ns)), make([]float64, len(ns))
+	for i, n := range ns {
+		vs[i], dists[i] = vf.data[n.Idx], n.Dist
+	}
+	return vs, dists, nil
+}
+
+// idwVectorQty 根据向量场中原始无规则离散分布的 data 数据, 利用反距离加权插值(IDW)方法获得任一点的向量.
+// 向量的两个分量以相同的权重分别插值.
+func (vf *VectorField) idwVectorQty(x, y float64) (*VectorQty, error) {
+	ns := vf.idwNeighbors(x, y, func(i int) (x, y float64) {
+		return vf.data[i].X, vf.data[i].Y
+	})
+	if ns == nil {
+		if !vf.opts.AssignZeroOnIntrplFail {
+			return nil, errors.New("no known point existing around the given point")
+		}
+		return NewVectorQty(x, y, 0.0, 0.0), nil
+	}
+	var vx, vy, sw float64
+	for i, w := range idwWeights(ns, vf.opts.IDW.Power) {
+		v := vf.data[ns[i].Idx]
+		vx += w * v.Vector.X
+		vy += w * v.Vector.Y
+		sw += w
+	}
+	return NewVectorQty(x, y, vx/sw, vy/sw), nil
+}
+
+// GenNodes 根据向量场中无规则离散分布的向量数据 data, 通过反距离加权插值方法,
+// 计算各个单元格节点处的向量, 从而构建出可以进行双线性插值的向量场网格.
+// 若通过 SetMethod 选择了其他插值方法, 则使用该方法进行插值; 对于由有限元网格创建的向量场,
+// 节点处的向量由形函数插值求得. 若场设置了定义域, 则定义域以外且不与之相交的单元格的节点不进行插值, 其向量为零.
+// 各节点在多个 goroutine 中同时插值, goroutine 的个数由场的参数 Workers 确定, 其结果与逐个插值相同.
+func (vf *VectorField) GenNodes() (err error) {
+	return vf.GenNodesContext(context.Background())
+}
+
+// GenNodesContext 方法与 GenNodes 方法相同, 但可以通过 ctx 中途取消. 取消或插值失败时返回一个错误,
+// 且场原有的节点数据保持不变.
+func (vf *VectorField) GenNodesContext(ctx context.Context) (err error) {
+	intrpl := vf.idwVectorQty
+	switch {
+	case vf.mesh != nil:
+		intrpl = vf.meshVectorQty
+	case vf.method == RBFMethod:
+		intrpl = vf.rbfVectorQty()
+	case vf.method == KrigingMethod:
+		if intrpl, err = vf.krigVectorQty(); err != nil {
+			return err
+		}
+	case vf.usesTriangulation():
+		if intrpl, err = vf.triVectorQty(); err != nil {
+			return err
+		}
+	}
+	nodes := make([]*VectorQty, vf.grid.NodeNum)
+	err = parallel(ctx, len(nodes), vf.opts.Workers, func(i int) (err error) {
+		x, y := vf.grid.Nodes[i].X, vf.grid.Nodes[i].Y
+		if vf.grid.NodeLoc(i) == grid.Outside {
+			nodes[i] = NewVectorQty(x, y, 0.0, 0.0)
+			return nil
+		}
+		nodes[i], err = intrpl(x, y)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	vf.nodes = nodes
+	return nil
+}
+
+// vectorAt 方法通过空间插值方法获得向量场内任意点 (x, y) 处向量的两个分量. 对于由有限元网格创建的向量场,
+// 由点所在单元的形函数插值求得; 否则由点所在单元格四个节点处的向量进行双线性插值求得.
+func (vf *VectorField) vectorAt(x, y float64) (vx, vy float64, err error) {
+	if err := vf.checkDomain(x, y); err != nil {
+		return 0.0, 0.0, err
+	}
+	if vf.mesh != nil {
+		ei, w, err := vf.mesh.Locate(x, y)
+		if err != nil {
+			return 0.0, 0.0, err
+		}
+		for k, ni := range vf.mesh.Elements[ei] {
+			vx += w[k] * vf.data[ni].Vector.X
+			vy += w[k] * vf.data[ni].Vector.Y
+		}
+		return vx, vy, nil
+	}
+	if len(vf.nodes) == 0 {
+		return 0.0, 0.0, errors.New("the nodes of the vector field have not been generated")
+	}
+	cell, nodeIdxes, err := vf.grid.CellNodeIdxes(x, y)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	ll, ul := &vf.nodes[nodeIdxes[0]].Vector, &vf.nodes[nodeIdxes[1]].Vector
+	lu, uu := &vf.nodes[nodeIdxes[2]].Vector, &vf.nodes[nodeIdxes[3]].Vector
+	vx = cell.Value(x, y, ll.X, ul.X, lu.X, uu.X)
+	vy = cell.Value(x, y, ll.Y, ul.Y, lu.Y, uu.Y)
+	return vx, vy, nil
+}
+
+// Vx 方法通过空间插值方法获得向量场内任意点 (x, y) 处向量的 x 分量.
+func (vf *VectorField) Vx(x, y float64) (v float64, err error) {
+	v, _, err = vf.vectorAt(x, y)
+	return v, err
+}
+
+// Vy 方法通过空间插值方法获得向量场内任意点 (x, y) 处向量的 y 分量.
+func (vf *VectorField) Vy(x, y float64) (v float64, err error) {
+	_, v, err = vf.vectorAt(x, y)
+	return v, err
+}
+
+// Norm 方法获得向量场内任意点 (x, y) 处向量的模. 它由插值所得的两个分量求得, 而不是对各节点处向量的模进行插值,
+// 因而与 Vx, Vy 方法的返回值总是一致的.
+func (vf *VectorField) Norm(x, y float64) (v float64, err error) {
+	vx, vy, err := vf.vectorAt(x, y)
+	if err != nil {
+		return 0.0, err
+	}
+	return vector.New(vx, vy).Norm(), nil
+}
+
+// Slope 方法获得向量场内任意点 (x, y) 处向量的斜率, 即流线在该点的切线斜率. 它同样由插值所得的两个分量求得.
+// 若向量平行于 y 轴, 则返回正无穷大或负无穷大; 若向量为零向量(如在场的临界点处), 则返回一个错误.
+func (vf *VectorField) Slope(x, y float64) (v float64, err error) {
+	vx, vy, err := vf.vectorAt(x, y)
+	if err != nil {
+		return 0.0, err
+	}
+	return vector.New(vx, vy).Slp()
+}
+
+// QtyAt 方法通过空间插值方法获得向量场内任意点 (x, y) 处的向量, 其分量, 模和斜率分别与 Vx, Vy, Norm 和 Slope
+// 方法的返回值相同, 但只需查找一次单元格. 与 NewVectorQty 相同, 零向量的斜率为 1.
+func (vf *VectorField) QtyAt(x, y float64) (*VectorQty, error) {
+	vx, vy, err := vf.vectorAt(x, y)
+	if err != nil {
+		return nil, err
+	}
+	return NewVectorQty(x, y, vx, vy), nil
+}
+
+// QtiesAt 方法求向量场内各点 ps 处的向量, 它与对每个点调用 QtyAt 方法的结果相同, 但各点在多个 goroutine 中
+// 同时求值, goroutine 的个数由场的参数 Workers 确定. 若某点求值失败, 则返回的错误中指明该点在 ps 中的索引.
+func (vf *VectorField) QtiesAt(ps []geom.Point) ([]*VectorQty, error) {
+	vs := make([]*VectorQty, len(ps))
+	err := parallel(context.Background(), len(ps), vf.opts.Workers, func(i int) (err error) {
+		if vs[i], err = vf.QtyAt(ps[i].X, ps[i].Y); err != nil {
+			return fmt.Errorf("point %d: %v", i, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// ParseVectorData 解析由数值模拟导出的向量场数据文本, 并生成一个 *VectorField.
+// 该文本的格式为以下形式:
+//
+// x, y, vx, vy\n
+//
+// 数字之间的分隔符以及行尾的要求与 ParseTensorData 相同. o 的含义与 ReadVectorData 相同.
+func ParseVectorData(input []byte, o *Options) (vf *VectorField, err error) {
+	return ReadVectorData(bytes.NewReader(input), o)
+}
+
+// ReadVectorData 从 r 中逐行读取由数值模拟导出的向量场数据文本, 并生成一个 *VectorField.
+// 文本的格式与 ParseVectorData 相同. 该函数并不将整个文本同时读入内存, 适用于很大的数据文件.
+// o 为解析数据和创建场所用的参数, 若为 nil, 则使用默认参数.
+func ReadVectorData(r io.Reader, o *Options) (vf *VectorField, err error) {
+	if o, err = o.resolve(); err != nil {
+		return nil, err
+	}
+	var data []*VectorQty
+	// 如果每行解析出的文本数不等于 4, 则并不满足向量数据需求, 直接舍弃
+	err = readData(r, 4, func(floats []float64) {
+		if !o.DiscardZeroQty || !isZeroQty(floats[2:]...) {
+			data = append(data, NewVectorQty(floats[0], floats[1], floats[2], floats[3]))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("no valid data parsed")
+	}
+	return NewVectorField(data, o)
+}
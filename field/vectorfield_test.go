@@ -1 +1,93 @@
 package field
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/geom"
+)
+
+func TestNewVectorQty(t *testing.T) {
+	v := NewVectorQty(1.0, 2.0, 3.0, -4.0)
+	if v.N != 5.0 || math.Abs(v.S+4.0/3.0) > 1.0e-12 {
+		t.Errorf("func NewVectorQty wrong, N: %v, S: %v", v.N, v.S)
+	}
+}
+
+func TestParseVectorData(t *testing.T) {
+	input := []byte("x\ty\tvx\tvy\n0\t0\t1\t0\n2\t0\t0\t1\n0\t1\t-1\t0\n2\t1\n")
+	vf, err := ParseVectorData(input, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(vf.data) != 3 {
+		t.Fatalf("got %d vector quantities, want 3", len(vf.data))
+	}
+	r := vf.Range()
+	if r.Xmin != 0.0 || r.Xmax != 2.0 || r.Ymin != 0.0 || r.Ymax != 1.0 {
+		t.Errorf("wrong field range: %v", *r)
+	}
+	for i, d := range vf.data {
+		if c, _ := vf.grid.Cell(d.X, d.Y); !containsInt(c.QtyIdxes, i) {
+			t.Errorf("vector quantity %d is not added to the grid", i)
+		}
+	}
+}
+
+// TestVectorFieldValue 检查由线性向量场 v = (x - 2, 1 - y) 的离散数据生成的向量场在任一点处的分量, 模和斜率.
+func TestVectorFieldValue(t *testing.T) {
+	var data []*VectorQty
+	for x := 0.0; x <= 4.0; x++ {
+		for y := 0.0; y <= 4.0; y++ {
+			data = append(data, NewVectorQty(x, y, x-2.0, 1.0-y))
+		}
+	}
+	vf, err := NewVectorField(data, nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, err := vf.Vx(1.0, 1.0); err == nil {
+		t.Error("func VectorField.Vx should fail before the nodes are generated")
+	}
+	vf.SetMethod(LinearMethod) // 分片线性插值和双线性插值都能精确地重现线性场
+	if err := vf.GenNodes(); err != nil {
+		t.Fatal(err.Error())
+	}
+	ps := []geom.Point{{X: 0.5, Y: 3.7}, {X: 3.2, Y: 0.4}, {X: 2.9, Y: 2.2}}
+	vs, err := vf.QtiesAt(ps)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for i, p := range ps {
+		vx, vy := p.X-2.0, 1.0-p.Y
+		gx, err1 := vf.Vx(p.X, p.Y)
+		gy, err2 := vf.Vy(p.X, p.Y)
+		n, err3 := vf.Norm(p.X, p.Y)
+		s, err4 := vf.Slope(p.X, p.Y)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			t.Fatalf("errors at %v: %v, %v, %v, %v", p, err1, err2, err3, err4)
+		}
+		if math.Abs(gx-vx) > 1e-10 || math.Abs(gy-vy) > 1e-10 || math.Abs(n-math.Hypot(vx, vy)) > 1e-10 ||
+			math.Abs(s-vy/vx) > 1e-10 {
+			t.Errorf("at %v got (%g, %g), norm %g, slope %g, want (%g, %g)", p, gx, gy, n, s, vx, vy)
+		}
+		if vs[i].Vector.X != gx || vs[i].Vector.Y != gy || vs[i].N != n || vs[i].S != s {
+			t.Errorf("func QtiesAt got %v at %v", *vs[i], p)
+		}
+	}
+	if _, err := vf.Slope(2.0, 1.0); err == nil {
+		t.Error("func VectorField.Slope should fail at a zero vector")
+	}
+	if _, err := vf.Vx(5.0, 1.0); err == nil {
+		t.Error("func VectorField.Vx should fail outside the field")
+	}
+}
+
+func containsInt(s []int, v int) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
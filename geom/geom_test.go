@@ -17,3 +17,36 @@ func TestRect(t *testing.T) {
 		t.Error("func Rect.Area wrong")
 	}
 }
+
+func TestPolygon(t *testing.T) {
+	// 逆时针排列的 L 形
+	pg := geom.Polygon{{X: 0, Y: 0}, {X: 2, Y: 0}, {X: 2, Y: 1}, {X: 1, Y: 1}, {X: 1, Y: 2}, {X: 0, Y: 2}}
+	if !num.Equal(pg.Area(), 3.0) {
+		t.Errorf("Polygon.Area = %g, want 3", pg.Area())
+	}
+	if !pg.Contains(0.5, 1.5) || pg.Contains(1.5, 1.5) || pg.Contains(-1, 0.5) {
+		t.Error("func Polygon.Contains wrong")
+	}
+	if !num.Equal(pg.DistTo(1.5, 1.5), 0.5) || !num.Equal(pg.DistTo(0.5, 0.2), 0.2) {
+		t.Error("func Polygon.DistTo wrong")
+	}
+	r := pg.Bounds()
+	if r != (geom.Rect{Xmin: 0, Ymin: 0, Xmax: 2, Ymax: 2}) {
+		t.Errorf("Polygon.Bounds = %v", r)
+	}
+	c := geom.Rect{Xmin: 1, Ymin: 1, Xmax: 2, Ymax: 2}
+	for _, s := range []struct {
+		p, q geom.Point
+		want bool
+	}{
+		{geom.Point{X: 0, Y: 0}, geom.Point{X: 3, Y: 3}, true},
+		{geom.Point{X: 1.2, Y: 1.2}, geom.Point{X: 1.5, Y: 1.8}, true},
+		{geom.Point{X: 0, Y: 1}, geom.Point{X: 1, Y: 1}, true},
+		{geom.Point{X: 0, Y: 2}, geom.Point{X: 0.9, Y: 3}, false},
+		{geom.Point{X: 0, Y: 2.5}, geom.Point{X: 3, Y: 2.5}, false},
+	} {
+		if c.IntersectsSegment(s.p, s.q) != s.want {
+			t.Errorf("IntersectsSegment(%v, %v) = %v", s.p, s.q, !s.want)
+		}
+	}
+}
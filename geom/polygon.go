@@ -0,0 +1,80 @@
+package geom
+
+import (
+	"math"
+)
+
+// Polygon 定义了平面上的一个简单多边形, 其顶点按顺序排列, 最后一个顶点与第一个顶点相连, 首尾顶点不必重复.
+type Polygon []Point
+
+// Area 返回多边形的有向面积. 顶点按逆时针排列时为正, 按顺时针排列时为负.
+func (pg Polygon) Area() float64 {
+	var a float64
+	for i := range pg {
+		p, q := pg[i], pg[(i+1)%len(pg)]
+		a += p.X*q.Y - q.X*p.Y
+	}
+	return 0.5 * a
+}
+
+// Contains 判断点 (x, y) 是否在多边形内部. 点正好在多边形的边上时, 结果是不确定的,
+// 需要时应先用 DistTo 方法判断点是否在边上.
+func (pg Polygon) Contains(x, y float64) bool {
+	in := false
+	for i, j := 0, len(pg)-1; i < len(pg); j, i = i, i+1 {
+		p, q := pg[i], pg[j]
+		if (p.Y > y) != (q.Y > y) && x < (q.X-p.X)*(y-p.Y)/(q.Y-p.Y)+p.X {
+			in = !in
+		}
+	}
+	return in
+}
+
+// DistTo 返回点 (x, y) 到多边形边界的最短距离.
+func (pg Polygon) DistTo(x, y float64) float64 {
+	d := math.Inf(1)
+	for i := range pg {
+		p, q := pg[i], pg[(i+1)%len(pg)]
+		d = math.Min(d, SegDist(x, y, p, q))
+	}
+	return d
+}
+
+// SegDist 返回点 (x, y) 到线段 pq 的距离.
+func SegDist(x, y float64, p, q Point) float64 {
+	dx, dy := q.X-p.X, q.Y-p.Y
+	t := 0.0
+	if l2 := dx*dx + dy*dy; l2 > 0.0 {
+		t = math.Max(0.0, math.Min(1.0, ((x-p.X)*dx+(y-p.Y)*dy)/l2))
+	}
+	return math.Hypot(x-p.X-t*dx, y-p.Y-t*dy)
+}
+
+// Bounds 返回多边形的外接矩形.
+func (pg Polygon) Bounds() Rect {
+	r := Rect{Xmin: math.Inf(1), Ymin: math.Inf(1), Xmax: math.Inf(-1), Ymax: math.Inf(-1)}
+	for _, p := range pg {
+		r.Xmin, r.Xmax = math.Min(r.Xmin, p.X), math.Max(r.Xmax, p.X)
+		r.Ymin, r.Ymax = math.Min(r.Ymin, p.Y), math.Max(r.Ymax, p.Y)
+	}
+	return r
+}
+
+// IntersectsSegment 判断线段 pq 是否与矩形(包括其边界)相交, 线段完全在矩形内也算相交.
+// 采用 Liang-Barsky 线段裁剪算法.
+func (r *Rect) IntersectsSegment(p, q Point) bool {
+	t0, t1 := 0.0, 1.0
+	dx, dy := q.X-p.X, q.Y-p.Y
+	clip := func(d, n float64) bool { // 约束为 d*t <= n
+		switch {
+		case d == 0.0:
+			return n >= 0.0
+		case d < 0.0:
+			t0 = math.Max(t0, n/d)
+		default:
+			t1 = math.Min(t1, n/d)
+		}
+		return t0 <= t1
+	}
+	return clip(-dx, p.X-r.Xmin) && clip(dx, r.Xmax-p.X) && clip(-dy, p.Y-r.Ymin) && clip(dy, r.Ymax-p.Y)
+}
@@ -0,0 +1,281 @@
+package grid
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"stj/fieldline/geom"
+)
+
+// Domain 表示场的定义域, 它由一个外边界多边形和若干个孔洞多边形组成, 用于描述坝体, 隧洞,
+// 带缺口的板等非矩形的区域. 多边形的顶点可以按顺时针或逆时针排列.
+type Domain struct {
+	Outer geom.Polygon
+	Holes []geom.Polygon
+	tol   float64 // 判断点是否在边界上的距离容差
+}
+
+// NewDomain 根据外边界 outer 和孔洞 holes 创建一个定义域. 每个多边形至少应有 3 个顶点.
+func NewDomain(outer geom.Polygon, holes ...geom.Polygon) (*Domain, error) {
+	for _, pg := range append([]geom.Polygon{outer}, holes...) {
+		if len(pg) < 3 {
+			return nil, errors.New("a polygon of the domain should have at least 3 vertices")
+		}
+	}
+	r := outer.Bounds()
+	d := &Domain{Outer: outer, Holes: holes}
+	d.tol = 1e-9 * math.Max(r.Xmax-r.Xmin, r.Ymax-r.Ymin)
+	return d, nil
+}
+
+// NewDomainOfRings 根据一组闭合的边界环(如 delaunay 包中 AlphaShape 方法的返回值)创建一个定义域.
+// 面积最大的环作为外边界, 在外边界以内的其他环作为孔洞, 在外边界以外的环(即数据点中分离出来的小块)被舍弃.
+func NewDomainOfRings(rings []geom.Polygon) (*Domain, error) {
+	if len(rings) == 0 {
+		return nil, errors.New("no boundary ring given")
+	}
+	outer := 0
+	for i, r := range rings {
+		if math.Abs(r.Area()) > math.Abs(rings[outer].Area()) {
+			outer = i
+		}
+	}
+	var holes []geom.Polygon
+	for i, r := range rings {
+		if i != outer && len(r) > 0 && rings[outer].Contains(ringCenter(r)) {
+			holes = append(holes, r)
+		}
+	}
+	return NewDomain(rings[outer], holes...)
+}
+
+// ringCenter 返回边界环第一条边的中点, 用以判断一个环是否在另一个环以内. 由于各环互不相交,
+// 环上任一点与整个环在另一个环的同一侧, 取边的中点是为了避开两环共用的顶点.
+func ringCenter(r geom.Polygon) (x, y float64) {
+	q := r[1%len(r)]
+	return 0.5 * (r[0].X + q.X), 0.5 * (r[0].Y + q.Y)
+}
+
+// rings 返回定义域的所有边界多边形.
+func (d *Domain) rings() []geom.Polygon {
+	return append([]geom.Polygon{d.Outer}, d.Holes...)
+}
+
+// OnBoundary 判断点 (x, y) 是否在定义域的边界上(在舍入误差范围内).
+func (d *Domain) OnBoundary(x, y float64) bool {
+	for _, pg := range d.rings() {
+		if pg.DistTo(x, y) <= d.tol {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains 判断点 (x, y) 是否在定义域内, 边界上的点也属于定义域.
+func (d *Domain) Contains(x, y float64) bool {
+	if d.OnBoundary(x, y) {
+		return true
+	}
+	if !d.Outer.Contains(x, y) {
+		return false
+	}
+	for _, h := range d.Holes {
+		if h.Contains(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadDomain 读入一个定义域. 文本的每行为一个顶点的 x, y 坐标, 多边形之间以空行分隔,
+// 第一个多边形为外边界, 其余为孔洞. 以 # 开头的行为注释.
+func ReadDomain(r io.Reader) (*Domain, error) {
+	var pgs []geom.Polygon
+	var pg geom.Polygon
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "" {
+			if len(pg) > 0 {
+				pgs = append(pgs, pg)
+				pg = nil
+			}
+			continue
+		}
+		fs := strings.FieldsFunc(line, func(c rune) bool { return c == ',' || c == ' ' || c == '\t' })
+		if len(fs) < 2 {
+			return nil, fmt.Errorf("line %d: two coordinates expected", n)
+		}
+		x, err := strconv.ParseFloat(fs[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		y, err := strconv.ParseFloat(fs[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		pg = append(pg, geom.Point{X: x, Y: y})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(pg) > 0 {
+		pgs = append(pgs, pg)
+	}
+	if len(pgs) == 0 {
+		return nil, errors.New("no polygon given")
+	}
+	return NewDomain(pgs[0], pgs[1:]...)
+}
+
+// Location 表示单元格或节点相对于网格定义域的位置.
+type Location int
+
+// 对于单元格, Boundary 表示定义域的边界穿过该单元格; 对于节点, Boundary 表示节点在定义域以外,
+// 但它是某个与定义域相交的单元格的顶点, 在该单元格内进行双线性插值时仍需要用到它的值.
+const (
+	Inside Location = iota
+	Outside
+	Boundary
+)
+
+var locationNames = []string{"inside", "outside", "boundary"}
+
+func (l Location) String() string {
+	if l < 0 || int(l) >= len(locationNames) {
+		return fmt.Sprintf("Location(%d)", int(l))
+	}
+	return locationNames[l]
+}
+
+// cellMargin 为判断边界是否穿过单元格内部时单元格各边向内收缩的量, 以单元格的边长为单位.
+const cellMargin = 1e-9
+
+// SetDomain 方法设置网格的定义域, 并据此标记各个单元格和节点的位置. d 为 nil 时取消定义域,
+// 即整个矩形网格范围都是定义域.
+func (g *Grid) SetDomain(d *Domain) {
+	g.Domain = d
+	g.CellLocs, g.NodeLocs = nil, nil
+	if d == nil {
+		return
+	}
+	g.CellLocs = make([]Location, g.CellNum)
+	for ci := range g.CellLocs {
+		c := &g.Cells[ci].Range
+		if !d.Contains(0.5*(c.Xmin+c.Xmax), 0.5*(c.Ymin+c.Ymax)) {
+			g.CellLocs[ci] = Outside
+		}
+	}
+	// 边界穿过其内部的单元格, 仅与边界相接的单元格不算在内. 对每条边只检查其外接矩形所覆盖的单元格.
+	ex, ey := cellMargin*g.XSpan, cellMargin*g.YSpan
+	for _, pg := range d.rings() {
+		for i := range pg {
+			p, q := pg[i], pg[(i+1)%len(pg)]
+			x0, y0 := g.clampedCellPos(math.Min(p.X, q.X), math.Min(p.Y, q.Y))
+			x1, y1 := g.clampedCellPos(math.Max(p.X, q.X), math.Max(p.Y, q.Y))
+			// 端点正好在网格线上时, 与之相接的下方和左方的单元格也可能与边相交
+			for yi := maxInt(y0-1, 0); yi <= y1; yi++ {
+				for xi := maxInt(x0-1, 0); xi <= x1; xi++ {
+					ci := g.CellIdx(xi, yi)
+					c := g.Cells[ci].Range
+					c.Xmin, c.Xmax, c.Ymin, c.Ymax = c.Xmin+ex, c.Xmax-ex, c.Ymin+ey, c.Ymax-ey
+					if c.IntersectsSegment(p, q) {
+						g.CellLocs[ci] = Boundary
+					}
+				}
+			}
+		}
+	}
+	g.NodeLocs = make([]Location, g.NodeNum)
+	for ni, n := range g.Nodes {
+		if !d.Contains(n.X, n.Y) {
+			g.NodeLocs[ni] = Outside
+		}
+	}
+	for ci, l := range g.CellLocs {
+		if l == Outside {
+			continue
+		}
+		for _, ni := range g.NodeIdxesofCell(ci) {
+			if g.NodeLocs[ni] == Outside {
+				g.NodeLocs[ni] = Boundary
+			}
+		}
+	}
+}
+
+// clampedCellPos 返回点 (x, y) 所在单元格的列, 行数. 与 CellPosIdx 不同, 网格范围以外的点被归入最近的单元格,
+// 且网格线上的点被归入其上方或右方的单元格.
+func (g *Grid) clampedCellPos(x, y float64) (xi, yi int) {
+	clamp := func(v float64, n int) int {
+		i := int(math.Floor(v))
+		if i < 0 {
+			return 0
+		}
+		if i >= n {
+			return n - 1
+		}
+		return i
+	}
+	xi = clamp((x-g.Range.Xmin)/g.XSpan, g.CellXN)
+	yi = clamp((y-g.Range.Ymin)/g.YSpan, g.CellYN)
+	return xi, yi
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// CellLoc 方法返回索引为 ci 的单元格相对于定义域的位置. 若网格没有设置定义域, 则总是返回 Inside.
+func (g *Grid) CellLoc(ci int) Location {
+	if g.CellLocs == nil {
+		return Inside
+	}
+	return g.CellLocs[ci]
+}
+
+// NodeLoc 方法返回索引为 ni 的节点相对于定义域的位置. 若网格没有设置定义域, 则总是返回 Inside.
+func (g *Grid) NodeLoc(ni int) Location {
+	if g.NodeLocs == nil {
+		return Inside
+	}
+	return g.NodeLocs[ni]
+}
+
+// InDomain 方法判断点 (x, y) 是否在网格的定义域内. 若网格没有设置定义域, 则总是返回 true.
+// 对于 Inside 和 Outside 单元格内部的点, 直接由单元格的位置确定; 只有 Boundary 单元格内的点,
+// 以及距单元格边界过近而可能与定义域边界重合的点, 才需逐边检查定义域的边界多边形.
+func (g *Grid) InDomain(x, y float64) bool {
+	if g.Domain == nil {
+		return true
+	}
+	if g.CellLocs != nil {
+		xi, yi := int(math.Floor((x-g.Range.Xmin)/g.XSpan)), int(math.Floor((y-g.Range.Ymin)/g.YSpan))
+		if xi >= 0 && yi >= 0 && xi < g.CellXN && yi < g.CellYN {
+			ci := g.CellIdx(xi, yi)
+			c := &g.Cells[ci].Range
+			// 在 SetDomain 所用的收缩量之外再留出边界的距离容差, 以使该范围内的点都与单元格中心在边界的同一侧
+			mx, my := cellMargin*g.XSpan+g.Domain.tol, cellMargin*g.YSpan+g.Domain.tol
+			if x > c.Xmin+mx && x < c.Xmax-mx && y > c.Ymin+my && y < c.Ymax-my {
+				switch g.CellLocs[ci] {
+				case Inside:
+					return true
+				case Outside:
+					return false
+				}
+			}
+		}
+	}
+	return g.Domain.Contains(x, y)
+}
@@ -1,5 +1,6 @@
 // grid 包实现了对平面矩形区域内数据的划分以及寻址操作.  该包将一个矩形区域划分为大小相等的,
 // 相互对齐的矩形网格, 每个网格又称为一个单元格(Cell), 网格线的交点称为节点(Node).
+// 此外, 该包还实现了用于查找邻近数据点的空间索引(Index), 包括适应点距疏密变化的四叉树(Quadtree).
 // field 包, streamline 包的实现都依赖此包.
 package grid
 
@@ -13,9 +14,9 @@ import (
 	"stj/fieldline/num"
 )
 
-// AvgQtyNumPerCell 表示设定的每个 Cell 中包含物理量(点)的平均个数.
-// 在创建 Grid 时, 该值将影响网格的密度. 该值越小, 网格越密.
-var AvgQtyNumPerCell = 0.5
+// DefaultDensity 为默认的每个 Cell 中包含物理量(点)的平均个数. 根据点的个数自动确定网格的大小时,
+// 该值将影响网格的密度, 该值越小, 网格越密. field 包中的场可由其参数 Options.Density 另行指定.
+const DefaultDensity = 0.5
 
 // Cell 表示 Grid 网格的一个单元格.
 type Cell struct {
@@ -37,6 +38,75 @@ func (c *Cell) Value(x, y float64, ll, ul, lu, uu float64) float64 {
 	return v
 }
 
+// Weights 方法返回点 (x, y) 处双线性插值中四个节点的权重, 节点的顺序与 Value 方法的 ll, ul, lu, uu 相同.
+// 以各节点的值乘以相应的权重并求和, 即得 Value 方法的结果.
+func (c *Cell) Weights(x, y float64) [4]float64 {
+	a := 1.0 / ((c.Range.Xmax - c.Range.Xmin) * (c.Range.Ymax - c.Range.Ymin))
+	return [4]float64{
+		a * ((c.Range.Xmax - x) * (c.Range.Ymax - y)),
+		a * ((x - c.Range.Xmin) * (c.Range.Ymax - y)),
+		a * ((c.Range.Xmax - x) * (y - c.Range.Ymin)),
+		a * ((x - c.Range.Xmin) * (y - c.Range.Ymin)),
+	}
+}
+
+// bilinearCoefs 返回单元格内双线性插值在局部坐标 (s, t) 下的系数, 其中 s, t 分别为点在单元格内 x, y 方向的
+// 相对位置, 取值范围为 [0, 1]. 插值函数为 a[0] + a[1]*s + a[2]*t + a[3]*s*t. vs 为四个节点处的值,
+// 顺序与 Value 方法的 ll, ul, lu, uu 相同.
+func bilinearCoefs(vs [4]float64) (a [4]float64) {
+	return [4]float64{vs[0], vs[1] - vs[0], vs[2] - vs[0], vs[0] - vs[1] - vs[2] + vs[3]}
+}
+
+// Gradient 方法返回以 vs 为四个节点处的值进行双线性插值时, 插值函数在点 (x, y) 处对 x 和 y 的偏导数.
+// vs 的顺序与 Value 方法的 ll, ul, lu, uu 相同.
+func (c *Cell) Gradient(x, y float64, vs [4]float64) (dx, dy float64) {
+	w, h := c.Range.Xmax-c.Range.Xmin, c.Range.Ymax-c.Range.Ymin
+	s, t := (x-c.Range.Xmin)/w, (y-c.Range.Ymin)/h
+	a := bilinearCoefs(vs)
+	return (a[1] + a[3]*t) / w, (a[2] + a[3]*s) / h
+}
+
+// zeroTol 为 Zeros 方法判断零点是否在单元格内时所用的相对容差, 以免落在单元格边界上的零点因舍入误差而被遗漏.
+const zeroTol = 1e-9
+
+// Zeros 方法求单元格内两个双线性插值函数同时为零的点, 如向量场的临界点(两个分量都为零)或张量场的退化点
+// (XX-YY 和 XY 都为零). u, v 分别为两个函数在四个节点处的值, 顺序与 Value 方法的 ll, ul, lu, uu 相同.
+// 单元格内至多有两个孤立的零点, 边界上的零点也包括在内. 若两个函数在单元格内有公共的零值曲线,
+// 则曲线上的点不是孤立的零点, 不予返回.
+func (c *Cell) Zeros(u, v [4]float64) []geom.Point {
+	a, b := bilinearCoefs(u), bilinearCoefs(v)
+	// 由 u = 0 得 t = -(a0 + a1*s) / (a2 + a3*s), 代入 v = 0 得关于 s 的二次方程
+	ss := num.QuadRoots(b[1]*a[3]-b[3]*a[1], b[0]*a[3]+b[1]*a[2]-b[2]*a[1]-b[3]*a[0], b[0]*a[2]-b[2]*a[0])
+	var ps []geom.Point
+	for _, s := range ss {
+		if s < -zeroTol || s > 1.0+zeroTol {
+			continue
+		}
+		s = math.Max(0.0, math.Min(1.0, s))
+		// 取分母绝对值较大的一个函数求 t, 若两个分母都为 0, 则 u 和 v 在直线 s 上都与 t 无关,
+		// 该直线要么没有零点, 要么全为零点
+		du, dv := a[2]+a[3]*s, b[2]+b[3]*s
+		var t float64
+		switch {
+		case math.Abs(du) >= math.Abs(dv) && du != 0.0:
+			t = -(a[0] + a[1]*s) / du
+		case dv != 0.0:
+			t = -(b[0] + b[1]*s) / dv
+		default:
+			continue
+		}
+		if t < -zeroTol || t > 1.0+zeroTol {
+			continue
+		}
+		t = math.Max(0.0, math.Min(1.0, t))
+		ps = append(ps, geom.Point{
+			X: c.Range.Xmin + s*(c.Range.Xmax-c.Range.Xmin),
+			Y: c.Range.Ymin + t*(c.Range.Ymax-c.Range.Ymin),
+		})
+	}
+	return ps
+}
+
 // Node 代表网格线的交叉点, 也即单元格的顶点.
 type Node struct {
 	X, Y float64
@@ -52,6 +122,10 @@ type Grid struct {
 	CellXN, CellYN   int
 	NodeXN, NodeYN   int
 	CellNum, NodeNum int
+	// Domain 为网格的定义域, 为 nil 时整个矩形范围都是定义域. CellLocs 和 NodeLocs 分别为各个单元格和节点
+	// 相对于定义域的位置, 它们由 SetDomain 方法设置, 没有定义域时为 nil.
+	Domain             *Domain
+	CellLocs, NodeLocs []Location
 }
 
 // New 根据输入参数创建一个 Grid 结构体, 总是应该使用此方法创建 Grid.
@@ -70,22 +144,32 @@ func New(r geom.Rect, cxn, cyn int) (g *Grid, err error) {
 	g.NodeNum = g.NodeXN * g.NodeYN
 	g.Cells = make([]Cell, g.CellNum)
 	for i := 0; i < g.CellNum; i++ {
-		g.Cells[i] = Cell{QtyIdxes: make([]int, 0, int(math.Ceil(AvgQtyNumPerCell)))}
+		g.Cells[i] = Cell{QtyIdxes: make([]int, 0, int(math.Ceil(DefaultDensity)))}
 		xi, yi := g.CellPos(i)
-		g.Cells[i].Range.Xmin = float64(xi) * g.XSpan
-		g.Cells[i].Range.Xmax = g.Cells[i].Range.Xmin + g.XSpan
-		g.Cells[i].Range.Ymin = float64(yi) * g.YSpan
-		g.Cells[i].Range.Ymax = g.Cells[i].Range.Ymin + g.YSpan
+		g.Cells[i].Range.Xmin, g.Cells[i].Range.Ymin = g.lineCoord(xi, yi)
+		g.Cells[i].Range.Xmax, g.Cells[i].Range.Ymax = g.lineCoord(xi+1, yi+1)
 	}
 	g.Nodes = make([]Node, g.NodeNum)
 	for i := 0; i < g.NodeNum; i++ {
-		xi, yi := g.NodePos(i)
-		g.Nodes[i].X = float64(xi) * g.XSpan
-		g.Nodes[i].Y = float64(yi) * g.YSpan
+		g.Nodes[i].X, g.Nodes[i].Y = g.lineCoord(g.NodePos(i))
 	}
 	return g, nil
 }
 
+// lineCoord 返回第 xi 条竖直网格线的 x 坐标和第 yi 条水平网格线的 y 坐标. 最后一条网格线的坐标
+// 总是等于网格范围的上边界, 以免舍入误差使其超出网格范围.
+func (g *Grid) lineCoord(xi, yi int) (x, y float64) {
+	x = g.Range.Xmin + float64(xi)*g.XSpan
+	if xi == g.CellXN {
+		x = g.Range.Xmax
+	}
+	y = g.Range.Ymin + float64(yi)*g.YSpan
+	if yi == g.CellYN {
+		y = g.Range.Ymax
+	}
+	return x, y
+}
+
 // CellPos 根据单元格索引计算返回其所在的列, 行数.
 func (g *Grid) CellPos(ci int) (xi, yi int) {
 	xi = ci % g.CellXN
@@ -131,6 +215,17 @@ func (g *Grid) NodeIdxes(x, y float64) ([]int, error) {
 	return g.NodeIdxesofCell(ci), nil
 }
 
+// CellNodeIdxes 方法返回点 (x, y) 所在的单元格及其四个节点的索引, 节点的顺序与 NodeIdxes 方法相同.
+// 与先后调用 Cell 和 NodeIdxes 方法相比, 它只查找一次单元格, 且不分配内存, 适用于需要大量求值的场合.
+func (g *Grid) CellNodeIdxes(x, y float64) (*Cell, [4]int, error) {
+	xi, yi, ci, err := g.CellPosIdx(x, y)
+	if err != nil {
+		return nil, [4]int{}, err
+	}
+	i := yi*g.NodeXN + xi
+	return &g.Cells[ci], [4]int{i, i + 1, i + g.NodeXN, i + g.NodeXN + 1}, nil
+}
+
 // AdjNodeIdxes 返回与索引为 ni 的节点相邻的最多 8 个节点.
 // 5 6 7
 // 3 * 4
@@ -218,18 +313,34 @@ func (g *Grid) CellPosIdx(x, y float64) (xi, yi, idx int, err error) {
 		err = fmt.Errorf("the input point (%g, %g) is out of the Grid gegion", x, y)
 		return -1, -1, -1, err
 	}
-	xi = int(math.Ceil((x-g.Range.Xmin)/g.XSpan)) - 1
-	yi = int(math.Ceil((y-g.Range.Ymin)/g.YSpan)) - 1
+	xi = cellPos((x - g.Range.Xmin) / g.XSpan)
+	yi = cellPos((y - g.Range.Ymin) / g.YSpan)
 	if yi < 0 { // 应对输入点正好在下边界的情况 (y == g.Range.Ymin)
 		yi = 0
 	}
 	if xi < 0 { // 应对输入点正好在左边界的情况 (x == g.Range.Xmin)
 		xi = 0
 	}
+	// 应对输入点正好在上边界或右边界, 而舍入误差使计算所得的位置超出网格的情况
+	if yi >= g.CellYN {
+		yi = g.CellYN - 1
+	}
+	if xi >= g.CellXN {
+		xi = g.CellXN - 1
+	}
 	idx = g.CellIdx(xi, yi)
 	return xi, yi, idx, nil
 }
 
+// cellPos 根据点到网格下边界的距离与单元格边长之比 r 计算点所在单元格的序号. 若 r 在舍入误差范围内
+// 等于整数, 则认为点正好在网格线上, 这样节点等网格线上的点所在的单元格不随网格的平移和缩放而改变.
+func cellPos(r float64) int {
+	if k := math.Round(r); math.Abs(r-k) <= 1e-9*math.Max(1.0, k) {
+		return int(k) - 1
+	}
+	return int(math.Ceil(r)) - 1
+}
+
 // Cell 根据输入的 (x, y) 坐标得出该点所在的单元格. 如果所输入的坐标超出网格定义域,
 // 或数据尚未赋值, 则返回 *Cell 值为 nil, 且 err 不为 nil.
 func (g *Grid) Cell(x, y float64) (*Cell, error) {
@@ -262,20 +373,16 @@ func (g *Grid) NearCells(x, y float64, layer int) (cells []*Cell, err error) {
 	return g.NearCellsAlt(xi, yi, idx, layer), nil
 }
 
-// yi, xi, idx 必须是有效值.
+// yi, xi, idx 必须是有效值. 单元格是否在网格之内由其行列号判断, 而不是由其坐标判断,
+// 以免网格远离原点时舍入误差使边界上的单元格被遗漏.
 func (g *Grid) NearCellsAlt(xi, yi, idx, layer int) (cells []*Cell) {
-	x0 := float64(xi)*g.XSpan + g.Range.Xmin
-	y0 := float64(yi)*g.YSpan + g.Range.Ymin
 	n := 2*layer + 1
 	cells = make([]*Cell, 0, n*n)
-	var cxmin, cymin, cxmax, cymax float64
 	for c := 0; c < n; c++ {
 		for r := 0; r < n; r++ {
-			cxmin = x0 - float64((layer-c))*g.XSpan
-			cxmax = cxmin + g.XSpan
-			cymin = y0 - float64((layer-r))*g.YSpan
-			cymax = cymin + g.YSpan
-			if cxmin >= g.Range.Xmin && cymin >= g.Range.Ymin && cxmax <= g.Range.Xmax && cymax <= g.Range.Ymax {
+			cxi := xi - (layer - c)
+			cyi := yi - (layer - r)
+			if cxi >= 0 && cyi >= 0 && cxi < g.CellXN && cyi < g.CellYN {
 				i := idx - (layer-r)*g.CellXN - (layer - c)
 				cells = append(cells, &(g.Cells[i]))
 			}
@@ -301,7 +408,7 @@ func (g *Grid) NearQtyIdxes(x, y float64, layer int) (qis []int, err error) {
 	if err != nil {
 		return nil, err
 	}
-	qis = make([]int, 0, int(math.Ceil(AvgQtyNumPerCell)))
+	qis = make([]int, 0, int(math.Ceil(DefaultDensity)))
 	for _, c := range cells {
 		if c != nil {
 			for _, id := range c.QtyIdxes {
@@ -320,10 +427,8 @@ func (g *Grid) String() string {
 	for yi := 0; yi < g.CellYN; yi++ {
 		for xi := 0; xi < g.CellXN; xi++ {
 			fmt.Fprintf(&b, "(yi: %d,\tcol: %d)\t\t", yi, xi)
-			x0 = float64(xi)*g.XSpan + g.Range.Xmin
-			x1 = x0 + g.XSpan
-			y0 = float64(yi)*g.YSpan + g.Range.Ymin
-			y1 = y0 + g.YSpan
+			x0, y0 = g.lineCoord(xi, yi)
+			x1, y1 = g.lineCoord(xi+1, yi+1)
 			fmt.Fprintf(&b, "[x: %v ~ %v,\ty: %v ~ %v]\t", x0, x1, y0, y1)
 			idx = yi*g.CellXN + xi
 			for _, id := range g.Cells[idx].QtyIdxes {
@@ -1 +1,216 @@
 package grid
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"stj/fieldline/geom"
+)
+
+// TestOffsetGrid 检查不以原点为起点(且坐标为负)的网格中, 单元格, 节点的坐标以及点的寻址都是正确的.
+func TestOffsetGrid(t *testing.T) {
+	r, _ := geom.NewRect(-10.0, -5.0, -2.0, 3.0)
+	g, err := New(*r, 4, 2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	c := g.Cells[g.CellIdx(1, 1)].Range
+	if c.Xmin != -8.0 || c.Xmax != -6.0 || c.Ymin != -1.0 || c.Ymax != 3.0 {
+		t.Errorf("cell (1, 1) covers %v", c)
+	}
+	if n := g.Nodes[g.NodeIdx(4, 2)]; n.X != -2.0 || n.Y != 3.0 {
+		t.Errorf("the last node is at %v", n)
+	}
+	for _, tc := range []struct {
+		x, y   float64
+		xi, yi int
+	}{
+		{-10, -5, 0, 0},
+		{-7.5, 0.5, 1, 1},
+		{-2, 3, 3, 1},
+		{-4.1, -4.9, 2, 0},
+	} {
+		xi, yi, _, err := g.CellPosIdx(tc.x, tc.y)
+		if err != nil || xi != tc.xi || yi != tc.yi {
+			t.Errorf("(%g, %g) is in cell (%d, %d), %v, want (%d, %d)", tc.x, tc.y, xi, yi, err, tc.xi, tc.yi)
+		}
+		cell, _ := g.Cell(tc.x, tc.y)
+		if tc.x < cell.Range.Xmin || tc.x > cell.Range.Xmax || tc.y < cell.Range.Ymin || tc.y > cell.Range.Ymax {
+			t.Errorf("(%g, %g) is not in the range %v of its cell", tc.x, tc.y, cell.Range)
+		}
+		c, ni, err := g.CellNodeIdxes(tc.x, tc.y)
+		want, _ := g.NodeIdxes(tc.x, tc.y)
+		if err != nil || c != cell || ni[0] != want[0] || ni[1] != want[1] || ni[2] != want[2] || ni[3] != want[3] {
+			t.Errorf("CellNodeIdxes(%g, %g) = %v, %v, want %v", tc.x, tc.y, ni, err, want)
+		}
+		ws := c.Weights(tc.x, tc.y)
+		v := ws[0]*1.0 + ws[1]*2.0 + ws[2]*3.0 + ws[3]*4.0
+		if want := c.Value(tc.x, tc.y, 1.0, 2.0, 3.0, 4.0); math.Abs(v-want) > 1e-12 {
+			t.Errorf("weighted value at (%g, %g) is %g, want %g", tc.x, tc.y, v, want)
+		}
+	}
+	if cells, _ := g.NearCells(-9, -4, 1); len(cells) != 4 {
+		t.Errorf("got %d cells around a corner cell, want 4", len(cells))
+	}
+}
+
+// TestCellZeros 检查单元格内两个双线性函数 u = (x-1.5)(y-2.2) 和 v = x+y-4 的公共零点以及 u 的偏导数.
+func TestCellZeros(t *testing.T) {
+	r, _ := geom.NewRect(1.0, 2.0, 3.0, 3.0)
+	g, err := New(*r, 1, 1)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	c := &g.Cells[0]
+	var u, v [4]float64
+	for k, ni := range g.NodeIdxesofCell(0) {
+		n := g.Nodes[ni]
+		u[k], v[k] = (n.X-1.5)*(n.Y-2.2), n.X+n.Y-4.0
+	}
+	ps := c.Zeros(u, v)
+	if len(ps) != 2 || math.Hypot(ps[0].X-1.5, ps[0].Y-2.5) > 1e-12 || math.Hypot(ps[1].X-1.8, ps[1].Y-2.2) > 1e-12 {
+		t.Errorf("got zeros %v, want (1.5, 2.5) and (1.8, 2.2)", ps)
+	}
+	if dx, dy := c.Gradient(2.0, 2.5, u); math.Abs(dx-0.3) > 1e-12 || math.Abs(dy-0.5) > 1e-12 {
+		t.Errorf("got gradient (%g, %g), want (0.3, 0.5)", dx, dy)
+	}
+	// 零点在单元格的角点上
+	for k, ni := range g.NodeIdxesofCell(0) {
+		n := g.Nodes[ni]
+		u[k], v[k] = n.X-3.0, n.Y-2.0
+	}
+	if ps := c.Zeros(u, v); len(ps) != 1 || ps[0].X != 3.0 || ps[0].Y != 2.0 {
+		t.Errorf("got zeros %v, want (3, 2)", ps)
+	}
+	// 公共的零值直线上的点不是孤立零点
+	if ps := c.Zeros(u, u); len(ps) != 0 {
+		t.Errorf("got zeros %v on a common zero line", ps)
+	}
+}
+
+// TestDomain 检查带有方形孔洞的定义域中单元格和节点的位置标记.
+func TestDomain(t *testing.T) {
+	square := func(a, b float64) geom.Polygon {
+		return geom.Polygon{{X: a, Y: a}, {X: b, Y: a}, {X: b, Y: b}, {X: a, Y: b}}
+	}
+	d, err := ReadDomain(strings.NewReader("# outer\n0 0\n10 0\n10 10\n0 10\n\n3.5,3.5\n3.5,6.5\n6.5,6.5\n6.5,3.5\n"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(d.Holes) != 1 || d.Holes[0].Area() != -9.0 || d.Outer.Area() != square(0, 10).Area() {
+		t.Fatalf("got domain %v", *d)
+	}
+	for _, c := range []struct {
+		x, y float64
+		in   bool
+	}{{1, 1, true}, {5, 5, false}, {3.5, 5, true}, {10, 10, true}, {11, 5, false}, {3.6, 3.6, false}} {
+		if d.Contains(c.x, c.y) != c.in {
+			t.Errorf("Contains(%g, %g) = %v", c.x, c.y, !c.in)
+		}
+	}
+	r, _ := geom.NewRect(0, 0, 10, 10)
+	g, _ := New(*r, 10, 10)
+	g.SetDomain(d)
+	for ci := 0; ci < g.CellNum; ci++ {
+		xi, yi := g.CellPos(ci)
+		want := Inside
+		switch {
+		case xi >= 4 && xi <= 5 && yi >= 4 && yi <= 5:
+			want = Outside
+		case xi >= 3 && xi <= 6 && yi >= 3 && yi <= 6:
+			want = Boundary
+		}
+		if g.CellLoc(ci) != want {
+			t.Errorf("cell (%d, %d) is %v, want %v", xi, yi, g.CellLoc(ci), want)
+		}
+	}
+	for ni := 0; ni < g.NodeNum; ni++ {
+		xi, yi := g.NodePos(ni)
+		want := Inside
+		switch {
+		case xi == 5 && yi == 5:
+			want = Outside
+		case xi >= 4 && xi <= 6 && yi >= 4 && yi <= 6:
+			want = Boundary
+		}
+		if g.NodeLoc(ni) != want {
+			t.Errorf("node (%d, %d) is %v, want %v", xi, yi, g.NodeLoc(ni), want)
+		}
+	}
+	// 由单元格位置判断的结果与逐边检查的结果相同, 包括网格线和定义域边界上的点
+	for x := -0.5; x <= 10.5; x += 0.25 {
+		for y := -0.5; y <= 10.5; y += 0.25 {
+			if g.InDomain(x, y) != d.Contains(x, y) {
+				t.Errorf("InDomain(%g, %g) = %v", x, y, !d.Contains(x, y))
+			}
+		}
+	}
+	if g.SetDomain(nil); g.CellLoc(g.CellIdx(4, 4)) != Inside || !g.InDomain(5, 5) {
+		t.Error("the domain has not been cleared")
+	}
+}
+
+// TestIndex 检查四叉树和网格索引的查找结果都与逐点比较的结果相同. 数据点大部分集中在一个小区域内.
+func TestIndex(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	var ps []geom.Point
+	for i := 0; i < 2000; i++ {
+		if i%10 == 0 {
+			ps = append(ps, geom.Point{X: 100 * rnd.Float64(), Y: 50 * rnd.Float64()})
+		} else {
+			ps = append(ps, geom.Point{X: 60 + 0.5*rnd.Float64(), Y: 20 + 0.5*rnd.Float64()})
+		}
+	}
+	ps = append(ps, ps[3], ps[4]) // 重合点
+	r, _ := geom.NewRect(0, 0, 100, 50)
+	g, _ := New(*r, 20, 10)
+	for i, p := range ps {
+		if err := g.Add(p.X, p.Y, i); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	brute := func(x, y float64) []Neighbor {
+		ns := make([]Neighbor, len(ps))
+		for i, p := range ps {
+			ns[i] = Neighbor{Idx: i, Dist: math.Hypot(p.X-x, p.Y-y)}
+		}
+		SortNeighbors(ns)
+		return ns
+	}
+	same := func(a, b []Neighbor) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+	idxes := map[string]Index{"quadtree": NewQuadtree(ps, 0), "quadtree1": NewQuadtree(ps, 1), "grid": NewGridIndex(g, ps)}
+	for _, q := range []geom.Point{{X: 60.2, Y: 20.3}, {X: 10, Y: 40}, {X: -20, Y: 70}, ps[3]} {
+		all := brute(q.X, q.Y)
+		for name, idx := range idxes {
+			for _, k := range []int{1, 7, 50, len(ps) + 5} {
+				want := all
+				if k < len(all) {
+					want = all[:k]
+				}
+				if got := idx.KNearest(q.X, q.Y, k); !same(got, want) {
+					t.Errorf("%s: KNearest(%v, %d) differs from the brute force search", name, q, k)
+				}
+			}
+			rad := all[30].Dist
+			n := 31
+			for n < len(all) && all[n].Dist <= rad {
+				n++
+			}
+			if got := idx.Within(q.X, q.Y, rad); !same(got, all[:n]) {
+				t.Errorf("%s: Within(%v, %g) returns %d points, want %d", name, q, rad, len(got), n)
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package grid
+
+import (
+	"math"
+	"sort"
+
+	"stj/fieldline/geom"
+)
+
+// Neighbor 表示邻近点查找的一个结果, Idx 为点的索引, Dist 为点到查找点的距离.
+type Neighbor struct {
+	Idx  int
+	Dist float64
+}
+
+// Index 接口表示平面上离散点的空间索引, 用于查找一个点附近的数据点. 返回的结果都按距离由近到远排列,
+// 距离相等时按索引由小到大排列, 因而结果是确定的. Quadtree 和由 NewGridIndex 创建的网格索引都实现了该接口.
+type Index interface {
+	// KNearest 返回距点 (x, y) 最近的 k 个点. 当点的总数不足 k 个时, 返回所有的点.
+	KNearest(x, y float64, k int) []Neighbor
+	// Within 返回与点 (x, y) 的距离不大于 r 的所有点.
+	Within(x, y, r float64) []Neighbor
+}
+
+// SortNeighbors 将查找结果按距离由近到远排列, 距离相等时按索引由小到大排列, 即 Index 接口所要求的顺序.
+func SortNeighbors(ns []Neighbor) {
+	sort.Slice(ns, func(i, j int) bool {
+		if ns[i].Dist != ns[j].Dist {
+			return ns[i].Dist < ns[j].Dist
+		}
+		return ns[i].Idx < ns[j].Idx
+	})
+}
+
+// gridIndex 利用网格的单元格实现 Index 接口.
+type gridIndex struct {
+	g      *Grid
+	points []geom.Point
+}
+
+// NewGridIndex 利用网格 g 中各单元格所包含的点的索引创建一个空间索引, ps[i] 为索引为 i 的点的坐标.
+// 它适用于点距大致均匀的数据; 点距相差悬殊时, 大部分单元格是空的, 而少数单元格包含大量的点, 应使用 Quadtree.
+func NewGridIndex(g *Grid, ps []geom.Point) Index {
+	return &gridIndex{g: g, points: ps}
+}
+
+// collect 方法将列, 行数为 (xi, yi) 的单元格中的点添加到 ns 中.
+func (gi *gridIndex) collect(ns []Neighbor, xi, yi int, x, y float64) []Neighbor {
+	for _, i := range gi.g.Cells[gi.g.CellIdx(xi, yi)].QtyIdxes {
+		p := gi.points[i]
+		ns = append(ns, Neighbor{Idx: i, Dist: math.Hypot(p.X-x, p.Y-y)})
+	}
+	return ns
+}
+
+// KNearest 方法由点 (x, y) 所在的单元格开始逐层向外查找, 直到已找到的第 k 个点比尚未查找的单元格更近为止.
+func (gi *gridIndex) KNearest(x, y float64, k int) []Neighbor {
+	g := gi.g
+	if k > len(gi.points) {
+		k = len(gi.points)
+	}
+	if k <= 0 {
+		return nil
+	}
+	xi, yi := g.clampedCellPos(x, y)
+	var ns []Neighbor
+	for layer := 0; ; layer++ {
+		x0, x1, y0, y1 := xi-layer, xi+layer, yi-layer, yi+layer
+		for cy := y0; cy <= y1; cy++ {
+			if cy < 0 || cy >= g.CellYN {
+				continue
+			}
+			for cx := x0; cx <= x1; cx++ {
+				if cx < 0 || cx >= g.CellXN || (cy != y0 && cy != y1 && cx != x0 && cx != x1) {
+					continue
+				}
+				ns = gi.collect(ns, cx, cy, x, y)
+			}
+		}
+		// 尚未查找的点与 (x, y) 的最短距离不小于 (x, y) 到已查找区域边界的距离
+		bound := math.Inf(1)
+		if x0 > 0 {
+			bound = math.Min(bound, x-(g.Range.Xmin+float64(x0)*g.XSpan))
+		}
+		if x1 < g.CellXN-1 {
+			bound = math.Min(bound, g.Range.Xmin+float64(x1+1)*g.XSpan-x)
+		}
+		if y0 > 0 {
+			bound = math.Min(bound, y-(g.Range.Ymin+float64(y0)*g.YSpan))
+		}
+		if y1 < g.CellYN-1 {
+			bound = math.Min(bound, g.Range.Ymin+float64(y1+1)*g.YSpan-y)
+		}
+		SortNeighbors(ns)
+		if len(ns) >= k && ns[k-1].Dist <= bound || math.IsInf(bound, 1) {
+			return ns[:k]
+		}
+	}
+}
+
+// Within 方法查找与点 (x, y) 的距离不大于 r 的点所在的各个单元格.
+func (gi *gridIndex) Within(x, y, r float64) []Neighbor {
+	g := gi.g
+	x0, y0 := g.clampedCellPos(x-r, y-r)
+	x1, y1 := g.clampedCellPos(x+r, y+r)
+	var ns []Neighbor
+	for cy := y0; cy <= y1; cy++ {
+		for cx := x0; cx <= x1; cx++ {
+			for _, n := range gi.collect(nil, cx, cy, x, y) {
+				if n.Dist <= r {
+					ns = append(ns, n)
+				}
+			}
+		}
+	}
+	SortNeighbors(ns)
+	return ns
+}
@@ -0,0 +1,171 @@
+package grid
+
+import (
+	"container/heap"
+	"math"
+
+	"stj/fieldline/geom"
+)
+
+// DefaultQuadLeafSize 为四叉树的一个叶节点中默认最多容纳的点数.
+const DefaultQuadLeafSize = 8
+
+// quadMaxDepth 为四叉树的最大深度, 以免大量重合的点使节点无限细分.
+const quadMaxDepth = 32
+
+// Quadtree 是一个自适应的四叉树空间索引: 点密集的地方节点细分得多, 点稀疏的地方节点细分得少.
+// 与单元格大小相同的 Grid 相比, 它适用于点距相差悬殊的数据, 如在缺口附近加密了上百倍的有限元网格的节点.
+type Quadtree struct {
+	points   []geom.Point
+	leafSize int // 一个叶节点中最多容纳的点数, 超过该值时叶节点被分为 4 个子节点
+	root     *quadNode
+}
+
+// quadNode 是四叉树的一个节点. 叶节点的 kids 为 nil, 点的索引存储在 idxes 中.
+type quadNode struct {
+	r     geom.Rect
+	idxes []int
+	kids  []*quadNode
+}
+
+// NewQuadtree 为点集 ps 创建一个四叉树索引, 点的索引即其在 ps 中的位置, 一个叶节点中最多容纳 leafSize 个点.
+// 若 leafSize <= 0, 则取为 DefaultQuadLeafSize. 四叉树引用而不复制 ps, 创建之后不应再修改 ps 中各点的坐标.
+func NewQuadtree(ps []geom.Point, leafSize int) *Quadtree {
+	if leafSize <= 0 {
+		leafSize = DefaultQuadLeafSize
+	}
+	q := &Quadtree{points: ps, leafSize: leafSize}
+	r := geom.Rect{Xmin: math.Inf(1), Ymin: math.Inf(1), Xmax: math.Inf(-1), Ymax: math.Inf(-1)}
+	idxes := make([]int, len(ps))
+	for i, p := range ps {
+		r.Xmin, r.Xmax = math.Min(r.Xmin, p.X), math.Max(r.Xmax, p.X)
+		r.Ymin, r.Ymax = math.Min(r.Ymin, p.Y), math.Max(r.Ymax, p.Y)
+		idxes[i] = i
+	}
+	q.root = q.build(r, idxes, 0)
+	return q
+}
+
+// build 方法创建一个范围为 r, 包含点 idxes 的节点.
+func (q *Quadtree) build(r geom.Rect, idxes []int, depth int) *quadNode {
+	n := &quadNode{r: r}
+	if len(idxes) <= q.leafSize || depth >= quadMaxDepth {
+		n.idxes = idxes
+		return n
+	}
+	xm, ym := 0.5*(r.Xmin+r.Xmax), 0.5*(r.Ymin+r.Ymax)
+	var parts [4][]int
+	for _, i := range idxes {
+		k := 0
+		if q.points[i].X > xm {
+			k++
+		}
+		if q.points[i].Y > ym {
+			k += 2
+		}
+		parts[k] = append(parts[k], i)
+	}
+	rs := [4]geom.Rect{
+		{Xmin: r.Xmin, Ymin: r.Ymin, Xmax: xm, Ymax: ym},
+		{Xmin: xm, Ymin: r.Ymin, Xmax: r.Xmax, Ymax: ym},
+		{Xmin: r.Xmin, Ymin: ym, Xmax: xm, Ymax: r.Ymax},
+		{Xmin: xm, Ymin: ym, Xmax: r.Xmax, Ymax: r.Ymax},
+	}
+	for k, p := range parts {
+		if len(p) > 0 {
+			n.kids = append(n.kids, q.build(rs[k], p, depth+1))
+		}
+	}
+	return n
+}
+
+// rectDist 返回点 (x, y) 到矩形 r 的最短距离, 点在矩形内时为 0.
+func rectDist(r *geom.Rect, x, y float64) float64 {
+	dx := math.Max(0.0, math.Max(r.Xmin-x, x-r.Xmax))
+	dy := math.Max(0.0, math.Max(r.Ymin-y, y-r.Ymax))
+	return math.Hypot(dx, dy)
+}
+
+// quadItem 是最近点查找时优先队列中的一项, 它是一个节点(node 不为 nil)或一个点.
+type quadItem struct {
+	node *quadNode
+	Neighbor
+}
+
+// quadQueue 是按距离排列的优先队列. 距离相等时节点排在点之前, 以保证与之等距的点都已进入队列,
+// 点再按索引排列.
+type quadQueue []quadItem
+
+func (h quadQueue) Len() int { return len(h) }
+func (h quadQueue) Less(i, j int) bool {
+	if h[i].Dist != h[j].Dist {
+		return h[i].Dist < h[j].Dist
+	}
+	if (h[i].node == nil) != (h[j].node == nil) {
+		return h[i].node != nil
+	}
+	return h[i].Idx < h[j].Idx
+}
+func (h quadQueue) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *quadQueue) Push(x interface{}) { *h = append(*h, x.(quadItem)) }
+func (h *quadQueue) Pop() interface{} {
+	old := *h
+	it := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return it
+}
+
+// KNearest 方法返回距点 (x, y) 最近的 k 个点. 采用最佳优先搜索: 按距离由近到远依次展开节点, 因此只访问
+// 与结果有关的少数节点.
+func (q *Quadtree) KNearest(x, y float64, k int) []Neighbor {
+	if k > len(q.points) {
+		k = len(q.points)
+	}
+	if k <= 0 {
+		return nil
+	}
+	ns := make([]Neighbor, 0, k)
+	h := &quadQueue{{node: q.root, Neighbor: Neighbor{Dist: rectDist(&q.root.r, x, y)}}}
+	for h.Len() > 0 && len(ns) < k {
+		it := heap.Pop(h).(quadItem)
+		switch {
+		case it.node == nil:
+			ns = append(ns, it.Neighbor)
+		case it.node.kids == nil:
+			for _, i := range it.node.idxes {
+				p := q.points[i]
+				heap.Push(h, quadItem{Neighbor: Neighbor{Idx: i, Dist: math.Hypot(p.X-x, p.Y-y)}})
+			}
+		default:
+			for _, c := range it.node.kids {
+				heap.Push(h, quadItem{node: c, Neighbor: Neighbor{Dist: rectDist(&c.r, x, y)}})
+			}
+		}
+	}
+	return ns
+}
+
+// Within 方法返回与点 (x, y) 的距离不大于 r 的所有点.
+func (q *Quadtree) Within(x, y, r float64) []Neighbor {
+	var ns []Neighbor
+	var walk func(n *quadNode)
+	walk = func(n *quadNode) {
+		if rectDist(&n.r, x, y) > r {
+			return
+		}
+		for _, c := range n.kids {
+			walk(c)
+		}
+		for _, i := range n.idxes {
+			p := q.points[i]
+			if d := math.Hypot(p.X-x, p.Y-y); d <= r {
+				ns = append(ns, Neighbor{Idx: i, Dist: d})
+			}
+		}
+	}
+	if len(q.points) > 0 {
+		walk(q.root)
+	}
+	SortNeighbors(ns)
+	return ns
+}
@@ -19,7 +19,8 @@ type Region interface {
 	// 其值只应该是 PointRegionType, CurveRegionType, RegionRegionType 或 CompositeRegionType.
 	Type() int
 	// Index 表示向量或张量场中某个孤立奇点的庞加莱(Polincare) 指数, 即所谓的向量指数或张量指数.
-	//Index() int
+	// 向量指数为整数, 张量指数为半整数(如 ±1/2), 因而以 float64 表示.
+	Index() float64
 }
 
 // shape 结构体代表网格中几何形体的实际表示. 该结构体是各类退化形状的基类, 它实现了 Region 接口.
@@ -32,6 +33,12 @@ func (s *shape) Type() int {
 	return s.shapeType
 }
 
+// Index 方法返回 0. 由零节点解析所得的形状不带有场的方向信息, 无法求其指数; 曲线和区域也不是孤立的奇点.
+// 孤立奇点的指数可由 field 包中的 CriticalPoints 和 DegeneratePoints 方法求得.
+func (s *shape) Index() float64 {
+	return 0.0
+}
+
 // PointRegion 是向量或张量场中的一个
 // 在张量场中, 该点实际上是一个脐点(Umbilical Point), 通常称为退化点(Degenerate Point).
 type PointRegion struct {
@@ -0,0 +1,206 @@
+/*
+mesh 包实现了由三角形和四边形单元组成的二维有限元网格. 它保留了数值模拟结果中节点之间的连接关系,
+使得场中任一点的值可以由其所在单元各节点的值通过形函数插值求得:
+
+	三角形单元(3 个节点): 线性形函数, 即面积坐标;
+	四边形单元(4 个节点): 双线性形函数, 由点的坐标反求其在单元中的局部坐标.
+
+为了快速找到一点所在的单元, 网格中的所有单元都按其外接矩形登记到一个 grid.Grid 中.
+*/
+package mesh
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/grid"
+)
+
+// eps 是判断一点是否在单元内时所允许的(局部坐标或面积坐标)误差.
+const eps = 1e-9
+
+// maxNewtonIter 是反求四边形单元局部坐标时牛顿迭代的最大次数.
+const maxNewtonIter = 20
+
+// Element 是网格中的一个单元, 其元素依次为单元各个顶点在 Mesh.Nodes 中的索引.
+// 三角形单元有 3 个顶点, 四边形单元有 4 个顶点, 四边形的顶点应沿其边界依次排列(顺时针或逆时针均可).
+type Element []int
+
+// Mesh 是由三角形和四边形单元组成的二维网格.
+type Mesh struct {
+	Nodes    []geom.Point
+	Elements []Element
+	Range    geom.Rect  // 所有节点的坐标范围
+	index    *grid.Grid // 单元索引, 其中各单元格的 QtyIdxes 为与该单元格相交的单元
+}
+
+// New 根据节点坐标和单元连接关系创建一个网格.
+func New(nodes []geom.Point, elems []Element) (m *Mesh, err error) {
+	if len(nodes) == 0 || len(elems) == 0 {
+		return nil, errors.New("no nodes or elements given")
+	}
+	for i, e := range elems {
+		if len(e) != 3 && len(e) != 4 {
+			return nil, fmt.Errorf("element %d has %d nodes, only triangles and quadrilaterals are supported", i, len(e))
+		}
+		for _, ni := range e {
+			if ni < 0 || ni >= len(nodes) {
+				return nil, fmt.Errorf("element %d refers to node %d, which does not exist", i, ni)
+			}
+		}
+	}
+	m = &Mesh{Nodes: nodes, Elements: elems}
+	m.Range = bounds(nodes)
+	if m.Range.Xmin >= m.Range.Xmax || m.Range.Ymin >= m.Range.Ymax {
+		return nil, errors.New("wrong region parameters")
+	}
+	if err := m.buildIndex(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// buildIndex 方法创建单元索引. 单元格的个数根据单元个数和 grid.DefaultDensity 确定,
+// 与 field 包中根据数据点个数创建网格的方法相同.
+func (m *Mesh) buildIndex() error {
+	r := m.Range
+	n := float64(len(m.Elements))
+	xl, yl := r.Xmax-r.Xmin, r.Ymax-r.Ymin
+	cellXN := int(math.Ceil(math.Sqrt(n * xl / (grid.DefaultDensity * yl))))
+	cellYN := int(math.Ceil(math.Sqrt(n * yl / (grid.DefaultDensity * xl))))
+	g, err := grid.New(r, cellXN, cellYN)
+	if err != nil {
+		return err
+	}
+	for ei, e := range m.Elements {
+		ps := make([]geom.Point, len(e))
+		for k, ni := range e {
+			ps[k] = m.Nodes[ni]
+		}
+		b := bounds(ps)
+		xi0, yi0, _, _ := g.CellPosIdx(b.Xmin, b.Ymin)
+		xi1, yi1, _, _ := g.CellPosIdx(b.Xmax, b.Ymax)
+		for yi := yi0; yi <= yi1; yi++ {
+			for xi := xi0; xi <= xi1; xi++ {
+				c := &g.Cells[g.CellIdx(xi, yi)]
+				c.QtyIdxes = append(c.QtyIdxes, ei)
+			}
+		}
+	}
+	m.index = g
+	return nil
+}
+
+// Locate 方法返回点 (x, y) 所在单元的索引 ei, 以及该单元各个形函数在该点处的值 w,
+// w 中的元素与单元的各个顶点一一对应. 若该点不在任何单元之内, 则返回一个错误.
+func (m *Mesh) Locate(x, y float64) (ei int, w []float64, err error) {
+	c, err := m.index.Cell(x, y)
+	if err != nil {
+		return -1, nil, err
+	}
+	for _, ei := range c.QtyIdxes {
+		if w, ok := m.shape(ei, x, y); ok {
+			return ei, w, nil
+		}
+	}
+	return -1, nil, fmt.Errorf("the point (%g, %g) is not inside any element of the mesh", x, y)
+}
+
+// Interpolate 方法利用形函数求得点 (x, y) 处的值. v 返回索引为 ni 的节点处的值.
+func (m *Mesh) Interpolate(x, y float64, v func(ni int) float64) (float64, error) {
+	ei, w, err := m.Locate(x, y)
+	if err != nil {
+		return 0.0, err
+	}
+	sum := 0.0
+	for k, ni := range m.Elements[ei] {
+		sum += w[k] * v(ni)
+	}
+	return sum, nil
+}
+
+// shape 方法计算索引为 ei 的单元在点 (x, y) 处的形函数值. 若该点不在单元内, 则 ok 为 false.
+func (m *Mesh) shape(ei int, x, y float64) (w []float64, ok bool) {
+	e := m.Elements[ei]
+	if len(e) == 3 {
+		return triShape(m.Nodes[e[0]], m.Nodes[e[1]], m.Nodes[e[2]], x, y)
+	}
+	return quadShape([4]geom.Point{m.Nodes[e[0]], m.Nodes[e[1]], m.Nodes[e[2]], m.Nodes[e[3]]}, x, y)
+}
+
+// triShape 计算三角形 abc 中点 (x, y) 的面积坐标, 即线性三角形单元的形函数值.
+func triShape(a, b, c geom.Point, x, y float64) (w []float64, ok bool) {
+	det := (b.X-a.X)*(c.Y-a.Y) - (c.X-a.X)*(b.Y-a.Y)
+	if det == 0.0 {
+		return nil, false
+	}
+	l1 := ((b.X-x)*(c.Y-y) - (c.X-x)*(b.Y-y)) / det
+	l2 := ((c.X-x)*(a.Y-y) - (a.X-x)*(c.Y-y)) / det
+	l3 := 1.0 - l1 - l2
+	if l1 < -eps || l2 < -eps || l3 < -eps {
+		return nil, false
+	}
+	return []float64{l1, l2, l3}, true
+}
+
+// quadShape 计算四边形单元中点 (x, y) 处的双线性形函数值. 其局部坐标 (xi, eta) 由牛顿迭代法反求得出,
+// 四个顶点的局部坐标依次为 (-1, -1), (1, -1), (1, 1), (-1, 1).
+func quadShape(p [4]geom.Point, x, y float64) (w []float64, ok bool) {
+	xs := [4]float64{-1, 1, 1, -1}
+	es := [4]float64{-1, -1, 1, 1}
+	size := math.Abs(p[2].X-p[0].X) + math.Abs(p[2].Y-p[0].Y) + math.Abs(p[3].X-p[1].X) + math.Abs(p[3].Y-p[1].Y)
+	xi, eta := 0.0, 0.0
+	for iter := 0; iter < maxNewtonIter; iter++ {
+		// 残差及雅可比矩阵
+		fx, fy := -x, -y
+		var j11, j12, j21, j22 float64
+		for k := 0; k < 4; k++ {
+			n := 0.25 * (1 + xs[k]*xi) * (1 + es[k]*eta)
+			dxi := 0.25 * xs[k] * (1 + es[k]*eta)
+			deta := 0.25 * es[k] * (1 + xs[k]*xi)
+			fx += n * p[k].X
+			fy += n * p[k].Y
+			j11 += dxi * p[k].X
+			j12 += deta * p[k].X
+			j21 += dxi * p[k].Y
+			j22 += deta * p[k].Y
+		}
+		det := j11*j22 - j12*j21
+		if det == 0.0 {
+			return nil, false
+		}
+		dxi := (j22*fx - j12*fy) / det
+		deta := (j11*fy - j21*fx) / det
+		xi -= dxi
+		eta -= deta
+		if math.Abs(fx)+math.Abs(fy) <= eps*size {
+			break
+		}
+		// 远离单元的点可能使迭代发散, 这时可以确定该点不在单元内
+		if math.Abs(xi) > 10 || math.Abs(eta) > 10 {
+			return nil, false
+		}
+	}
+	if math.Abs(xi) > 1+1e-6 || math.Abs(eta) > 1+1e-6 {
+		return nil, false
+	}
+	w = make([]float64, 4)
+	for k := 0; k < 4; k++ {
+		w[k] = 0.25 * (1 + xs[k]*xi) * (1 + es[k]*eta)
+	}
+	return w, true
+}
+
+// bounds 返回一组点的坐标范围.
+func bounds(ps []geom.Point) geom.Rect {
+	r := geom.Rect{Xmin: ps[0].X, Xmax: ps[0].X, Ymin: ps[0].Y, Ymax: ps[0].Y}
+	for _, p := range ps[1:] {
+		r.Xmin = math.Min(r.Xmin, p.X)
+		r.Xmax = math.Max(r.Xmax, p.X)
+		r.Ymin = math.Min(r.Ymin, p.Y)
+		r.Ymax = math.Max(r.Ymax, p.Y)
+	}
+	return r
+}
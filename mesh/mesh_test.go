@@ -0,0 +1,77 @@
+package mesh_test
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/geom"
+	"stj/fieldline/mesh"
+)
+
+// newMesh 创建一个 2x1 的网格, 左侧为一个(歪斜的)四边形单元, 右侧为两个三角形单元.
+//
+//	3 ---- 4 ---- 5
+//	|      |    / |
+//	|      |  /   |
+//	0 ---- 1 ---- 2
+func newMesh(t *testing.T) *mesh.Mesh {
+	nodes := []geom.Point{{X: 0, Y: 0}, {X: 1.2, Y: 0}, {X: 2, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}, {X: 2, Y: 1}}
+	elems := []mesh.Element{{0, 1, 4, 3}, {1, 2, 5}, {1, 5, 4}}
+	m, err := mesh.New(nodes, elems)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return m
+}
+
+func TestLocate(t *testing.T) {
+	m := newMesh(t)
+	for _, tc := range []struct {
+		x, y float64
+		ei   int
+	}{
+		{0.5, 0.5, 0},
+		{1.8, 0.2, 1},
+		{1.3, 0.8, 2},
+		{0, 0, 0},
+		{2, 1, 1},
+	} {
+		ei, w, err := m.Locate(tc.x, tc.y)
+		if err != nil || ei != tc.ei {
+			t.Errorf("(%g, %g): got element %d, %v, want %d", tc.x, tc.y, ei, err, tc.ei)
+			continue
+		}
+		sum := 0.0
+		for _, v := range w {
+			sum += v
+		}
+		if math.Abs(sum-1.0) > 1e-9 {
+			t.Errorf("(%g, %g): the shape functions sum to %g", tc.x, tc.y, sum)
+		}
+	}
+	if _, _, err := m.Locate(3, 0.5); err == nil {
+		t.Error("a point outside the mesh should not be located")
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	m := newMesh(t)
+	// 线性函数在三角形和平行四边形单元中都可以被精确重现, 在一般四边形中重现节点处的值
+	f := func(p geom.Point) float64 { return 3*p.X - 2*p.Y + 1 }
+	v := func(ni int) float64 { return f(m.Nodes[ni]) }
+	for _, p := range []geom.Point{{X: 1.6, Y: 0.3}, {X: 1.4, Y: 0.9}, {X: 1.2, Y: 0}, {X: 0, Y: 1}} {
+		got, err := m.Interpolate(p.X, p.Y, v)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if math.Abs(got-f(p)) > 1e-9 {
+			t.Errorf("(%g, %g): got %g, want %g", p.X, p.Y, got, f(p))
+		}
+	}
+	if _, err := mesh.New(m.Nodes, []mesh.Element{{0, 1}}); err == nil {
+		t.Error("an element with 2 nodes should be rejected")
+	}
+	if _, err := mesh.New(m.Nodes, []mesh.Element{{0, 1, 9}}); err == nil {
+		t.Error("an element with a nonexistent node should be rejected")
+	}
+}
@@ -0,0 +1,53 @@
+package num
+
+import (
+	"math"
+	"sort"
+)
+
+// CubicRoots 求一元三次方程 a*x^3 + b*x^2 + c*x + d = 0 的实根, 按由小到大的顺序返回. 重根只返回一次;
+// 若 a 为 0, 则按 QuadRoots 求解. 先将方程化为不含二次项的形式, 有三个实根时用三角函数公式, 否则用卡尔达诺公式求解,
+// 最后对每个根进行一次牛顿迭代以减小舍入误差.
+func CubicRoots(a, b, c, d float64) []float64 {
+	if a == 0.0 {
+		return QuadRoots(b, c, d)
+	}
+	b, c, d = b/a, c/a, d/a
+	// 令 x = t - b/3, 得 t^3 + p*t + q = 0
+	p := c - b*b/3.0
+	q := 2.0*b*b*b/27.0 - b*c/3.0 + d
+	disc := q*q/4.0 + p*p*p/27.0
+	var ts []float64
+	switch {
+	case p == 0.0 && q == 0.0: // 三重根
+		ts = []float64{0.0}
+	case disc > 0.0: // 一个实根
+		u := -math.Copysign(math.Cbrt(math.Abs(q)/2.0+math.Sqrt(disc)), q)
+		ts = []float64{u - p/(3.0*u)}
+	case disc == 0.0: // 一个单根和一个二重根
+		ts = []float64{3.0 * q / p, -1.5 * q / p}
+	default: // 三个不等的实根, 这时必有 p < 0
+		r := 2.0 * math.Sqrt(-p/3.0)
+		phi := math.Acos(math.Max(-1.0, math.Min(1.0, 3.0*q/(p*r)))) / 3.0
+		for k := 0.0; k < 3.0; k++ {
+			ts = append(ts, r*math.Cos(phi-2.0*math.Pi*k/3.0))
+		}
+	}
+	xs := make([]float64, 0, len(ts))
+	for _, t := range ts {
+		x := t - b/3.0
+		if df := (3.0*x+2.0*b)*x + c; df != 0.0 {
+			x -= ((x+b)*x*x + c*x + d) / df
+		}
+		xs = append(xs, x)
+	}
+	sort.Float64s(xs)
+	n := 1
+	for i := 1; i < len(xs); i++ {
+		if xs[i] != xs[n-1] {
+			xs[n] = xs[i]
+			n++
+		}
+	}
+	return xs[:n]
+}
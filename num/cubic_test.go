@@ -0,0 +1,37 @@
+package num_test
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/num"
+)
+
+func TestCubicRoots(t *testing.T) {
+	for _, tc := range []struct {
+		a, b, c, d float64
+		want       []float64
+	}{
+		{1, -6, 11, -6, []float64{1, 2, 3}},
+		{2, -12, 22, -12, []float64{1, 2, 3}},
+		{1, 0, 0, -1, []float64{1}},
+		{1, 0, -3, 2, []float64{-2, 1}},
+		{1, -3, 3, -1, []float64{1}},
+		{1, 0, 1, 0, []float64{0}},
+		{-1, 0, 4, 0, []float64{-2, 0, 2}},
+		{0, 1, -3, 2, []float64{1, 2}},
+		{1, -1000, -1, 1000, []float64{-1, 1, 1000}},
+	} {
+		got := num.CubicRoots(tc.a, tc.b, tc.c, tc.d)
+		if len(got) != len(tc.want) {
+			t.Errorf("CubicRoots(%g, %g, %g, %g) = %v, want %v", tc.a, tc.b, tc.c, tc.d, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i]-tc.want[i]) > 1e-9*math.Max(1.0, math.Abs(tc.want[i])) {
+				t.Errorf("CubicRoots(%g, %g, %g, %g) = %v, want %v", tc.a, tc.b, tc.c, tc.d, got, tc.want)
+				break
+			}
+		}
+	}
+}
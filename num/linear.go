@@ -0,0 +1,110 @@
+package num
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// singularTol 为 Solve 判断矩阵奇异时所用的相对容差: 若消元过程中主元的绝对值不大于矩阵元素
+// 最大绝对值的 singularTol 倍, 则认为矩阵奇异.
+const singularTol = 1e-13
+
+// Solve 利用列主元高斯消去法求解线性方程组 a * x = b, 其中 a 为 n 阶方阵, b 为 n 行 m 列的矩阵,
+// 即同时求解系数矩阵相同的 m 个方程组. 求得的解存放在 b 中, a 的内容将被破坏.
+// 若 a 为奇异矩阵, 则返回一个错误.
+func Solve(a, b [][]float64) error {
+	lu, err := Factor(a)
+	if err != nil {
+		return err
+	}
+	return lu.Solve(b)
+}
+
+// LU 为 n 阶方阵 A 的列主元 LU 分解 P * A = L * U. 分解一次之后, 对于系数矩阵相同而右端项不同的方程组,
+// 每次求解只需 O(n^2) 次运算.
+type LU struct {
+	a    [][]float64 // 对角线以下为 L 的元素(L 的对角线元素均为 1, 不存储), 其余为 U 的元素
+	perm []int       // perm[i] 为 P * A 的第 i 行在 A 中的行号
+}
+
+// Factor 对 n 阶方阵 a 进行列主元 LU 分解, 分解结果存放在 a 中, a 原来的内容将被破坏.
+// 若 a 为奇异矩阵, 则返回一个错误.
+func Factor(a [][]float64) (*LU, error) {
+	n := len(a)
+	scale := 0.0
+	for i := range a {
+		if len(a[i]) != n {
+			return nil, errors.New("the coefficient matrix should be square")
+		}
+		for _, v := range a[i] {
+			scale = math.Max(scale, math.Abs(v))
+		}
+	}
+	if scale == 0.0 {
+		return nil, errors.New("the coefficient matrix is singular")
+	}
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	for k := 0; k < n; k++ {
+		p := k
+		for i := k + 1; i < n; i++ {
+			if math.Abs(a[i][k]) > math.Abs(a[p][k]) {
+				p = i
+			}
+		}
+		if math.Abs(a[p][k]) <= singularTol*scale {
+			return nil, errors.New("the coefficient matrix is singular")
+		}
+		a[k], a[p] = a[p], a[k]
+		perm[k], perm[p] = perm[p], perm[k]
+		for i := k + 1; i < n; i++ {
+			f := a[i][k] / a[k][k]
+			a[i][k] = f
+			if f == 0.0 {
+				continue
+			}
+			for j := k + 1; j < n; j++ {
+				a[i][j] -= f * a[k][j]
+			}
+		}
+	}
+	return &LU{a: a, perm: perm}, nil
+}
+
+// Solve 方法求解线性方程组 A * x = b, 其中 b 为 n 行 m 列的矩阵. 求得的解存放在 b 中, 分解结果保持不变,
+// 因而可以反复调用.
+func (lu *LU) Solve(b [][]float64) error {
+	n := len(lu.a)
+	if len(b) != n {
+		return fmt.Errorf("the coefficient matrix has %d rows, but the right-hand side has %d", n, len(b))
+	}
+	y := make([][]float64, n)
+	for i, p := range lu.perm {
+		y[i] = append([]float64(nil), b[p]...)
+	}
+	for i := 0; i < n; i++ {
+		for k := 0; k < i; k++ {
+			if f := lu.a[i][k]; f != 0.0 {
+				for j := range y[i] {
+					y[i][j] -= f * y[k][j]
+				}
+			}
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := range y[i] {
+			s := y[i][j]
+			for k := i + 1; k < n; k++ {
+				s -= lu.a[i][k] * y[k][j]
+			}
+			y[i][j] = s / lu.a[i][i]
+		}
+	}
+	for i := range b {
+		copy(b[i], y[i])
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package num_test
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/num"
+)
+
+func TestSolve(t *testing.T) {
+	// 第一个主元为 0, 必须交换行才能求解
+	a := [][]float64{{0, 2, 1}, {1, 1, 1}, {2, 1, -1}}
+	b := [][]float64{{7, 3}, {6, 3}, {1, 2}}
+	if err := num.Solve(a, b); err != nil {
+		t.Fatal(err.Error())
+	}
+	want := [][]float64{{1, 1}, {2, 1}, {3, 1}}
+	for i := range want {
+		for j := range want[i] {
+			if math.Abs(b[i][j]-want[i][j]) > 1e-12 {
+				t.Errorf("x[%d][%d] = %g, want %g", i, j, b[i][j], want[i][j])
+			}
+		}
+	}
+	a = [][]float64{{1, 2}, {2, 4}}
+	if err := num.Solve(a, [][]float64{{1}, {2}}); err == nil {
+		t.Error("a singular matrix should be reported")
+	}
+
+	// 同一个分解可以反复求解不同的右端项
+	lu, err := num.Factor([][]float64{{0, 2, 1}, {1, 1, 1}, {2, 1, -1}})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, c := range []struct{ b, x []float64 }{{[]float64{7, 6, 1}, []float64{1, 2, 3}}, {[]float64{3, 3, 2}, []float64{1, 1, 1}}} {
+		b := [][]float64{{c.b[0]}, {c.b[1]}, {c.b[2]}}
+		if err := lu.Solve(b); err != nil {
+			t.Fatal(err.Error())
+		}
+		for i := range c.x {
+			if math.Abs(b[i][0]-c.x[i]) > 1e-12 {
+				t.Errorf("x[%d] = %g, want %g", i, b[i][0], c.x[i])
+			}
+		}
+	}
+}
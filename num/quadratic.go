@@ -0,0 +1,30 @@
+package num
+
+import (
+	"math"
+)
+
+// QuadRoots 求一元二次方程 a*x^2 + b*x + c = 0 的实根, 按由小到大的顺序返回. 重根只返回一次;
+// 若 a 为 0, 则按一次方程求解; 若方程没有实根或有无穷多个根(a, b, c 都为 0), 则返回 nil.
+// 为避免两个相近的数相减而损失精度, 先求绝对值较大的根, 再由韦达定理求另一个根.
+func QuadRoots(a, b, c float64) []float64 {
+	if a == 0.0 {
+		if b == 0.0 {
+			return nil
+		}
+		return []float64{-c / b}
+	}
+	d := b*b - 4.0*a*c
+	if d < 0.0 {
+		return nil
+	}
+	if d == 0.0 {
+		return []float64{-0.5 * b / a}
+	}
+	q := -0.5 * (b + math.Copysign(math.Sqrt(d), b))
+	x1, x2 := q/a, c/q
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+	return []float64{x1, x2}
+}
@@ -0,0 +1,35 @@
+package num_test
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/num"
+)
+
+func TestQuadRoots(t *testing.T) {
+	for _, tc := range []struct {
+		a, b, c float64
+		want    []float64
+	}{
+		{1, -3, 2, []float64{1, 2}},
+		{2, 0, -8, []float64{-2, 2}},
+		{1, 2, 1, []float64{-1}},
+		{1, 0, 1, nil},
+		{0, 2, -1, []float64{0.5}},
+		{0, 0, 1, nil},
+		{1, -1e8, 1, []float64{1e-8, 1e8}}, // 直接用求根公式求较小的根会损失全部精度
+	} {
+		got := num.QuadRoots(tc.a, tc.b, tc.c)
+		if len(got) != len(tc.want) {
+			t.Errorf("QuadRoots(%g, %g, %g) = %v, want %v", tc.a, tc.b, tc.c, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i]-tc.want[i]) > 1e-12*math.Max(1.0, math.Abs(tc.want[i])) {
+				t.Errorf("QuadRoots(%g, %g, %g) = %v, want %v", tc.a, tc.b, tc.c, got, tc.want)
+				break
+			}
+		}
+	}
+}
@@ -10,11 +10,14 @@ y1: 计算所得下一点 y 坐标.
 h1: 预计的下一步步长.
 ODE: 计算微分的函数.
 
-关于该包的使用可参见相应的测试文件.
+积分由积分器 Integrator 进行, 每个积分器带有自己的参数和状态, 因而多个积分器可以在不同的 goroutine 中
+同时使用. 关于该包的使用可参见相应的测试文件.
 */
 package ode
 
 import (
+	"errors"
+	"fmt"
 	"math"
 
 	"stj/fieldline/geom"
@@ -29,30 +32,86 @@ const (
 	Right
 )
 
-// RelErrMin 为每个计算步允许的最小误差.
-var RelErrMin float64 = 1.0e-10
+// Options 结构体给出了积分的参数. 各函数中的 *Options 参数为 nil 时使用 DefaultOptions,
+// 否则应是经过 Validate 方法检查的有效参数.
+type Options struct {
+	// RelErrMin 为每个计算步允许的最小误差, RelErrMax 为每个计算步允许的最大误差.
+	RelErrMin, RelErrMax float64
+	// H0 为计算的初始步长, 由于步长主要是根据值 RelErrMin 和
+	// RelErrMax 动态调节的, 该值的大小对计算影响并不大.
+	H0 float64
+	// DistMin 两步计算所得两点间的最小距离. 当计算到边界时,
+	// 若两次计算所得的两点间的距离少于 DistMin, 则终止计算.
+	// 使用该参数可以防止在边界位置计算取点过密.
+	DistMin float64
+}
 
-// RelErrMin 为每个计算步允许的最大误差.
-var RelErrMax float64 = 1.0e-9
+// DefaultOptions 返回一组默认的积分参数.
+func DefaultOptions() *Options {
+	return &Options{RelErrMin: 1.0e-10, RelErrMax: 1.0e-9, H0: 0.1, DistMin: 1.0e-5}
+}
 
-// H0 为计算的初始步长, 由于步长主要是根据值 RelErrMin 和
-// RelErrMax 动态调节的, 该值的大小对计算影响并不大.
-var H0 = 0.1
+// Validate 方法检查各参数是否有效, 以及参数之间是否相互矛盾.
+func (o *Options) Validate() error {
+	if o.RelErrMin <= 0.0 || o.RelErrMax <= 0.0 {
+		return errors.New("the relative error limits should be greater than zero")
+	}
+	if o.RelErrMin >= o.RelErrMax {
+		return errors.New("the minimum relative error should be less than the maximum one")
+	}
+	if o.H0 <= 0.0 || o.DistMin <= 0.0 {
+		return errors.New("the initial step and the minimum distance should be greater than zero")
+	}
+	// 否则第一步计算后即被视为已计算至边界
+	if o.DistMin >= o.H0 {
+		return errors.New("the minimum distance should be less than the initial step")
+	}
+	return nil
+}
 
-// DistMin 两步计算所得两点间的最小距离. 当计算到边界时,
-// 若两次计算所得的两点间的距离少于 DistMin, 则终止计算.
-// 使用该变量可以防止在边界位置计算取点过密.
-var DistMin float64 = 1.0e-5
+// orDefault 方法在 o 为 nil 时返回默认参数, 否则返回 o.
+func (o *Options) orDefault() *Options {
+	if o == nil {
+		return DefaultOptions()
+	}
+	return o
+}
 
 // Theta 是用来和 y0 比较的一个大于零的小值
-var Theta float64 = 1.0e-200
+const Theta float64 = 1.0e-200
+
+// Integrator 是一个积分器, 它带有自己的积分参数以及流线的推进方向等状态. 一个积分器同时只能积分一条流线,
+// 而不同的积分器之间互不影响, 因而可以在多个 goroutine 中各用一个积分器同时积分多条流线.
+type Integrator struct {
+	Options
+	dir int // 上一步运算时流线的推进方向, 0 表示尚未进行运算, 不确定推进方向
+}
+
+// NewIntegrator 创建一个以 o 为参数的积分器. 若 o 为 nil, 则使用默认参数.
+func NewIntegrator(o *Options) *Integrator {
+	return &Integrator{Options: *o.orDefault()}
+}
 
-// dir 存储在上一步运算时流线的推进方向, 默认值 0 表示尚未进行运算, 不确定推进方向.
-var dir0 int
+// Reset 方法清除积分器上一步运算时流线的推进方向, 使下一次 Step 运算如同从种子点开始一样.
+// Steps 和 Solve 方法在开始时都会调用该方法.
+func (it *Integrator) Reset() {
+	it.dir = 0
+}
 
 // ODE 定义了常微分方程的格式. 当 x, y 不在函数的定义域时返回一个错误.
 type ODE func(x, y float64) (deriv float64, err error)
 
+// Masked 返回一个 ODE, 它在 in(x, y) 为 true 的区域内与 f 相同, 在区域以外则返回一个错误.
+// 由此可以使 Steps 和 Solve 的积分在该区域(如有孔洞的非矩形定义域)的边界处终止.
+func Masked(f ODE, in func(x, y float64) bool) ODE {
+	return ODE(func(x, y float64) (deriv float64, err error) {
+		if !in(x, y) {
+			return 0.0, fmt.Errorf("the point (%g, %g) is out of the domain", x, y)
+		}
+		return f(x, y)
+	})
+}
+
 // rf 使用闭包功能返回一个 ODE 类型的函数. 该函数是对输入的 f 函数的二次封装.
 // 从而实现在 x 轴和 y 轴互换时求得任一点的导数. 它将输入的 (x, y) 坐标调换为
 // (y, x), 然后调用 f(y, x) 得到任一点的导数的倒数, 再对该导数求倒得到在 (y, x)
@@ -71,16 +130,23 @@ func rf(f ODE) ODE {
 	})
 }
 
-// Step 函数进行一次单步的常微分方程求解运算. 其中 f 为所求解的常微分方程,
+// Step 方法进行一次单步的常微分方程求解运算. 其中 f 为所求解的常微分方程,
 // (x0, y0) 为初始坐标, h0 为初始步长. 若 h0 为正, 则向右侧或上方(x 轴或
-// y 轴正方向)计算; 否则向左侧或下方(x 轴或 y 轴负方向)计算.
+// y 轴正方向)计算; 否则向左侧或下方(x 轴或 y 轴负方向)计算. 在此之后的计算中,
+// 流线将沿上一步的推进方向继续推进.
 // (x1, y1) 为计算所得的下一点的坐标, h1 为下一步计算合适的步长.
-func Step(f ODE, x0, y0, h0 float64) (x1, y1, h1 float64, err error) {
+func (it *Integrator) Step(f ODE, x0, y0, h0 float64) (x1, y1, h1 float64, err error) {
+	return it.step(f, x0, y0, h0, it.RelErrMin, it.RelErrMax)
+}
+
+// step 方法以 relErrMin 和 relErrMax 为误差要求进行一次单步运算.
+func (it *Integrator) step(f ODE, x0, y0, h0, relErrMin, relErrMax float64) (x1, y1, h1 float64, err error) {
 	s1, err := f(x0, y0)
 	if err != nil {
 		return 0.0, 0.0, 0.0, err
 	}
 
+	dir0 := it.dir
 	dir := direction(dir0, s1, h0)
 	// 矢量的倾角在 (n*π - π/4, n*π + π/4] 之间. 在 xy 坐标系下计算
 	if s1 >= -1.0 && s1 <= 1.0 {
@@ -91,7 +157,7 @@ func Step(f ODE, x0, y0, h0 float64) (x1, y1, h1 float64, err error) {
 		if (dir0 == Up && dir == Left) || (dir0 == Down && dir == Right) {
 			h0 = -h0
 		}
-		x1, y1, h1, err = calcODE(f, x0, y0, h0, s1)
+		x1, y1, h1, err = calcODE(f, x0, y0, h0, s1, relErrMin, relErrMax)
 	} else if s1 < -1.0 || s1 > 1.0 {
 		// 矢量的倾角在 (n*π + π/4, n*π + 3*π/4] 之间. 在 yx 坐标系下计算
 		// 右 --> 上: h 不变
@@ -102,9 +168,9 @@ func Step(f ODE, x0, y0, h0 float64) (x1, y1, h1 float64, err error) {
 			h0 = -h0
 		}
 		s1 = 1.0 / s1
-		y1, x1, h1, err = calcODE(rf(f), y0, x0, h0, s1)
+		y1, x1, h1, err = calcODE(rf(f), y0, x0, h0, s1, relErrMin, relErrMax)
 	}
-	dir0 = dir
+	it.dir = dir
 	return x1, y1, h1, err
 }
 
@@ -177,7 +243,7 @@ func direction(dir0 int, s1, h0 float64) (dir int) {
 	return dir
 }
 
-func calcODE(f ODE, x0, y0, h0, s1 float64) (x1, y1, h1 float64, err error) {
+func calcODE(f ODE, x0, y0, h0, s1, relErrMin, relErrMax float64) (x1, y1, h1 float64, err error) {
 	var s2, s3, s4, s5, s6, hs1, hs2, hs3, hs4, hs5, absErr, relErr, zn float64
 	needReCompute := true
 	firstReCompute := true
@@ -216,16 +282,16 @@ func calcODE(f ODE, x0, y0, h0, s1 float64) (x1, y1, h1 float64, err error) {
 		absErr = math.Abs(zn - y1)
 		relErr = absErr / math.Max(math.Abs(y0), Theta)
 
-		if relErr <= RelErrMax {
+		if relErr <= relErrMax {
 			// 当误差太小时, 适当增加步长以提高计算速度.
-			if relErr < RelErrMin {
+			if relErr < relErrMin {
 				h1 = 1.2 * h1
 			}
 			needReCompute = false
 		} else {
 			// 当误差太大时, 适当减小步长以提高计算精度.
 			if firstReCompute {
-				h1 = 0.8 * h1 * math.Pow(RelErrMax/relErr, 0.2)
+				h1 = 0.8 * h1 * math.Pow(relErrMax/relErr, 0.2)
 				firstReCompute = false
 			} else {
 				h1 = 0.5 * h1
@@ -235,63 +301,98 @@ func calcODE(f ODE, x0, y0, h0, s1 float64) (x1, y1, h1 float64, err error) {
 	return x1, y1, h1, nil
 }
 
-// Steps 函数进行多次的常微分方程求解运算. 其中 f 为所求解的常微分方程, (x0, y0) 为种子点坐
+// Steps 方法进行多次的常微分方程求解运算. 其中 f 为所求解的常微分方程, (x0, y0) 为种子点坐
 // 标, nMax 为最大的计算步数(同时也是可能返回的点的最大个数), 该值防止函数出现无限循环.
 // points 为返回的点列表. forward 为 true 时最初向右侧或上方(x 轴或 y 轴正方向)计算;
 // 否则最初向左侧或下方(x 轴或 y 轴负方向)计算. 若流线连续, 该函数可以沿一个初始方向沿流线一
 // 直推进下去. 在将来需改进算法, 使其能自动动检测闭合的流线.
-func Steps(f ODE, x0, y0 float64, forward bool, nMax int) (points []geom.Point, looped bool) {
+// 当 f 在某点返回错误时, 认为该点在定义域以外: 若种子点在定义域以外, 则不返回任何点; 流线推进至定义域的
+// 边界时, 步长逐渐减小, 直到小于 DistMin 时终止, 因而返回的各点都在定义域内.
+func (it *Integrator) Steps(f ODE, x0, y0 float64, forward bool, nMax int) (points []geom.Point, looped bool) {
+	points, _ = it.StepsUntil(f, x0, y0, forward, nMax, nil)
+	return points, false
+}
+
+// StepsUntil 方法与 Steps 方法相同, 但每求得一个点后即以已求得的所有点调用 stop, 若其返回 true, 则终止计算.
+// 由此调用者可以在流线到达某个点附近或与自身闭合时及时终止. stop 为 nil 时与 Steps 方法完全相同.
+// stopped 表示计算是否由 stop 终止.
+func (it *Integrator) StepsUntil(f ODE, x0, y0 float64, forward bool, nMax int, stop func(points []geom.Point) bool) (points []geom.Point, stopped bool) {
+	it.Reset()
 	points = make([]geom.Point, 0, nMax)
-	relErrMin0, relErrMax0 := RelErrMin, RelErrMax
-	h0 := H0
+	if _, err := f(x0, y0); err != nil {
+		return points, false
+	}
+	relErrMin, relErrMax := it.RelErrMin, it.RelErrMax // 在边界附近临时提高的误差要求
+	h0 := it.H0
 	if !forward {
 		h0 = -h0
 	}
 	x1, y1, h1 := x0, y0, h0
 	var err error
+loop:
 	for i := 1; i <= nMax; i++ {
 		x0, y0, h0 = x1, y1, h1 // 将上步计算的最终状态作为本次计算的初始状态
 		for {
-			x1, y1, h1, err = Step(f, x0, y0, h0)
+			x1, y1, h1, err = it.step(f, x0, y0, h0, relErrMin, relErrMax)
+			if err == nil {
+				_, err = f(x1, y1) // 所得的点也必须在定义域内
+			}
 			if err != nil {
+				if math.Abs(h0) < it.DistMin { // 步长已足够小, 表明已计算至边界
+					break loop
+				}
 				// 如果计算超出范围, 则提高精度, 减小步长
-				RelErrMin = 0.5 * RelErrMin
-				RelErrMax = 0.5 * RelErrMax
+				relErrMin = 0.5 * relErrMin
+				relErrMax = 0.5 * relErrMax
 				h0 = 0.5 * h0
 			} else {
 				// 如果没有返回错误, 则表示完成一步计算, 恢复初始的误差要求, 跳出循环.
 				// 下次计算如果还在边界附近, 需要重新减小误差要求, 这样会降低运算速度,
 				// 但目前还没有好的解决办法.
-				RelErrMin, RelErrMax = relErrMin0, relErrMax0
+				relErrMin, relErrMax = it.RelErrMin, it.RelErrMax
 				break
 			}
 		}
 		// 如果两次计算所得的两点间的距离小于 DistMin, 则说明因无限接近边界而使步长
 		// 已经足够小了, 这是表明已计算至边界, 应终止计算
-		if math.Sqrt(math.Pow(x0-x1, 2.0)+math.Pow(y0-y1, 2.0)) < DistMin {
+		if math.Sqrt(math.Pow(x0-x1, 2.0)+math.Pow(y0-y1, 2.0)) < it.DistMin {
 			break
 		}
 		points = append(points, *geom.NewPoint(x1, y1))
+		if stop != nil && stop(points) {
+			return points, true
+		}
 	}
-	dir0 = 0
 	return points, false
 }
 
-// Solve 函数进行多次的常微分方程求解运算吗, 其与 Steps 函数的不同之处在于当流线不闭合时,
+// Solve 方法进行多次的常微分方程求解运算吗, 其与 Steps 方法的不同之处在于当流线不闭合时,
 // 它自动沿两个不同的顺序推进流线, 并将所得结果点连续排列. 其中 f 为所求解的常微分方程,
 // (x0, y0) 为种子点坐标, h0 为初始步长, nMax 为最大的计算步数(同时也是可能返回的点的最大
 // 个数), 该值防止函数出现无限循环. points 为返回的点列表. 若流线连续, 该函数可以沿一个初始
 // 方向沿流线一直推进下去. 在将来需改进算法, 使其能自动动检测闭合的流线.
-func Solve(f ODE, x0, y0 float64, nMax int) (points []geom.Point, looped bool) {
-	points, looped = Steps(f, x0, y0, true, nMax)
+func (it *Integrator) Solve(f ODE, x0, y0 float64, nMax int) (points []geom.Point, looped bool) {
+	points, looped = it.Steps(f, x0, y0, true, nMax)
 	if !looped {
-		points2, _ := Steps(f, x0, y0, false, nMax)
+		points2, _ := it.Steps(f, x0, y0, false, nMax)
 		reverse(points2)
 		points = append(points2, points...)
 	}
 	return points, looped
 }
 
+// Steps 函数以 o 为参数创建一个积分器, 并用它进行 Steps 运算. 若 o 为 nil, 则使用默认参数.
+// 每次调用都使用一个新的积分器, 因而可以在多个 goroutine 中同时调用.
+func Steps(f ODE, x0, y0 float64, forward bool, nMax int, o *Options) (points []geom.Point, looped bool) {
+	return NewIntegrator(o).Steps(f, x0, y0, forward, nMax)
+}
+
+// Solve 函数以 o 为参数创建一个积分器, 并用它进行 Solve 运算. 若 o 为 nil, 则使用默认参数.
+// 每次调用都使用一个新的积分器, 因而可以在多个 goroutine 中同时调用.
+func Solve(f ODE, x0, y0 float64, nMax int, o *Options) (points []geom.Point, looped bool) {
+	return NewIntegrator(o).Solve(f, x0, y0, nMax)
+}
+
 func reverse(points []geom.Point) {
 	l := len(points)
 	hl := int(math.Floor(float64(l) / 2.0))
@@ -1,10 +1,13 @@
 package ode_test
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"sync"
 	"testing"
 
+	"stj/fieldline/geom"
 	"stj/fieldline/ode"
 )
 
@@ -73,7 +76,7 @@ func TestSolve(t *testing.T) {
 	for _, e := range eqs {
 		fmt.Printf("i\tx\t\ty\t\trealY\n")
 		fmt.Println("-------------------------------------------------------")
-		points, _ := ode.Solve(e.d, e.x0, e.y0, nMax)
+		points, _ := ode.Solve(e.d, e.x0, e.y0, nMax, nil)
 		for i := 0; i < len(points); i++ {
 			rY1, rY2, ds := e.s(points[i].X)
 			var relErr1, relErr2 float64
@@ -106,3 +109,112 @@ func TestSolve(t *testing.T) {
 		fmt.Println("*******************************************************")
 	}
 }
+
+// TestMasked 检查流线在圆形定义域的边界处终止, 且种子点在定义域以外时不返回任何点.
+func TestMasked(t *testing.T) {
+	disc := func(x, y float64) bool { return x*x+y*y <= 1.0 }
+	f := ode.Masked(func(x, y float64) (float64, error) { return 0.0, nil }, disc)
+	points, _ := ode.Steps(f, 0.0, 0.5, true, 1000, nil)
+	if len(points) == 0 {
+		t.Fatal("no point traced")
+	}
+	for _, p := range points {
+		if !disc(p.X, p.Y) {
+			t.Errorf("(%g, %g) is out of the domain", p.X, p.Y)
+		}
+	}
+	if last := points[len(points)-1]; math.Abs(last.X-math.Sqrt(0.75)) > 1e-3 {
+		t.Errorf("the streamline stops at (%g, %g), want it at the boundary", last.X, last.Y)
+	}
+	if points, _ := ode.Solve(f, 2.0, 0.0, 1000, nil); len(points) != 0 {
+		t.Errorf("got %d points from a seed out of the domain", len(points))
+	}
+}
+
+func TestOptions(t *testing.T) {
+	if err := ode.DefaultOptions().Validate(); err != nil {
+		t.Errorf("the default options are invalid: %v", err)
+	}
+	for i, o := range []ode.Options{
+		{RelErrMin: 1e-9, RelErrMax: 1e-10, H0: 0.1, DistMin: 1e-5},
+		{RelErrMin: 0.0, RelErrMax: 1e-9, H0: 0.1, DistMin: 1e-5},
+		{RelErrMin: 1e-10, RelErrMax: 1e-9, H0: 0.0, DistMin: 1e-5},
+		{RelErrMin: 1e-10, RelErrMax: 1e-9, H0: 0.1, DistMin: 0.2},
+	} {
+		if err := o.Validate(); err == nil {
+			t.Errorf("case %d: invalid options passed the validation", i)
+		}
+	}
+	// 初始步长不同的两组参数互不影响, 且积分结果都在同一直线上
+	f := ode.ODE(func(x, y float64) (float64, error) { return 0.5, nil })
+	for _, h0 := range []float64{0.1, 0.01} {
+		o := ode.DefaultOptions()
+		o.H0 = h0
+		points, _ := ode.Steps(f, 0.0, 0.0, true, 10, o)
+		if len(points) != 10 || math.Abs(points[0].X-h0) > 1e-12 {
+			t.Fatalf("got %d points starting at %v with H0 = %g", len(points), points, h0)
+		}
+		for _, p := range points {
+			if math.Abs(p.Y-0.5*p.X) > 1e-9 {
+				t.Errorf("(%g, %g) is not on the line", p.X, p.Y)
+			}
+		}
+	}
+}
+
+// TestConcurrent 检查在多个 goroutine 中同时积分与逐条积分所得的流线完全相同.
+func TestConcurrent(t *testing.T) {
+	// 以原点为圆心的圆, 积分时推进方向不断变化
+	f := ode.ODE(func(x, y float64) (float64, error) {
+		if x*x+y*y > 4.0 {
+			return 0.0, errors.New("out of the domain")
+		}
+		return -x / y, nil
+	})
+	seeds := []float64{0.3, 0.6, 0.9, 1.2, 1.5, 1.8}
+	want := make([][]geom.Point, len(seeds))
+	for i, r := range seeds {
+		want[i], _ = ode.Solve(f, r, 0.01, 200, nil)
+	}
+	got := make([][]geom.Point, len(seeds))
+	var wg sync.WaitGroup
+	for i, r := range seeds {
+		wg.Add(1)
+		go func(i int, r float64) {
+			defer wg.Done()
+			it := ode.NewIntegrator(nil)
+			for k := 0; k < 3; k++ { // 同一积分器可重复使用
+				got[i], _ = it.Solve(f, r, 0.01, 200)
+			}
+		}(i, r)
+	}
+	wg.Wait()
+	for i := range seeds {
+		if len(got[i]) != len(want[i]) || len(got[i]) == 0 {
+			t.Fatalf("seed %d: got %d points, want %d", i, len(got[i]), len(want[i]))
+		}
+		for k := range got[i] {
+			if got[i][k] != want[i][k] {
+				t.Fatalf("seed %d: point %d is %v, want %v", i, k, got[i][k], want[i][k])
+			}
+		}
+	}
+}
+
+// TestStepsUntil 检查 StepsUntil 在 stop 返回 true 时立即终止, 且此前的各点与 Steps 所得的相同.
+func TestStepsUntil(t *testing.T) {
+	f := ode.ODE(func(x, y float64) (float64, error) { return -x / y, nil })
+	it := ode.NewIntegrator(nil)
+	all, _ := it.Steps(f, 1.0, 0.01, true, 200)
+	points, stopped := it.StepsUntil(f, 1.0, 0.01, true, 200, func(ps []geom.Point) bool {
+		return ps[len(ps)-1].X < 0.0
+	})
+	if !stopped || len(points) == 0 || points[len(points)-1].X >= 0.0 {
+		t.Fatalf("got %d points, stopped: %v", len(points), stopped)
+	}
+	for k, p := range points {
+		if p != all[k] || (k < len(points)-1 && p.X < 0.0) {
+			t.Fatalf("point %d is %v, want %v", k, p, all[k])
+		}
+	}
+}
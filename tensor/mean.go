@@ -0,0 +1,87 @@
+package tensor
+
+import (
+	"errors"
+	"math"
+)
+
+// FromEig 根据特征值 v1, v2 以及特征值 v1 所对应的特征向量方向角 d1 构造一个张量, 它是 EigValDir 的逆运算.
+// 特征值 v2 所对应的特征向量与 d1 垂直.
+func FromEig(v1, v2, d1 float64) *Tensor {
+	m := 0.5 * (v1 + v2)
+	r := 0.5 * (v1 - v2)
+	c, s := math.Cos(2.0*d1), math.Sin(2.0*d1)
+	return New(m+r*c, m-r*c, -r*s)
+}
+
+// Log 计算正定张量的矩阵对数, 即特征向量不变而特征值取对数所得的张量. 若张量不是正定的, 则返回一个错误.
+func (t *Tensor) Log() (*Tensor, error) {
+	v1, v2, d1, _, _ := t.EigValDir()
+	if v2 <= 0.0 {
+		return nil, errors.New("the logarithm of a tensor which is not positive definite")
+	}
+	return FromEig(math.Log(v1), math.Log(v2), d1), nil
+}
+
+// Exp 计算张量的矩阵指数, 即特征向量不变而特征值取指数所得的张量.
+func (t *Tensor) Exp() *Tensor {
+	v1, v2, d1, _, _ := t.EigValDir()
+	return FromEig(math.Exp(v1), math.Exp(v2), d1)
+}
+
+// LogEuclideanMean 计算张量 ts 以 ws 为权的对数欧氏平均值, 即各张量矩阵对数的加权平均值的矩阵指数.
+// 与按分量的加权平均不同, 它保持了行列式的几何平均, 不会使各向异性的张量在平均后"膨胀".
+// ts 必须全部为正定张量或全部为负定张量(如各向受压的应力), 否则返回一个错误. 权重之和不必为 1.
+func LogEuclideanMean(ts []*Tensor, ws []float64) (*Tensor, error) {
+	if len(ts) == 0 || len(ts) != len(ws) {
+		return nil, errors.New("the tensors and weights should be nonempty and of the same length")
+	}
+	sign := 1.0
+	if _, v2, _, _, _ := ts[0].EigValDir(); v2 <= 0.0 {
+		sign = -1.0
+	}
+	var sum Tensor
+	var sw float64
+	for i, t := range ts {
+		l, err := Rescale(t, sign).Log()
+		if err != nil {
+			return nil, errors.New("the tensors should be all positive definite or all negative definite")
+		}
+		sum.XX += ws[i] * l.XX
+		sum.YY += ws[i] * l.YY
+		sum.XY += ws[i] * l.XY
+		sw += ws[i]
+	}
+	if sw == 0.0 {
+		return nil, errors.New("the sum of weights should not be zero")
+	}
+	return Rescale(Rescale(&sum, 1.0/sw).Exp(), sign), nil
+}
+
+// EigenMean 计算张量 ts 以 ws 为权的平均值, 其中较大和较小的特征值分别进行加权平均, 特征向量的方向角
+// 以 2 倍方向角的单位向量进行加权平均(从而 d 与 d+PI 表示同一方向), 其权重还乘以各张量的各向异性程度
+// (两特征值之差), 因而近乎各向同性的张量对方向几乎没有影响. 适用于不定张量. 权重之和不必为 1.
+func EigenMean(ts []*Tensor, ws []float64) (*Tensor, error) {
+	if len(ts) == 0 || len(ts) != len(ws) {
+		return nil, errors.New("the tensors and weights should be nonempty and of the same length")
+	}
+	var v1, v2, c, s, cw, sw, w float64
+	for i, t := range ts {
+		e1, e2, d1, _, _ := t.EigValDir()
+		v1 += ws[i] * e1
+		v2 += ws[i] * e2
+		cos, sin := math.Cos(2.0*d1), math.Sin(2.0*d1)
+		c += ws[i] * (e1 - e2) * cos
+		s += ws[i] * (e1 - e2) * sin
+		cw += ws[i] * cos
+		sw += ws[i] * sin
+		w += ws[i]
+	}
+	if w == 0.0 {
+		return nil, errors.New("the sum of weights should not be zero")
+	}
+	if c == 0.0 && s == 0.0 {
+		c, s = cw, sw
+	}
+	return FromEig(v1/w, v2/w, 0.5*math.Atan2(s, c)), nil
+}
@@ -0,0 +1,55 @@
+package tensor_test
+
+import (
+	"math"
+	"testing"
+
+	"stj/fieldline/tensor"
+)
+
+func TestFromEig(t *testing.T) {
+	for _, s := range []*tensor.Tensor{tensor.New(3, -1, 2), tensor.New(-5, -2, -0.5), tensor.New(1, 4, 0)} {
+		v1, v2, d1, _, _ := s.EigValDir()
+		if r := tensor.FromEig(v1, v2, d1); math.Abs(r.XX-s.XX)+math.Abs(r.YY-s.YY)+math.Abs(r.XY-s.XY) > 1e-12 {
+			t.Errorf("FromEig(EigValDir(%v)) = %v", *s, *r)
+		}
+		if s.Exp().Det() <= 0.0 {
+			t.Errorf("the exponential of %v is not positive definite", *s)
+		}
+	}
+	if _, err := tensor.New(3, -1, 2).Log(); err == nil {
+		t.Error("the logarithm of an indefinite tensor should be rejected")
+	}
+}
+
+func TestMean(t *testing.T) {
+	// 两个主方向相互垂直, 各向异性程度相同的张量: 按分量平均得到各向同性张量,
+	// 对数欧氏平均保持行列式, 按特征值平均保持特征值.
+	a, b := tensor.New(4, 1, 0), tensor.New(1, 4, 0)
+	ws := []float64{1, 1}
+	le, err := tensor.LogEuclideanMean([]*tensor.Tensor{a, b}, ws)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if math.Abs(le.Det()-4.0) > 1e-9 {
+		t.Errorf("the determinant of the log-Euclidean mean is %g, want 4", le.Det())
+	}
+	neg, err := tensor.LogEuclideanMean([]*tensor.Tensor{tensor.Rescale(a, -1), tensor.Rescale(b, -1)}, ws)
+	if err != nil || !tensor.Equal(neg, tensor.Rescale(le, -1)) {
+		t.Errorf("the log-Euclidean mean of negative definite tensors is %v, %v", neg, err)
+	}
+	if _, err := tensor.LogEuclideanMean([]*tensor.Tensor{a, tensor.New(1, -1, 0)}, ws); err == nil {
+		t.Error("the log-Euclidean mean of indefinite tensors should be rejected")
+	}
+
+	// 方向角分别为 10° 和 50° 的两个张量, 平均后方向角为 30°, 特征值不变
+	d := math.Pi / 18
+	c, err := tensor.EigenMean([]*tensor.Tensor{tensor.FromEig(3, -1, d), tensor.FromEig(3, -1, 5*d)}, ws)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	v1, v2, d1, _, _ := c.EigValDir()
+	if math.Abs(v1-3) > 1e-9 || math.Abs(v2+1) > 1e-9 || math.Abs(math.Cos(2*(d1-3*d))-1) > 1e-9 {
+		t.Errorf("got eigenvalues %g, %g and direction %g", v1, v2, d1)
+	}
+}
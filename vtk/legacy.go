@@ -0,0 +1,438 @@
+package vtk
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"stj/fieldline/geom"
+)
+
+// tokenizer 将传统格式 VTK 文件的内容逐个分割为以空白字符分隔的单词.
+type tokenizer struct {
+	sc     *bufio.Scanner
+	fields []string
+	line   int
+}
+
+func newTokenizer(r io.Reader) *tokenizer {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	return &tokenizer{sc: sc}
+}
+
+// readLine 方法读取下一行. 若已达到文件末尾, 则返回 io.EOF.
+func (t *tokenizer) readLine() (string, error) {
+	if !t.sc.Scan() {
+		if err := t.sc.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	t.line++
+	return t.sc.Text(), nil
+}
+
+// next 方法返回下一个单词. 若已达到文件末尾, 则返回 io.EOF.
+func (t *tokenizer) next() (string, error) {
+	for len(t.fields) == 0 {
+		line, err := t.readLine()
+		if err != nil {
+			return "", err
+		}
+		t.fields = strings.Fields(line)
+	}
+	w := t.fields[0]
+	t.fields = t.fields[1:]
+	return w, nil
+}
+
+// peek 方法返回下一个单词, 但并不将其取出.
+func (t *tokenizer) peek() (string, error) {
+	w, err := t.next()
+	if err != nil {
+		return "", err
+	}
+	t.fields = append([]string{w}, t.fields...)
+	return w, nil
+}
+
+// int 方法读取下一个整数.
+func (t *tokenizer) int() (int, error) {
+	w, err := t.next()
+	if err != nil {
+		return 0, t.unexpected(err)
+	}
+	n, err := strconv.Atoi(w)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("line %d: invalid count %q", t.line, w)
+	}
+	return n, nil
+}
+
+// floats 方法读取接下来的 n 个浮点数.
+func (t *tokenizer) floats(n int) ([]float64, error) {
+	vs := make([]float64, n)
+	for i := 0; i < n; i++ {
+		w, err := t.next()
+		if err != nil {
+			return nil, t.unexpected(err)
+		}
+		if vs[i], err = strconv.ParseFloat(w, 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid number %q", t.line, w)
+		}
+	}
+	return vs, nil
+}
+
+// skip 方法跳过接下来的 n 个单词.
+func (t *tokenizer) skip(n int) error {
+	for i := 0; i < n; i++ {
+		if _, err := t.next(); err != nil {
+			return t.unexpected(err)
+		}
+	}
+	return nil
+}
+
+// skipBlock 方法跳过当前行的剩余部分以及其后直到空行为止的所有行.
+func (t *tokenizer) skipBlock() error {
+	t.fields = nil
+	for {
+		line, err := t.readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) == "" {
+			return nil
+		}
+	}
+}
+
+func (t *tokenizer) unexpected(err error) error {
+	if err == io.EOF {
+		return errors.New("unexpected end of VTK file")
+	}
+	return err
+}
+
+// ReadLegacy 从 r 中读取一个 ASCII 编码的传统格式 VTK 文件. 其数据集类型应为 POLYDATA 或
+// UNSTRUCTURED_GRID.
+func ReadLegacy(r io.Reader) (*Data, error) {
+	t := newTokenizer(r)
+	// 前 3 行依次为版本, 标题和编码方式
+	version, err := t.readLine()
+	if err != nil || !strings.HasPrefix(version, "# vtk") {
+		return nil, errors.New("not a legacy VTK file")
+	}
+	if _, err := t.readLine(); err != nil {
+		return nil, t.unexpected(err)
+	}
+	format, err := t.readLine()
+	if err != nil {
+		return nil, t.unexpected(err)
+	}
+	if !strings.EqualFold(strings.TrimSpace(format), "ASCII") {
+		return nil, fmt.Errorf("unsupported VTK file format %q, only ASCII is supported", strings.TrimSpace(format))
+	}
+	d := &Data{}
+	pointData := false // 是否处于 POINT_DATA 部分
+	var cells, polygons [][]int
+	var types []int
+	for {
+		kw, err := t.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToUpper(kw) {
+		case "DATASET":
+			typ, err := t.next()
+			if err != nil {
+				return nil, t.unexpected(err)
+			}
+			typ = strings.ToUpper(typ)
+			if typ != "POLYDATA" && typ != "UNSTRUCTURED_GRID" {
+				return nil, fmt.Errorf("unsupported VTK dataset type %q", typ)
+			}
+		case "POINTS":
+			err = d.readPoints(t)
+		case "VERTICES", "LINES", "TRIANGLE_STRIPS":
+			_, err = t.readCells()
+		case "POLYGONS":
+			polygons, err = t.readCells()
+		case "CELLS":
+			cells, err = t.readCells()
+		case "CELL_TYPES":
+			var n int
+			if n, err = t.int(); err == nil {
+				types, err = t.ints(n)
+			}
+		case "POINT_DATA", "CELL_DATA":
+			var n int
+			if n, err = t.int(); err != nil {
+				break
+			}
+			pointData = strings.ToUpper(kw) == "POINT_DATA"
+			if pointData && n != len(d.Points) {
+				err = fmt.Errorf("line %d: POINT_DATA has %d values, but there are %d points", t.line, n, len(d.Points))
+			}
+		case "SCALARS", "VECTORS", "NORMALS", "TENSORS", "TENSORS6", "TEXTURE_COORDINATES", "COLOR_SCALARS", "LOOKUP_TABLE":
+			err = d.readAttribute(t, strings.ToUpper(kw), pointData)
+		case "FIELD":
+			err = d.readFieldData(t, pointData)
+		case "METADATA":
+			err = t.skipBlock()
+		default:
+			err = fmt.Errorf("line %d: unknown keyword %q", t.line, kw)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(d.Points) == 0 {
+		return nil, errors.New("no points found in the VTK file")
+	}
+	if types == nil && cells != nil {
+		return nil, errors.New("CELL_TYPES not found in the VTK file")
+	}
+	for range polygons {
+		types = append(types, cellPolygon)
+	}
+	if err := d.addElements(append(cells, polygons...), types); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// readPoints 方法读取 POINTS 部分, 其格式为:
+//
+//	POINTS n dataType
+//	p0x p0y p0z
+//	...
+func (d *Data) readPoints(t *tokenizer) error {
+	n, err := t.int()
+	if err != nil {
+		return err
+	}
+	if err := t.skip(1); err != nil {
+		return err
+	}
+	vs, err := t.floats(3 * n)
+	if err != nil {
+		return err
+	}
+	d.Points = make([]geom.Point, n)
+	for i := range d.Points {
+		d.Points[i] = geom.Point{X: vs[3*i], Y: vs[3*i+1]}
+	}
+	return nil
+}
+
+// readCells 方法读取 CELLS, POLYGONS 等单元定义部分, 返回各单元的顶点索引. 它们可以是以下两种格式之一:
+//
+//	CELLS n size
+//	numPoints0 i0 j0 k0 ...
+//
+// 或(自 5.1 版开始):
+//
+//	CELLS n size
+//	OFFSETS dataType
+//	o0 o1 ... (共 n 个)
+//	CONNECTIVITY dataType
+//	c0 c1 ... (共 size 个)
+func (t *tokenizer) readCells() ([][]int, error) {
+	n, err := t.int()
+	if err != nil {
+		return nil, err
+	}
+	size, err := t.int()
+	if err != nil {
+		return nil, err
+	}
+	w, err := t.peek()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !strings.EqualFold(w, "OFFSETS") {
+		vs, err := t.ints(size)
+		if err != nil {
+			return nil, err
+		}
+		cells := make([][]int, 0, n)
+		for i := 0; i < len(vs); i += vs[i] + 1 {
+			if i+vs[i] >= len(vs) {
+				return nil, fmt.Errorf("line %d: wrong cell size", t.line)
+			}
+			cells = append(cells, vs[i+1:i+1+vs[i]])
+		}
+		if len(cells) != n {
+			return nil, fmt.Errorf("line %d: %d cells found, want %d", t.line, len(cells), n)
+		}
+		return cells, nil
+	}
+	// 5.1 版中的 n 为偏移量的个数, 即单元个数加 1
+	if err := t.skip(2); err != nil {
+		return nil, err
+	}
+	offsets, err := t.ints(n)
+	if err != nil {
+		return nil, err
+	}
+	if w, err := t.next(); err != nil || !strings.EqualFold(w, "CONNECTIVITY") {
+		return nil, fmt.Errorf("line %d: CONNECTIVITY expected", t.line)
+	}
+	if err := t.skip(1); err != nil {
+		return nil, err
+	}
+	conn, err := t.ints(size)
+	if err != nil {
+		return nil, err
+	}
+	return splitCells(conn, offsets[1:], offsets[0])
+}
+
+// ints 方法读取接下来的 n 个非负整数.
+func (t *tokenizer) ints(n int) ([]int, error) {
+	vs := make([]int, n)
+	for i := range vs {
+		v, err := t.int()
+		if err != nil {
+			return nil, err
+		}
+		vs[i] = v
+	}
+	return vs, nil
+}
+
+// readAttribute 方法读取一个数据属性. 若 keep 为 false, 则读取后将其舍弃.
+func (d *Data) readAttribute(t *tokenizer, kw string, keep bool) error {
+	name, err := t.next()
+	if err != nil {
+		return t.unexpected(err)
+	}
+	n := len(d.Points)
+	a := &Array{Name: name}
+	switch kw {
+	case "SCALARS":
+		// SCALARS name dataType [numComp]
+		// LOOKUP_TABLE tableName
+		if err := t.skip(1); err != nil {
+			return err
+		}
+		a.NumComp = 1
+		if w, err := t.peek(); err == nil && !strings.EqualFold(w, "LOOKUP_TABLE") {
+			if a.NumComp, err = t.int(); err != nil {
+				return err
+			}
+		}
+		if w, err := t.peek(); err == nil && strings.EqualFold(w, "LOOKUP_TABLE") {
+			if err := t.skip(2); err != nil {
+				return err
+			}
+		}
+	case "VECTORS", "NORMALS":
+		a.NumComp = 3
+		err = t.skip(1)
+	case "TENSORS":
+		a.NumComp = 9
+		err = t.skip(1)
+	case "TENSORS6":
+		a.NumComp = 6
+		err = t.skip(1)
+	case "TEXTURE_COORDINATES":
+		// TEXTURE_COORDINATES name dim dataType
+		if a.NumComp, err = t.int(); err == nil {
+			err = t.skip(1)
+		}
+	case "COLOR_SCALARS":
+		// COLOR_SCALARS name nValues
+		a.NumComp, err = t.int()
+	case "LOOKUP_TABLE":
+		// LOOKUP_TABLE name size, 每个颜色有 4 个分量
+		size, err := t.int()
+		if err != nil {
+			return err
+		}
+		return t.skip(4 * size)
+	}
+	if err != nil {
+		return err
+	}
+	if !keep {
+		// 单元数据的个数与点数无关, 但其个数已在 CELL_DATA 中给出, 这里逐行跳过直到下一个关键字.
+		return t.skipValues()
+	}
+	if a.Values, err = t.floats(a.NumComp * n); err != nil {
+		return err
+	}
+	return d.addArray(a)
+}
+
+// readFieldData 方法读取 FIELD 部分, 其格式为:
+//
+//	FIELD dataName numArrays
+//	arrayName0 numComponents numTuples dataType
+//	...
+func (d *Data) readFieldData(t *tokenizer, keep bool) error {
+	if err := t.skip(1); err != nil {
+		return err
+	}
+	num, err := t.int()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < num; i++ {
+		name, err := t.next()
+		if err != nil {
+			return t.unexpected(err)
+		}
+		nc, err := t.int()
+		if err != nil {
+			return err
+		}
+		nt, err := t.int()
+		if err != nil {
+			return err
+		}
+		if err := t.skip(1); err != nil {
+			return err
+		}
+		vs, err := t.floats(nc * nt)
+		if err != nil {
+			return err
+		}
+		// 仅保留与点一一对应的数组
+		if keep && nt == len(d.Points) {
+			if err := d.addArray(&Array{Name: name, NumComp: nc, Values: vs}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipValues 方法跳过接下来的所有数字, 直到遇到下一个关键字或文件末尾.
+func (t *tokenizer) skipValues() error {
+	for {
+		w, err := t.peek()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := strconv.ParseFloat(w, 64); err != nil {
+			return nil
+		}
+		t.fields = t.fields[1:]
+	}
+}
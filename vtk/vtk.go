@@ -0,0 +1,251 @@
+/*
+vtk 包实现了对 VTK 文件中二维点数据的导入. 它支持以下格式:
+
+	传统格式(.vtk): ASCII 编码的 POLYDATA 和 UNSTRUCTURED_GRID 数据集;
+	XML 格式(.vtu, .vtp): UnstructuredGrid 和 PolyData 数据集, 数据数组可以是 ascii 格式,
+	或(未压缩或经 zlib 压缩的) base64 编码的 binary 格式.
+
+VTK 文件中的点坐标和点数据(POINT_DATA)都被读入, 单元数据(CELL_DATA)被忽略. 单元(CELLS)中的三角形和
+四边形单元(包括二次单元, 这时仅取其角点)被读入为有限元网格, 其他类型的单元被忽略. 若文件中含有这样的
+单元, 则由其生成的标量场和张量场将利用单元的形函数进行插值.
+由于 fieldline 只处理二维场, 点坐标和数据数组中的 z 分量将被舍弃. 数据数组根据其分量个数转换为场:
+
+	1 个分量: 标量场;
+	2 或 3 个分量: 向量场, 取 (x, y) 分量;
+	4 个分量: 2x2 张量, 按 XX, XY, YX, YY 的顺序排列;
+	6 个分量: 对称张量, 按 VTK 的约定以 XX, YY, ZZ, XY, YZ, XZ 的顺序排列;
+	9 个分量: 3x3 张量, 按行序排列.
+
+参见: https://vtk.org/wp-content/uploads/2015/04/file-formats.pdf
+*/
+package vtk
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"stj/fieldline/field"
+	"stj/fieldline/geom"
+	"stj/fieldline/mesh"
+)
+
+// Array 是 VTK 文件中的一个点数据数组.
+type Array struct {
+	Name    string
+	NumComp int       // 每个点的分量个数
+	Values  []float64 // 依次存储各点的各个分量, 其长度为点数与 NumComp 之积
+}
+
+// Data 是从 VTK 文件中读出的二维点数据.
+type Data struct {
+	Points   []geom.Point
+	Arrays   []*Array
+	Elements []mesh.Element // 三角形和四边形单元, 若文件中没有这样的单元, 则为空
+}
+
+// Read 从 r 中读取一个 VTK 文件. 文件的格式(传统格式或 XML 格式)根据文件开头的内容自动判断.
+func Read(r io.Reader) (*Data, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(64)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	head = bytes.TrimSpace(head)
+	switch {
+	case bytes.HasPrefix(head, []byte("# vtk")):
+		return ReadLegacy(br)
+	case bytes.HasPrefix(head, []byte("<")):
+		return ReadXML(br)
+	}
+	return nil, errors.New("unknown VTK file format")
+}
+
+// Array 方法返回名称为 name 的点数据数组.
+func (d *Data) Array(name string) (*Array, error) {
+	for _, a := range d.Arrays {
+		if a.Name == name {
+			return a, nil
+		}
+	}
+	return nil, fmt.Errorf("point data array %q not found", name)
+}
+
+// pick 方法选取用于生成场的数据数组. 若 name 不为空, 则返回该名称的数组; 否则, 若文件中
+// 正好只有一个分量个数满足 ok 的数组, 则返回该数组.
+func (d *Data) pick(name string, ok func(numComp int) bool, kind string) (*Array, error) {
+	if name != "" {
+		a, err := d.Array(name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok(a.NumComp) {
+			return nil, fmt.Errorf("point data array %q with %d components can not be converted to a %s field", name, a.NumComp, kind)
+		}
+		return a, nil
+	}
+	var found *Array
+	for _, a := range d.Arrays {
+		if ok(a.NumComp) {
+			if found != nil {
+				return nil, fmt.Errorf("more than one %s array found, the array name should be given", kind)
+			}
+			found = a
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no %s array found", kind)
+	}
+	return found, nil
+}
+
+// ScalarField 方法将名称为 name 的点数据数组转换为标量场. 若 name 为空, 且文件中仅有一个标量数组,
+// 则使用该数组. o 为创建场所用的参数, 若为 nil, 则使用默认参数.
+func (d *Data) ScalarField(name string, o *field.Options) (*field.ScalarField, error) {
+	a, err := d.pick(name, func(n int) bool { return n == 1 }, "scalar")
+	if err != nil {
+		return nil, err
+	}
+	data := make([]*field.ScalarQty, len(d.Points))
+	for i, p := range d.Points {
+		data[i] = field.NewScalarQty(p.X, p.Y, a.Values[i])
+	}
+	if len(d.Elements) != 0 {
+		m, err := mesh.New(d.Points, d.Elements)
+		if err != nil {
+			return nil, err
+		}
+		return field.NewMeshScalarField(m, data, o)
+	}
+	return field.NewScalarField(data, o)
+}
+
+// VectorField 方法将名称为 name 的点数据数组转换为向量场. 若 name 为空, 且文件中仅有一个向量数组,
+// 则使用该数组. o 为创建场所用的参数, 若为 nil, 则使用默认参数.
+func (d *Data) VectorField(name string, o *field.Options) (*field.VectorField, error) {
+	a, err := d.pick(name, func(n int) bool { return n == 2 || n == 3 }, "vector")
+	if err != nil {
+		return nil, err
+	}
+	data := make([]*field.VectorQty, len(d.Points))
+	for i, p := range d.Points {
+		v := a.Values[i*a.NumComp:]
+		data[i] = field.NewVectorQty(p.X, p.Y, v[0], v[1])
+	}
+	if len(d.Elements) != 0 {
+		m, err := mesh.New(d.Points, d.Elements)
+		if err != nil {
+			return nil, err
+		}
+		return field.NewMeshVectorField(m, data, o)
+	}
+	return field.NewVectorField(data, o)
+}
+
+// tensorComps 给出各种分量个数的张量数组中 XX, YY, XY 分量的位置.
+var tensorComps = map[int][3]int{
+	4: {0, 3, 1},
+	6: {0, 1, 3},
+	9: {0, 4, 1},
+}
+
+// TensorField 方法将名称为 name 的点数据数组转换为张量场, 其 z 方向的分量将被舍弃.
+// 若 name 为空, 且文件中仅有一个张量数组, 则使用该数组. o 为创建场所用的参数, 若为 nil, 则使用默认参数.
+func (d *Data) TensorField(name string, o *field.Options) (*field.TensorField, error) {
+	a, err := d.pick(name, func(n int) bool { _, ok := tensorComps[n]; return ok }, "tensor")
+	if err != nil {
+		return nil, err
+	}
+	c := tensorComps[a.NumComp]
+	data := make([]*field.TensorQty, len(d.Points))
+	for i, p := range d.Points {
+		t := a.Values[i*a.NumComp:]
+		data[i] = field.NewTensorQty(p.X, p.Y, t[c[0]], t[c[1]], t[c[2]])
+	}
+	if len(d.Elements) != 0 {
+		m, err := mesh.New(d.Points, d.Elements)
+		if err != nil {
+			return nil, err
+		}
+		return field.NewMeshTensorField(m, data, o)
+	}
+	return field.NewTensorField(data, o)
+}
+
+// addArray 方法添加一个点数据数组, 并检查其长度是否与点数相符.
+func (d *Data) addArray(a *Array) error {
+	if a.NumComp <= 0 {
+		return fmt.Errorf("invalid number of components of array %q", a.Name)
+	}
+	if len(a.Values) != a.NumComp*len(d.Points) {
+		return fmt.Errorf("array %q has %d values, want %d", a.Name, len(a.Values), a.NumComp*len(d.Points))
+	}
+	d.Arrays = append(d.Arrays, a)
+	return nil
+}
+
+// VTK 单元类型中可以转换为有限元网格单元的类型. cellPolygon 也用于传统格式 POLYDATA 中的 POLYGONS.
+const (
+	cellTriangle       = 5
+	cellPolygon        = 7
+	cellPixel          = 8
+	cellQuad           = 9
+	cellQuadTriangle   = 22
+	cellQuadQuad       = 23
+	cellBiquadQuad     = 28
+	cellQuadLinQuad    = 31
+	cellBiquadTriangle = 34
+)
+
+// addElements 方法将类型为 types 的各个单元 cells 中的三角形和四边形单元添加到网格单元中.
+func (d *Data) addElements(cells [][]int, types []int) error {
+	if len(cells) != len(types) {
+		return fmt.Errorf("%d cells found, but %d cell types given", len(cells), len(types))
+	}
+	for i, c := range cells {
+		e := element(c, types[i])
+		if e == nil {
+			continue
+		}
+		for _, pi := range e {
+			if pi >= len(d.Points) {
+				return fmt.Errorf("cell %d refers to point %d, which does not exist", i, pi)
+			}
+		}
+		d.Elements = append(d.Elements, e)
+	}
+	return nil
+}
+
+// element 将 VTK 单元转换为有限元网格单元. 若该单元不是三角形或四边形单元, 则返回 nil.
+func element(c []int, typ int) mesh.Element {
+	switch {
+	case typ == cellTriangle && len(c) == 3, typ == cellQuad && len(c) == 4:
+		return c
+	case typ == cellPolygon && (len(c) == 3 || len(c) == 4):
+		return c
+	case typ == cellPixel && len(c) == 4:
+		// 像素单元的顶点按 x, y 的顺序排列, 而不是沿边界排列
+		return mesh.Element{c[0], c[1], c[3], c[2]}
+	case (typ == cellQuadTriangle || typ == cellBiquadTriangle) && len(c) >= 3:
+		return c[:3]
+	case (typ == cellQuadQuad || typ == cellBiquadQuad || typ == cellQuadLinQuad) && len(c) >= 4:
+		return c[:4]
+	}
+	return nil
+}
+
+// splitCells 根据各单元在 conn 中的结束位置 ends 将顶点索引列表 conn 分割为各个单元. start 为第一个单元的开始位置.
+func splitCells(conn []int, ends []int, start int) ([][]int, error) {
+	cells := make([][]int, len(ends))
+	for i, end := range ends {
+		if end < start || end > len(conn) {
+			return nil, fmt.Errorf("invalid cell offset %d", end)
+		}
+		cells[i] = conn[start:end]
+		start = end
+	}
+	return cells, nil
+}
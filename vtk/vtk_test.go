@@ -0,0 +1,236 @@
+package vtk_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"stj/fieldline/vtk"
+)
+
+const polyData = `# vtk DataFile Version 3.0
+plate
+ASCII
+DATASET POLYDATA
+POINTS 4 float
+0 0 0  2 0 0
+0 1 0  2 1 0
+POLYGONS 1 5
+4 0 1 3 2
+CELL_DATA 1
+SCALARS id int 1
+LOOKUP_TABLE default
+7
+POINT_DATA 4
+SCALARS temperature double 1
+LOOKUP_TABLE default
+1 2 3 4
+VECTORS velocity float
+1 0 0  0 1 0  -1 0 0  0 -1 0
+TENSORS stress float
+1 5 0  5 2 0  0 0 9
+1.1 5 0  5 2 0  0 0 9
+1.2 5 0  5 2 0  0 0 9
+1.3 5 0  5 2 0  0 0 9
+`
+
+const unstructuredGrid = `# vtk DataFile Version 5.1
+mesh
+ASCII
+
+DATASET UNSTRUCTURED_GRID
+POINTS 3 double
+0 0 0 1 0 0 0 1 0
+METADATA
+INFORMATION 0
+
+CELLS 2 3
+OFFSETS vtktypeint64
+0 3
+CONNECTIVITY vtktypeint64
+0 1 2
+CELL_TYPES 1
+5
+POINT_DATA 3
+FIELD FieldData 2
+pressure 1 3 double
+10 20 30
+strain 6 3 double
+1 2 0 3 0 0
+1 2 0 3 0 0
+1 2 0 3 0 0
+`
+
+func TestReadLegacyPolyData(t *testing.T) {
+	d, err := vtk.Read(strings.NewReader(polyData))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(d.Points) != 4 || d.Points[3].X != 2 || d.Points[3].Y != 1 {
+		t.Fatalf("wrong points: %v", d.Points)
+	}
+	if len(d.Elements) != 1 || len(d.Elements[0]) != 4 {
+		t.Errorf("wrong elements: %v", d.Elements)
+	}
+	if len(d.Arrays) != 3 {
+		t.Fatalf("got %d arrays, want 3", len(d.Arrays))
+	}
+	if _, err := d.Array("id"); err == nil {
+		t.Error("cell data should be ignored")
+	}
+	if _, err := d.ScalarField("", nil); err != nil {
+		t.Error(err.Error())
+	}
+	if _, err := d.VectorField("velocity", nil); err != nil {
+		t.Error(err.Error())
+	}
+	if _, err := d.TensorField("temperature", nil); err == nil {
+		t.Error("a scalar array should not be converted to a tensor field")
+	}
+	tf, err := d.TensorField("stress", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	xx, _ := tf.Near(0, 0, 0)
+	if len(xx) == 0 || xx[0].XX != 1 || xx[0].YY != 2 || xx[0].XY != 5 {
+		t.Errorf("wrong tensor: %v", xx)
+	}
+}
+
+func TestReadLegacyUnstructuredGrid(t *testing.T) {
+	d, err := vtk.ReadLegacy(strings.NewReader(unstructuredGrid))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	a, err := d.Array("pressure")
+	if err != nil || len(a.Values) != 3 || a.Values[2] != 30 {
+		t.Errorf("wrong pressure array: %v, %v", a, err)
+	}
+	if len(d.Elements) != 1 || len(d.Elements[0]) != 3 {
+		t.Errorf("wrong elements: %v", d.Elements)
+	}
+	tf, err := d.TensorField("", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if tf.Mesh() == nil {
+		t.Error("the tensor field should be interpolated on the mesh")
+	}
+	ts, _ := tf.Near(1, 0, 0)
+	if len(ts) == 0 || ts[0].XX != 1 || ts[0].YY != 2 || ts[0].XY != 3 {
+		t.Errorf("wrong tensor from symmetric array: %v", ts)
+	}
+	if _, err := vtk.ReadLegacy(strings.NewReader("# vtk DataFile Version 3.0\nx\nBINARY\n")); err == nil {
+		t.Error("binary legacy files should be rejected")
+	}
+}
+
+const vtuASCII = `<?xml version="1.0"?>
+<VTKFile type="UnstructuredGrid" version="0.1" byte_order="LittleEndian">
+  <UnstructuredGrid>
+    <Piece NumberOfPoints="3" NumberOfCells="1">
+      <PointData Scalars="T">
+        <DataArray type="Float64" Name="T" format="ascii">1 2 3</DataArray>
+        <DataArray type="Float64" Name="U" NumberOfComponents="3" format="ascii">1 0 0 0 1 0 1 1 0</DataArray>
+      </PointData>
+      <CellData>
+        <DataArray type="Int32" Name="mat" format="ascii">1</DataArray>
+      </CellData>
+      <Points>
+        <DataArray type="Float32" NumberOfComponents="3" format="ascii">0 0 0 1 0 0 0 1 0</DataArray>
+      </Points>
+      <Cells>
+        <DataArray type="Int32" Name="connectivity" format="ascii">0 1 2</DataArray>
+        <DataArray type="Int32" Name="offsets" format="ascii">3</DataArray>
+        <DataArray type="UInt8" Name="types" format="ascii">5</DataArray>
+      </Cells>
+    </Piece>
+  </UnstructuredGrid>
+</VTKFile>
+`
+
+func TestReadXMLASCII(t *testing.T) {
+	d, err := vtk.Read(strings.NewReader(vtuASCII))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(d.Points) != 3 || len(d.Arrays) != 2 {
+		t.Fatalf("got %d points and %d arrays", len(d.Points), len(d.Arrays))
+	}
+	if len(d.Elements) != 1 {
+		t.Errorf("got %d elements, want 1", len(d.Elements))
+	}
+	sf, err := d.ScalarField("T", nil)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// 线性三角形单元中的值由面积坐标插值求得
+	if v, err := sf.V(0.25, 0.25); err != nil || math.Abs(v-1.75) > 1e-9 {
+		t.Errorf("got %v, %v, want 1.75", v, err)
+	}
+	if _, err := d.VectorField("U", nil); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+// float64Bytes 将浮点数按小端字节序编码.
+func float64Bytes(vs ...float64) []byte {
+	b := make([]byte, 8*len(vs))
+	for i, v := range vs {
+		binary.LittleEndian.PutUint64(b[8*i:], math.Float64bits(v))
+	}
+	return b
+}
+
+// binaryArray 按 VTK XML 格式对未压缩的数据进行 base64 编码, 头部为 UInt32.
+func binaryArray(raw []byte) string {
+	h := make([]byte, 4)
+	binary.LittleEndian.PutUint32(h, uint32(len(raw)))
+	return base64.StdEncoding.EncodeToString(append(h, raw...))
+}
+
+// compressedArray 按 VTK XML 格式对数据进行 zlib 压缩和 base64 编码, 头部为 UInt64, 仅含一个压缩块.
+func compressedArray(raw []byte) string {
+	var c bytes.Buffer
+	zw := zlib.NewWriter(&c)
+	zw.Write(raw)
+	zw.Close()
+	h := make([]byte, 32)
+	binary.LittleEndian.PutUint64(h[0:], 1)
+	binary.LittleEndian.PutUint64(h[8:], uint64(len(raw)))
+	binary.LittleEndian.PutUint64(h[16:], uint64(len(raw)))
+	binary.LittleEndian.PutUint64(h[24:], uint64(c.Len()))
+	return base64.StdEncoding.EncodeToString(h) + base64.StdEncoding.EncodeToString(c.Bytes())
+}
+
+func TestReadXMLBinary(t *testing.T) {
+	points := float64Bytes(0, 0, 0, 1, 0, 0, 0, 1, 0)
+	stress := float64Bytes(1, 2, 3, 4, 5, 6, 7, 8, 9)
+	for _, tc := range []struct {
+		attrs  string
+		encode func([]byte) string
+	}{
+		{``, binaryArray},
+		{`header_type="UInt64" compressor="vtkZLibDataCompressor"`, compressedArray},
+	} {
+		input := `<VTKFile type="PolyData" byte_order="LittleEndian" ` + tc.attrs + `><PolyData>
+<Piece NumberOfPoints="3"><PointData>
+<DataArray type="Float64" Name="S" NumberOfComponents="3" format="binary">` + tc.encode(stress) + `</DataArray>
+</PointData><Points>
+<DataArray type="Float64" NumberOfComponents="3" format="binary">` + tc.encode(points) + `</DataArray>
+</Points></Piece></PolyData></VTKFile>`
+		d, err := vtk.ReadXML(strings.NewReader(input))
+		if err != nil {
+			t.Errorf("%s: %v", tc.attrs, err)
+			continue
+		}
+		a, _ := d.Array("S")
+		if len(d.Points) != 3 || d.Points[2].Y != 1 || a == nil || a.Values[8] != 9 {
+			t.Errorf("%s: wrong data %v %v", tc.attrs, d.Points, a)
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package vtk
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+
+	"stj/fieldline/geom"
+)
+
+// xmlDataArray 对应 XML 格式 VTK 文件中的 DataArray 元素.
+type xmlDataArray struct {
+	Type     string `xml:"type,attr"`
+	Name     string `xml:"Name,attr"`
+	NumComp  int    `xml:"NumberOfComponents,attr"`
+	Format   string `xml:"format,attr"`
+	Content  string `xml:",chardata"`
+	numPoint int    // 数组对应的点数, 若为负数, 则不检查数组的长度
+}
+
+// xmlFile 记录 XML 格式 VTK 文件根元素中影响数据解码的属性.
+type xmlFile struct {
+	byteOrder  binary.ByteOrder
+	headerSize int // binary 格式中数据块头部各个整数的字节数
+	compressed bool
+}
+
+// ReadXML 从 r 中读取一个 XML 格式的 VTK 文件(.vtu 或 .vtp). 若文件中包含多个 Piece,
+// 则各 Piece 中的点, 点数据和单元将依次连接在一起.
+func ReadXML(r io.Reader) (*Data, error) {
+	dec := xml.NewDecoder(r)
+	f := &xmlFile{byteOrder: binary.LittleEndian, headerSize: 4}
+	d := &Data{}
+	arrays := map[string]*Array{} // 按名称合并各 Piece 中的点数据
+	var names []string
+	var pieceN int                 // 当前 Piece 中的点数
+	var pieceBase int              // 当前 Piece 中第一个点的索引
+	var conn, offsets, types []int // 当前 Piece 中的单元
+	var stack []string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			parent := ""
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+			switch el.Name.Local {
+			case "VTKFile":
+				if err := f.init(el); err != nil {
+					return nil, err
+				}
+			case "Piece":
+				if pieceN, err = intAttr(el, "NumberOfPoints"); err != nil {
+					return nil, err
+				}
+				pieceBase = len(d.Points)
+				conn, offsets, types = nil, nil, nil
+			case "AppendedData":
+				return nil, errors.New("appended data in XML VTK files is not supported")
+			case "DataArray":
+				if parent == "Cells" || parent == "Polys" {
+					da := &xmlDataArray{NumComp: 1, numPoint: -1}
+					if err := dec.DecodeElement(da, &el); err != nil {
+						return nil, err
+					}
+					vs, err := f.decode(da)
+					if err != nil {
+						return nil, fmt.Errorf("data array %q: %v", da.Name, err)
+					}
+					is := make([]int, len(vs))
+					for i, v := range vs {
+						is[i] = int(v)
+					}
+					switch da.Name {
+					case "connectivity":
+						conn = is
+					case "offsets":
+						offsets = is
+					case "types":
+						types = is
+					}
+					continue
+				}
+				if parent != "Points" && parent != "PointData" {
+					if err := dec.Skip(); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				da := &xmlDataArray{NumComp: 1, numPoint: pieceN}
+				if err := dec.DecodeElement(da, &el); err != nil {
+					return nil, err
+				}
+				vs, err := f.decode(da)
+				if err != nil {
+					return nil, fmt.Errorf("data array %q: %v", da.Name, err)
+				}
+				if parent == "Points" {
+					for i := 0; i+2 < len(vs); i += 3 {
+						d.Points = append(d.Points, geom.Point{X: vs[i], Y: vs[i+1]})
+					}
+					continue
+				}
+				a, ok := arrays[da.Name]
+				if !ok {
+					a = &Array{Name: da.Name, NumComp: da.NumComp}
+					arrays[da.Name] = a
+					names = append(names, da.Name)
+				} else if a.NumComp != da.NumComp {
+					return nil, fmt.Errorf("data array %q has different numbers of components", da.Name)
+				}
+				a.Values = append(a.Values, vs...)
+				continue
+			}
+			stack = append(stack, el.Name.Local)
+		case xml.EndElement:
+			if el.Name.Local == "Piece" && conn != nil {
+				if err := d.addPieceElements(conn, offsets, types, pieceBase); err != nil {
+					return nil, err
+				}
+			}
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+	if len(d.Points) == 0 {
+		return nil, errors.New("no points found in the VTK file")
+	}
+	// 若某个数组在部分 Piece 中缺失, 则其长度与点数不符, addArray 将返回错误.
+	for _, name := range names {
+		if err := d.addArray(arrays[name]); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// addPieceElements 方法将一个 Piece 中的单元添加到网格单元中. conn 为各单元的顶点在该 Piece 中的索引,
+// offsets 为各单元在 conn 中的结束位置. types 为各单元的类型, 对于 PolyData 中的 Polys, types 为空.
+// base 为该 Piece 中第一个点的索引.
+func (d *Data) addPieceElements(conn, offsets, types []int, base int) error {
+	for i := range conn {
+		conn[i] += base
+	}
+	cells, err := splitCells(conn, offsets, 0)
+	if err != nil {
+		return err
+	}
+	if types == nil {
+		types = make([]int, len(cells))
+		for i := range types {
+			types[i] = cellPolygon
+		}
+	}
+	return d.addElements(cells, types)
+}
+
+// init 方法根据 VTKFile 元素的属性设置数据的解码方式.
+func (f *xmlFile) init(el xml.StartElement) error {
+	for _, a := range el.Attr {
+		switch a.Name.Local {
+		case "type":
+			if a.Value != "UnstructuredGrid" && a.Value != "PolyData" {
+				return fmt.Errorf("unsupported VTK dataset type %q", a.Value)
+			}
+		case "byte_order":
+			if a.Value == "BigEndian" {
+				f.byteOrder = binary.BigEndian
+			}
+		case "header_type":
+			if a.Value == "UInt64" {
+				f.headerSize = 8
+			}
+		case "compressor":
+			if a.Value != "vtkZLibDataCompressor" {
+				return fmt.Errorf("unsupported compressor %q", a.Value)
+			}
+			f.compressed = true
+		}
+	}
+	return nil
+}
+
+// decode 方法将一个 DataArray 中的数据解码为浮点数列表, 并检查其个数.
+func (f *xmlFile) decode(da *xmlDataArray) (vs []float64, err error) {
+	switch da.Format {
+	case "ascii":
+		for _, w := range strings.Fields(da.Content) {
+			v, err := strconv.ParseFloat(w, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", w)
+			}
+			vs = append(vs, v)
+		}
+	case "binary":
+		raw, err := f.decodeBinary(strings.TrimSpace(da.Content))
+		if err != nil {
+			return nil, err
+		}
+		if vs, err = f.convert(raw, da.Type); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported data array format %q", da.Format)
+	}
+	if da.numPoint < 0 {
+		return vs, nil
+	}
+	if da.NumComp <= 0 || len(vs) != da.numPoint*da.NumComp {
+		return nil, fmt.Errorf("%d values found, want %d", len(vs), da.numPoint*da.NumComp)
+	}
+	return vs, nil
+}
+
+// decodeBinary 方法对 base64 编码的 binary 格式数据进行解码. 未压缩数据的格式为
+// base64(头部 + 数据), 其头部为数据的字节数. 压缩数据的格式为 base64(头部) + base64(各压缩块),
+// 其头部依次为块数, 未压缩时的块大小, 最后一块未压缩时的大小以及各块压缩后的大小.
+func (f *xmlFile) decodeBinary(s string) ([]byte, error) {
+	hs := f.headerSize
+	if !f.compressed {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) < hs {
+			return nil, errors.New("binary data too short")
+		}
+		n := f.uint(b[:hs])
+		if uint64(len(b)-hs) < n {
+			return nil, errors.New("binary data too short")
+		}
+		return b[hs : hs+int(n)], nil
+	}
+	// 先解码出块数, 进而确定整个头部的长度
+	prefix := (hs + 2) / 3 * 4
+	if len(s) < prefix {
+		return nil, errors.New("binary data too short")
+	}
+	b, err := base64.StdEncoding.DecodeString(s[:prefix])
+	if err != nil {
+		return nil, err
+	}
+	nb := int(f.uint(b[:hs]))
+	hlen := (3 + nb) * hs
+	henc := (hlen + 2) / 3 * 4
+	if len(s) < henc {
+		return nil, errors.New("binary data too short")
+	}
+	header, err := base64.StdEncoding.DecodeString(s[:henc])
+	if err != nil {
+		return nil, err
+	}
+	body, err := base64.StdEncoding.DecodeString(s[henc:])
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	for i := 0; i < nb; i++ {
+		cs := int(f.uint(header[(3+i)*hs : (4+i)*hs]))
+		if cs > len(body) {
+			return nil, errors.New("compressed data too short")
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(body[:cs]))
+		if err != nil {
+			return nil, err
+		}
+		block, err := ioutil.ReadAll(zr)
+		if err != nil {
+			return nil, err
+		}
+		out.Write(block)
+		body = body[cs:]
+	}
+	return out.Bytes(), nil
+}
+
+// uint 方法按文件的字节序读出一个头部整数.
+func (f *xmlFile) uint(b []byte) uint64 {
+	if f.headerSize == 8 {
+		return f.byteOrder.Uint64(b)
+	}
+	return uint64(f.byteOrder.Uint32(b))
+}
+
+// convert 方法将二进制数据按数据类型 typ 转换为浮点数列表.
+func (f *xmlFile) convert(b []byte, typ string) ([]float64, error) {
+	size := map[string]int{
+		"Int8": 1, "UInt8": 1, "Int16": 2, "UInt16": 2, "Int32": 4, "UInt32": 4,
+		"Int64": 8, "UInt64": 8, "Float32": 4, "Float64": 8,
+	}[typ]
+	if size == 0 {
+		return nil, fmt.Errorf("unsupported data type %q", typ)
+	}
+	if len(b)%size != 0 {
+		return nil, fmt.Errorf("binary data length %d is not a multiple of %d", len(b), size)
+	}
+	bo := f.byteOrder
+	vs := make([]float64, len(b)/size)
+	for i := range vs {
+		e := b[i*size : (i+1)*size]
+		switch typ {
+		case "Int8":
+			vs[i] = float64(int8(e[0]))
+		case "UInt8":
+			vs[i] = float64(e[0])
+		case "Int16":
+			vs[i] = float64(int16(bo.Uint16(e)))
+		case "UInt16":
+			vs[i] = float64(bo.Uint16(e))
+		case "Int32":
+			vs[i] = float64(int32(bo.Uint32(e)))
+		case "UInt32":
+			vs[i] = float64(bo.Uint32(e))
+		case "Int64":
+			vs[i] = float64(int64(bo.Uint64(e)))
+		case "UInt64":
+			vs[i] = float64(bo.Uint64(e))
+		case "Float32":
+			vs[i] = float64(math.Float32frombits(bo.Uint32(e)))
+		case "Float64":
+			vs[i] = math.Float64frombits(bo.Uint64(e))
+		}
+	}
+	return vs, nil
+}
+
+// intAttr 返回元素 el 中名称为 name 的整数属性.
+func intAttr(el xml.StartElement, name string) (int, error) {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			n, err := strconv.Atoi(a.Value)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid %s %q", name, a.Value)
+			}
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("attribute %s not found in %s", name, el.Name.Local)
+}